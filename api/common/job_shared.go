@@ -1,7 +1,11 @@
 // +k8s:deepcopy-gen=package
 package common
 
+import batchv1 "k8s.io/api/batch/v1"
+
 // +kubebuilder:object:generate=true
+// +kubebuilder:validation:XValidation:rule="!has(self.completionMode) || self.completionMode != 'Indexed' || has(self.completions)",message="completions must be set when completionMode is Indexed"
+// +kubebuilder:validation:XValidation:rule="!has(self.backoffLimitPerIndex) || (has(self.completionMode) && self.completionMode == 'Indexed')",message="backoffLimitPerIndex requires completionMode Indexed"
 type JobSettings struct {
 	// ActiveDeadlineSeconds denotes a duration in seconds started from when the job is first active. If the deadline is reached during the job's workload
 	// the job and its Pods are terminated. If the job is suspended using the Suspend field, this timer is stopped and reset when unsuspended.
@@ -14,6 +18,25 @@ type JobSettings struct {
 	//+kubebuilder:validation:Optional
 	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
 
+	// Specifies the number of retry attempts for each index of an indexed job before the index is marked as failed.
+	// The other indexes keep running, and the Job fails when it has finished if any index failed. When set,
+	// BackoffLimit is not defaulted, and only limits the retries of the Job as a whole if set explicitly.
+	// Requires completionMode Indexed.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	BackoffLimitPerIndex *int32 `json:"backoffLimitPerIndex,omitempty"`
+
+	// Rules for handling failed Pods based on their container exit codes or Pod conditions, evaluated in order.
+	// FailJob fails the Job immediately, for example on exit codes that will not succeed on retry. Ignore does not
+	// count the failure towards BackoffLimit, for example on the DisruptionTarget condition set on node drains and
+	// evictions. Count counts the failure as usual, and FailIndex fails the index of an indexed job with
+	// BackoffLimitPerIndex. The rule that failed the Job is reported in the Failed condition.
+	// Requires restartPolicy Never.
+	//
+	//+kubebuilder:validation:Optional
+	PodFailurePolicy *batchv1.PodFailurePolicy `json:"podFailurePolicy,omitempty"`
+
 	// If set to true, this tells Kubernetes to suspend this Job till the field is set to false. If the Job is active while this field is set to false,
 	// all running Pods will be terminated.
 	//
@@ -25,4 +48,26 @@ type JobSettings struct {
 	//
 	//+kubebuilder:validation:Optional
 	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// The maximum number of Pods running at the same time. Defaults to 1.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	Parallelism *int32 `json:"parallelism,omitempty"`
+
+	// The number of Pods that must complete successfully for the Job to finish. Defaults to 1.
+	// Changing it for a one-off job recreates the Job, which runs it again.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	Completions *int32 `json:"completions,omitempty"`
+
+	// NonIndexed completes the Job when Completions Pods have succeeded. Indexed gives each Pod an index
+	// from 0 to Completions-1, available in the JOB_COMPLETION_INDEX environment variable, and completes
+	// the Job when every index has succeeded. Defaults to NonIndexed.
+	// Changing it for a one-off job recreates the Job, which runs it again.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=NonIndexed;Indexed
+	CompletionMode *batchv1.CompletionMode `json:"completionMode,omitempty"`
 }
@@ -8,6 +8,7 @@ import corev1 "k8s.io/api/core/v1"
 // +kubebuilder:object:generate=true
 // +kubebuilder:validation:XValidation:rule="!(self.name in ['cloudsql-proxy', 'istio-proxy', 'istio-validation', 'istio-init'])",message="container name is reserved"
 // +kubebuilder:validation:XValidation:rule="!has(self.ingressPort) || (has(self.additionalPorts) && self.additionalPorts.exists(p, p.port == self.ingressPort))",message="ingressPort must be declared in the container's additionalPorts"
+// +kubebuilder:validation:XValidation:rule="!has(self.type) || self.type != 'oneshot' || (!has(self.liveness) && !has(self.readiness) && !has(self.startup) && !has(self.ingressPort))",message="oneshot containers cannot have probes or an ingressPort"
 type ContainerSpec struct {
 	// Name of the container. Must be unique within the pod and must not collide
 	// with the application name or a reserved name (e.g. cloudsql-proxy,
@@ -28,8 +29,13 @@ type ContainerSpec struct {
 	//     container for the lifetime of the pod.
 	//   - "init": an init container that starts before the main container and
 	//     keeps running for the lifetime of the pod.
+	//   - "oneshot": an init container that runs to completion before the main
+	//     container starts, e.g. for database migrations or config rendering.
+	//     Oneshot and init containers start one at a time in the declared order,
+	//     and a failing oneshot container is restarted and keeps the pod from
+	//     starting. Failures are reported in the InitContainersSucceeded condition.
 	//
-	//+kubebuilder:validation:Enum=standard;init
+	//+kubebuilder:validation:Enum=standard;init;oneshot
 	//+kubebuilder:validation:Optional
 	Type string `json:"type,omitempty"`
 
@@ -86,6 +92,12 @@ type ContainerSpec struct {
 	//+kubebuilder:validation:Optional
 	Startup *Probe `json:"startup,omitempty"`
 
+	// SecurityContext overrides the UID and GID the container runs as, or adds capabilities.
+	// The rest of the least-privilege security context cannot be overridden.
+	//
+	//+kubebuilder:validation:Optional
+	SecurityContext *ContainerSecurityContext `json:"securityContext,omitempty"`
+
 	// When set, the application's ingress traffic enters the pod through this
 	// container instead of the main container: the generated Service keeps its
 	// external port (spec.port) but routes its target port to this container's
@@ -113,4 +125,7 @@ const (
 	// ContainerTypeInit is an init container, implemented as a native sidecar
 	// (init container with restartPolicy: Always).
 	ContainerTypeInit = "init"
+	// ContainerTypeOneshot is a regular init container that runs to completion
+	// before the main container starts.
+	ContainerTypeOneshot = "oneshot"
 )
@@ -1,5 +1,9 @@
 package podtypes
 
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +kubebuilder:object:generate=true
+// +kubebuilder:validation:XValidation:rule="!has(self.secretStore) || (!has(self.configMap) && !has(self.secret))",message="secretStore cannot be combined with configMap or secret"
 type EnvFrom struct {
 	// Name of Kubernetes ConfigMap in which the deployment should mount environment variables from. Must be in the same namespace as the Application
 	//
@@ -10,15 +14,21 @@ type EnvFrom struct {
 	//
 	// +kubebuilder:validation:Optional
 	Secret string `json:"secret,omitempty"`
+
+	// Secret in an external secret manager, synced into the namespace by External Secrets Operator.
+	// All keys of the secret are assigned as environment variables.
+	//
+	// +kubebuilder:validation:Optional
+	SecretStore *SecretStoreSource `json:"secretStore,omitempty"`
 }
 
 // FilesFrom
 //
 // Struct representing information needed to mount a Kubernetes resource as a file to a Pod's directory.
-// One of ConfigMap, Secret, EmptyDir or PersistentVolumeClaim must be present, and just represent the name of the resource in question
+// One of ConfigMap, Secret, SecretStore, EmptyDir or PersistentVolumeClaim must be present, and just represent the name of the resource in question
 // NB. Out-of-the-box, skiperator provides a writable 'emptyDir'-volume at '/tmp'
 // +kubebuilder:object:generate=true
-// +kubebuilder:validation:XValidation:rule="(has(self.configMap) ? 1 : 0) + (has(self.secret) ? 1 : 0) + (has(self.emptyDir) ? 1 : 0) + (has(self.persistentVolumeClaim) ? 1 : 0) == 1",message="Exactly one of configMap, secret, emptyDir or persistentVolumeClaim must be set"
+// +kubebuilder:validation:XValidation:rule="(has(self.configMap) ? 1 : 0) + (has(self.secret) ? 1 : 0) + (has(self.emptyDir) ? 1 : 0) + (has(self.persistentVolumeClaim) ? 1 : 0) + (has(self.secretStore) ? 1 : 0) == 1",message="Exactly one of configMap, secret, secretStore, emptyDir or persistentVolumeClaim must be set"
 type FilesFrom struct {
 	// The path to mount the file in the Pods directory. Required.
 	//
@@ -42,6 +52,10 @@ type FilesFrom struct {
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:MinLength=1
 	PersistentVolumeClaim string `json:"persistentVolumeClaim,omitempty"`
+	// Secret in an external secret manager, synced into the namespace by External Secrets Operator
+	// and mounted with one file per key.
+	// +kubebuilder:validation:Optional
+	SecretStore *SecretStoreSource `json:"secretStore,omitempty"`
 	// defaultMode is optional: mode bits used to set permissions on created files by default.
 	// Must be between 0000 and 0777 when written as YAML octal, or between 0 and 511 as JSON/decimal.
 	// YAML values with a leading zero are parsed as octal before CRD validation, so 0777 is validated as 511.
@@ -54,3 +68,71 @@ type FilesFrom struct {
 	// +kubebuilder:validation:Optional
 	DefaultMode *int `json:"defaultMode,omitempty"`
 }
+
+// SecretStoreSource
+//
+// Reference to a secret in an external secret manager such as GCP Secret Manager or Vault.
+// Skiperator generates an ExternalSecret for it, and External Secrets Operator syncs the secret
+// into a Kubernetes Secret owned by the workload. The workload is not reported as synced until
+// the secret has been synced.
+// +kubebuilder:object:generate=true
+type SecretStoreSource struct {
+	// Name of the SecretStore or ClusterSecretStore to read the secret from.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Kind of the store. A SecretStore must be in the same namespace as the workload.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=ClusterSecretStore;SecretStore
+	// +kubebuilder:default=ClusterSecretStore
+	Kind string `json:"kind,omitempty"`
+
+	// Key of the secret in the secret manager, e.g. the secret name in GCP Secret Manager or the
+	// path in Vault. The secret value must be a JSON object, whose properties become the keys of the
+	// Kubernetes Secret.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	RemoteKey string `json:"remoteKey"`
+
+	// How often the secret is read from the secret manager. Defaults to 1h.
+	//
+	// +kubebuilder:validation:Optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// EnvFromKey
+//
+// Projects a single key of a ConfigMap or Secret into an environment variable. Exactly one of
+// ConfigMap or Secret must be set. Skiperator checks that the key exists while reconciling, and
+// reports a missing key in the status instead of letting the Pod fail to start.
+// +kubebuilder:object:generate=true
+// +kubebuilder:validation:XValidation:rule="has(self.configMap) != has(self.secret)",message="Exactly one of configMap or secret must be set"
+type EnvFromKey struct {
+	// Name of the ConfigMap to read the key from. Must be in the same namespace as the workload.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// Name of the Secret to read the key from. Must be in the same namespace as the workload.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	Secret string `json:"secret,omitempty"`
+
+	// The key in the ConfigMap or Secret.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+
+	// Name of the environment variable the value is assigned to.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z][-._a-zA-Z0-9]*$`
+	As string `json:"as"`
+}
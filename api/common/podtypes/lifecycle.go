@@ -0,0 +1,58 @@
+package podtypes
+
+// Lifecycle
+//
+// Hooks run by the kubelet when the main container starts or is about to be stopped.
+//
+// +kubebuilder:object:generate=true
+type Lifecycle struct {
+	// PreStop runs before the container receives SIGTERM. The time spent in the hook counts
+	// towards terminationGracePeriodSeconds.
+	//
+	//+kubebuilder:validation:Optional
+	PreStop *LifecycleHandler `json:"preStop,omitempty"`
+
+	// PostStart runs right after the container is created. The container is not marked as
+	// running until the hook completes.
+	//
+	//+kubebuilder:validation:Optional
+	PostStart *LifecycleHandler `json:"postStart,omitempty"`
+}
+
+// LifecycleHandler
+//
+// Action taken by a lifecycle hook. Exactly one of exec or sleepSeconds must be set.
+//
+// +kubebuilder:object:generate=true
+// +kubebuilder:validation:XValidation:rule="has(self.exec) != has(self.sleepSeconds)",message="exactly one of exec or sleepSeconds must be set"
+type LifecycleHandler struct {
+	// Exec runs a command inside the container.
+	//
+	//+kubebuilder:validation:Optional
+	Exec *ExecProbe `json:"exec,omitempty"`
+
+	// SleepSeconds pauses for the given number of seconds using the kubelet's built-in sleep,
+	// so the image does not need to ship a sleep binary.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=300
+	SleepSeconds *int64 `json:"sleepSeconds,omitempty"`
+}
+
+// GracefulShutdown
+//
+// Shorthand for draining traffic before the container is stopped.
+//
+// +kubebuilder:object:generate=true
+type GracefulShutdown struct {
+	// DrainSeconds is how long the Pod keeps serving after it has been marked as terminating,
+	// giving load balancers and sidecars time to stop sending it new requests. It adds a
+	// preStop sleep of this length, and terminationGracePeriodSeconds is extended by the
+	// same amount so the application keeps its full shutdown budget after the drain.
+	//
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=300
+	DrainSeconds int64 `json:"drainSeconds"`
+}
@@ -1,5 +1,7 @@
 package podtypes
 
+import corev1 "k8s.io/api/core/v1"
+
 // PodSettings
 //
 // +kubebuilder:object:generate=true
@@ -22,4 +24,33 @@ type PodSettings struct {
 	//+kubebuilder:validation:Optional
 	//+kubebuilder:default:=false
 	DisablePodSpreadTopologyConstraints bool `json:"disablePodSpreadTopologyConstraints,omitempty"`
+
+	// NodeSelector schedules Pods only on nodes with all the given labels. The cluster may restrict
+	// which node labels can be used.
+	//
+	//+kubebuilder:validation:Optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations allow Pods to be scheduled on nodes with matching taints, such as dedicated
+	// or spot node pools. The cluster may restrict which taints can be tolerated.
+	//
+	//+kubebuilder:validation:Optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity sets node affinity and Pod (anti-)affinity rules for the Pods.
+	//
+	//+kubebuilder:validation:Optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// TopologySpreadConstraints replaces the default spread constraints Skiperator adds to Pods.
+	// Remember to set a labelSelector matching the Pods, e.g. app: <name>.
+	//
+	//+kubebuilder:validation:Optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// SecurityContext overrides the UID, GID and fsGroup Skiperator runs the Pod's containers
+	// with. Use this for images that need a specific user, or volume permissions for another group.
+	//
+	//+kubebuilder:validation:Optional
+	SecurityContext *PodSecurityContext `json:"securityContext,omitempty"`
 }
@@ -4,17 +4,40 @@ import "k8s.io/apimachinery/pkg/util/intstr"
 
 // Probe
 //
-// Type configuration for all types of Kubernetes probes.
+// Type configuration for all types of Kubernetes probes. Exactly one of path (HTTP GET),
+// exec, tcpSocket or grpc must be set.
+//
+// +kubebuilder:object:generate=true
+// +kubebuilder:validation:XValidation:rule="[has(self.path), has(self.exec), has(self.tcpSocket), has(self.grpc)].filter(x, x).size() == 1",message="exactly one of path, exec, tcpSocket or grpc must be set"
+// +kubebuilder:validation:XValidation:rule="has(self.exec) || has(self.port)",message="port is required unless exec is set"
+// +kubebuilder:validation:XValidation:rule="!has(self.grpc) || type(self.port) == int",message="grpc probes require a numeric port"
 type Probe struct {
-	// Number of the port to access on the container
+	// Number of the port to access on the container. Not used by exec probes.
 	//
-	//+kubebuilder:validation:Required
-	Port intstr.IntOrString `json:"port"`
+	//+kubebuilder:validation:Optional
+	Port intstr.IntOrString `json:"port,omitzero"`
 
 	// The path to access on the HTTP server
 	//
-	//+kubebuilder:validation:Required
-	Path string `json:"path"`
+	//+kubebuilder:validation:Optional
+	Path string `json:"path,omitempty"`
+
+	// GRPC probes the port with the standard gRPC health checking protocol
+	// (grpc.health.v1.Health/Check) instead of an HTTP GET.
+	//
+	//+kubebuilder:validation:Optional
+	GRPC *GRPCProbe `json:"grpc,omitempty"`
+
+	// Exec runs a command inside the container. The probe succeeds if the command exits with 0.
+	//
+	//+kubebuilder:validation:Optional
+	Exec *ExecProbe `json:"exec,omitempty"`
+
+	// TCPSocket succeeds if a TCP connection to the port can be opened. Useful for
+	// databases and other services without an HTTP endpoint.
+	//
+	//+kubebuilder:validation:Optional
+	TCPSocket *TCPSocketProbe `json:"tcpSocket,omitempty"`
 
 	// Delay sending the first probe by X seconds. Can be useful for applications that
 	// are slow to start.
@@ -50,3 +73,37 @@ type Probe struct {
 	//+kubebuilder:validation:Optional
 	FailureThreshold int32 `json:"failureThreshold,omitempty"`
 }
+
+// GRPCProbe
+//
+// Settings for probes using the gRPC health checking protocol.
+//
+// +kubebuilder:object:generate=true
+type GRPCProbe struct {
+	// Service is the name of the service to place in the gRPC HealthCheckRequest.
+	// If unset, the overall health of the server is checked.
+	//
+	//+kubebuilder:validation:Optional
+	Service *string `json:"service,omitempty"`
+}
+
+// ExecProbe
+//
+// Settings for probes running a command in the container.
+//
+// +kubebuilder:object:generate=true
+type ExecProbe struct {
+	// Command to run. It is not run in a shell, so wrap it in e.g. ["sh", "-c", "..."]
+	// to use pipes or environment variables.
+	//
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
+}
+
+// TCPSocketProbe
+//
+// Settings for probes opening a TCP connection to the probe port.
+//
+// +kubebuilder:object:generate=true
+type TCPSocketProbe struct{}
@@ -0,0 +1,33 @@
+package podtypes
+
+import "k8s.io/apimachinery/pkg/api/resource"
+
+// ScratchVolume
+//
+// A writable, size-limited emptyDir mounted into the main container. Useful for caches and
+// temporary files, since the root filesystem of the container is read-only.
+//
+// +kubebuilder:object:generate=true
+type ScratchVolume struct {
+	// Path the volume is mounted at. A scratch volume at /tmp replaces the built-in /tmp
+	// volume, so its size limit and medium also apply to /tmp in extra containers.
+	//
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path"`
+
+	// SizeLimit is the maximum amount of storage the volume may use. Exceeding it gets the
+	// Pod evicted. Disk-backed volumes are added to the container's ephemeral-storage
+	// request, and memory-backed volumes count towards the container's memory limit.
+	//
+	//+kubebuilder:validation:Required
+	SizeLimit resource.Quantity `json:"sizeLimit"`
+
+	// Medium backing the volume. Memory uses a tmpfs, which is faster but is counted
+	// as memory used by the container.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=Disk;Memory
+	//+kubebuilder:default=Disk
+	Medium string `json:"medium,omitempty"`
+}
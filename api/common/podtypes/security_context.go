@@ -0,0 +1,60 @@
+package podtypes
+
+import corev1 "k8s.io/api/core/v1"
+
+// PodSecurityContext
+//
+// Pod-wide overrides of the hardened security context Skiperator sets on Pods. The cluster
+// may restrict which IDs can be requested.
+//
+// +kubebuilder:object:generate=true
+type PodSecurityContext struct {
+	// RunAsUser is the UID the main container and extra containers run as, unless a
+	// container sets its own. Defaults to 150.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+
+	// RunAsGroup is the primary GID the main container and extra containers run as, unless
+	// a container sets its own. Defaults to 150.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+
+	// FSGroup owns mounted volumes, so containers running as other UIDs can write to them.
+	// Defaults to 150.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+}
+
+// ContainerSecurityContext
+//
+// Overrides of the hardened security context Skiperator sets on a container. Containers
+// still run as non-root with a read-only root filesystem and all other capabilities dropped.
+// The cluster may restrict which IDs and capabilities can be requested.
+//
+// +kubebuilder:object:generate=true
+type ContainerSecurityContext struct {
+	// RunAsUser is the UID the container runs as. Takes precedence over podSettings.securityContext.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+
+	// RunAsGroup is the primary GID the container runs as. Takes precedence over
+	// podSettings.securityContext.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+
+	// AddCapabilities are Linux capabilities to add to the container, ie NET_BIND_SERVICE.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:MaxItems=5
+	AddCapabilities []corev1.Capability `json:"addCapabilities,omitempty"`
+}
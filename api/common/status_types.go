@@ -13,6 +13,10 @@ import (
 // A status field shown on a Skiperator resource which contains information regarding deployment of the resource.
 // +kubebuilder:object:generate=true
 type SkiperatorStatus struct {
+	// The generation of the resource that was last reconciled by Skiperator. The Ready, Reconciling and Stalled
+	// conditions describe this generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Legacy summary of the status, kept for compatibility. Prefer the Ready, Reconciling and Stalled conditions.
 	Summary            Status             `json:"summary"`
 	SubResources       map[string]Status  `json:"subresources"`
 	Conditions         []metav1.Condition `json:"conditions"`
@@ -21,6 +25,19 @@ type SkiperatorStatus struct {
 	AccessPolicies StatusNames `json:"accessPolicies"`
 }
 
+// ManualTriggerStatus
+//
+// Records the Job started for a skiperator.kartverket.no/trigger annotation on a scheduled SKIPJob.
+// +kubebuilder:object:generate=true
+type ManualTriggerStatus struct {
+	// Value of the trigger annotation that started the run
+	Trigger string `json:"trigger"`
+	// Name of the Job started for the trigger
+	JobName string `json:"jobName"`
+	// When the Job was started
+	TriggeredAt metav1.Time `json:"triggeredAt"`
+}
+
 // Status
 //
 // +kubebuilder:object:generate=true
@@ -43,15 +60,37 @@ const (
 	INVALIDCONFIG StatusNames = "InvalidConfig"
 
 	ReadyConditionType                = "Ready"
+	ReconcilingConditionType          = "Reconciling"
+	StalledConditionType              = "Stalled"
 	StandardRoutingReadyConditionType = "StandardRoutingReady"
 	LegacyRoutingActiveConditionType  = "LegacyRoutingActive"
 	SharedRoutingResourcesType        = "SharedRoutingResources"
 	RoutePathConflictType             = "RoutePathConflict"
+	InitContainersSucceededType       = "InitContainersSucceeded"
+	PreDeploySucceededType            = "PreDeploySucceeded"
+	AvailableConditionType            = "Available"
+	ProgressingConditionType          = "Progressing"
+	RolloutFailedConditionType        = "RolloutFailed"
+	RolledBackConditionType           = "RolledBack"
 
 	// MigrationStalledReason is the condition reason written when a Gateway API
 	// migration has kept legacy routing active past the deadline. Shared so the
 	// usage metrics package counts exactly the reason gwapi writes.
 	MigrationStalledReason = "MigrationStalled"
+
+	// Reasons of a Ready condition that is not True, which SetKStatusConditions
+	// treats as idle or stalled rather than reconciling.
+	JobPendingReason           = "JobPending"
+	JobSuspendedReason         = "JobSuspended"
+	InvalidConfigReason        = "InvalidConfig"
+	InvalidApplicationReason   = "InvalidApplication"
+	InvalidSKIPJobReason       = "InvalidSKIPJob"
+	ImagePolicyViolationReason = "ImagePolicyViolation"
+	TLSRouteUnavailableReason  = "TLSRouteUnavailable"
+	NamespaceNotInMeshReason   = "NamespaceNotInMesh"
+	GatewayAPIConflictReason   = "GatewayAPIConflict"
+	DependencyFailedReason     = "DependencyFailed"
+	JobFailedReason            = "JobFailed"
 )
 
 // conditionOrder is the canonical order status conditions are persisted in.
@@ -63,15 +102,21 @@ const (
 // the SKIPJob types, so it cannot import them back.
 var conditionOrder = map[string]int{
 	ReadyConditionType:                0,
-	"Failed":                          1,
-	"Running":                         2,
-	"Finished":                        3,
-	"InternalRulesValid":              4,
-	"ExternalRulesValid":              5,
-	LegacyRoutingActiveConditionType:  6,
-	StandardRoutingReadyConditionType: 7,
-	SharedRoutingResourcesType:        8,
-	RoutePathConflictType:             9,
+	ReconcilingConditionType:          1,
+	StalledConditionType:              2,
+	"Failed":                          3,
+	"Running":                         4,
+	"Finished":                        5,
+	"InternalRulesValid":              6,
+	"ExternalRulesValid":              7,
+	LegacyRoutingActiveConditionType:  8,
+	StandardRoutingReadyConditionType: 9,
+	SharedRoutingResourcesType:        10,
+	RoutePathConflictType:             11,
+	AvailableConditionType:            12,
+	ProgressingConditionType:          13,
+	RolloutFailedConditionType:        14,
+	RolledBackConditionType:           15,
 }
 
 // SortConditions orders Conditions canonically (see conditionOrder), so the
@@ -149,6 +194,9 @@ func (s *SkiperatorStatus) setCondition(conditionType string, status metav1.Cond
 	})
 }
 
+// SetReadyCondition records whether the resources of the object have been reconciled. For Applications it does not
+// follow the rollout of the workload: Ready and the summary stay Synced while RolloutFailed is True, as the
+// resources are in place and the failed rollout is reported by the RolloutFailed and Stalled conditions.
 func (s *SkiperatorStatus) SetReadyCondition(status metav1.ConditionStatus, observedGeneration int64, reason string, message string) {
 	s.setCondition(ReadyConditionType, status, observedGeneration, reason, message)
 }
@@ -173,6 +221,102 @@ func (s *SkiperatorStatus) SetRoutePathConflictCondition(status metav1.Condition
 	s.setCondition(RoutePathConflictType, status, observedGeneration, reason, message)
 }
 
+// SetInitContainersSucceededCondition records whether the one-shot init
+// containers of the workload's pods have run to completion.
+func (s *SkiperatorStatus) SetInitContainersSucceededCondition(status metav1.ConditionStatus, observedGeneration int64, reason string, message string) {
+	s.setCondition(InitContainersSucceededType, status, observedGeneration, reason, message)
+}
+
+// SetPreDeploySucceededCondition records whether the pre-deploy Job for the
+// current image has succeeded, which the rollout of the image waits for.
+func (s *SkiperatorStatus) SetPreDeploySucceededCondition(status metav1.ConditionStatus, observedGeneration int64, reason string, message string) {
+	s.setCondition(PreDeploySucceededType, status, observedGeneration, reason, message)
+}
+
+// SetAvailableCondition records whether the workload's pods are available, as
+// reported by the Deployment or StatefulSet.
+func (s *SkiperatorStatus) SetAvailableCondition(status metav1.ConditionStatus, observedGeneration int64, reason string, message string) {
+	s.setCondition(AvailableConditionType, status, observedGeneration, reason, message)
+}
+
+// SetProgressingCondition records whether the workload is rolling out the
+// current pod template.
+func (s *SkiperatorStatus) SetProgressingCondition(status metav1.ConditionStatus, observedGeneration int64, reason string, message string) {
+	s.setCondition(ProgressingConditionType, status, observedGeneration, reason, message)
+}
+
+// SetRolloutFailedCondition records whether the rollout of the current pod
+// template has failed, because its pods cannot start or it made no progress.
+// It does not change the Ready condition, see SetReadyCondition.
+func (s *SkiperatorStatus) SetRolloutFailedCondition(status metav1.ConditionStatus, observedGeneration int64, reason string, message string) {
+	s.setCondition(RolloutFailedConditionType, status, observedGeneration, reason, message)
+}
+
+// SetRolledBackCondition records whether the workload runs a rolled back image
+// instead of the image in spec, after a failed rollout.
+func (s *SkiperatorStatus) SetRolledBackCondition(status metav1.ConditionStatus, observedGeneration int64, reason string, message string) {
+	s.setCondition(RolledBackConditionType, status, observedGeneration, reason, message)
+}
+
+// SetKStatusConditions records the generation as observed and derives the
+// Reconciling and Stalled conditions, following the kstatus conventions used by
+// Argo CD, Flux and kubectl wait. A Ready condition that is not True makes the
+// resource Stalled when its reason needs a change to the resource or its
+// environment, see stalledReadyReasons, and Reconciling otherwise. A
+// Progressing workload makes it Reconciling and a failed rollout makes it
+// Stalled. Both are abnormal-true conditions, so they are removed rather than
+// set to False.
+func (s *SkiperatorStatus) SetKStatusConditions(generation int64) {
+	s.ObservedGeneration = generation
+	meta.RemoveStatusCondition(&s.Conditions, ReconcilingConditionType)
+	meta.RemoveStatusCondition(&s.Conditions, StalledConditionType)
+
+	ready := meta.FindStatusCondition(s.Conditions, ReadyConditionType)
+	switch {
+	case ready == nil:
+		s.setCondition(ReconcilingConditionType, metav1.ConditionTrue, generation, "Reconciling", "Awaiting first reconcile")
+	case ready.Status == metav1.ConditionTrue || idleReadyReasons[ready.Reason]:
+	case ready.Status == metav1.ConditionFalse && stalledReadyReasons[ready.Reason]:
+		s.setCondition(StalledConditionType, metav1.ConditionTrue, generation, ready.Reason, ready.Message)
+	default:
+		s.setCondition(ReconcilingConditionType, metav1.ConditionTrue, generation, ready.Reason, ready.Message)
+	}
+
+	if progressing := meta.FindStatusCondition(s.Conditions, ProgressingConditionType); progressing != nil && progressing.Status == metav1.ConditionTrue &&
+		meta.FindStatusCondition(s.Conditions, ReconcilingConditionType) == nil {
+		s.setCondition(ReconcilingConditionType, metav1.ConditionTrue, generation, progressing.Reason, progressing.Message)
+	}
+	if failed := meta.FindStatusCondition(s.Conditions, RolloutFailedConditionType); failed != nil && failed.Status == metav1.ConditionTrue &&
+		meta.FindStatusCondition(s.Conditions, StalledConditionType) == nil {
+		s.setCondition(StalledConditionType, metav1.ConditionTrue, generation, failed.Reason, failed.Message)
+	}
+}
+
+// idleReadyReasons are reasons of a Ready condition that is not True while
+// Skiperator has nothing to reconcile, e.g. a scheduled SKIPJob waiting for its
+// next run.
+var idleReadyReasons = map[string]bool{
+	JobPendingReason:   true,
+	JobSuspendedReason: true,
+}
+
+// stalledReadyReasons are reasons of a False Ready condition that are not
+// retried, as they do not resolve without a change to the resource or its
+// environment. Other reasons, e.g. failures to reach the API server or an
+// image registry, are retried and make the resource Reconciling.
+var stalledReadyReasons = map[string]bool{
+	InvalidConfigReason:        true,
+	InvalidApplicationReason:   true,
+	InvalidSKIPJobReason:       true,
+	ImagePolicyViolationReason: true,
+	TLSRouteUnavailableReason:  true,
+	NamespaceNotInMeshReason:   true,
+	GatewayAPIConflictReason:   true,
+	DependencyFailedReason:     true,
+	JobFailedReason:            true,
+	MigrationStalledReason:     true,
+}
+
 func (s *SkiperatorStatus) AddSubResourceStatus(object client.Object, message string, status StatusNames) {
 	if s.SubResources == nil {
 		s.SubResources = map[string]Status{}
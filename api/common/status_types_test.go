@@ -63,3 +63,123 @@ func TestSetSharedRoutingResourcesCondition(t *testing.T) {
 		assert.Equal(t, "shared routing resources are active", requirement.Message)
 	}
 }
+
+func TestSetKStatusConditions(t *testing.T) {
+	status := &SkiperatorStatus{}
+
+	status.SetKStatusConditions(7)
+	assert.Equal(t, int64(7), status.ObservedGeneration)
+	assert.True(t, meta.IsStatusConditionTrue(status.Conditions, ReconcilingConditionType))
+
+	status.SetReadyCondition(metav1.ConditionUnknown, 7, "Reconciling", "reconciling")
+	status.SetKStatusConditions(7)
+	assert.True(t, meta.IsStatusConditionTrue(status.Conditions, ReconcilingConditionType))
+	assert.Nil(t, meta.FindStatusCondition(status.Conditions, StalledConditionType))
+
+	status.SetReadyCondition(metav1.ConditionFalse, 7, InvalidConfigReason, "invalid")
+	status.SetKStatusConditions(7)
+	assert.Nil(t, meta.FindStatusCondition(status.Conditions, ReconcilingConditionType))
+	stalled := meta.FindStatusCondition(status.Conditions, StalledConditionType)
+	if assert.NotNil(t, stalled) {
+		assert.Equal(t, metav1.ConditionTrue, stalled.Status)
+		assert.Equal(t, InvalidConfigReason, stalled.Reason)
+		assert.Equal(t, "invalid", stalled.Message)
+	}
+
+	// Waiting for standard routing resolves on its own
+	status.SetReadyCondition(metav1.ConditionFalse, 7, "StandardRoutingNotReady", "not ready")
+	status.SetKStatusConditions(7)
+	assert.True(t, meta.IsStatusConditionTrue(status.Conditions, ReconcilingConditionType))
+	assert.Nil(t, meta.FindStatusCondition(status.Conditions, StalledConditionType))
+
+	// Failures that are retried make the resource Reconciling
+	status.SetReadyCondition(metav1.ConditionFalse, 7, "ImagePolicyFailure", "registry unavailable")
+	status.SetKStatusConditions(7)
+	reconciling := meta.FindStatusCondition(status.Conditions, ReconcilingConditionType)
+	if assert.NotNil(t, reconciling) {
+		assert.Equal(t, "ImagePolicyFailure", reconciling.Reason)
+	}
+	assert.Nil(t, meta.FindStatusCondition(status.Conditions, StalledConditionType))
+
+	// Reconciling and Stalled are removed rather than set to False
+	status.SetReadyCondition(metav1.ConditionTrue, 8, "Reconciled", "ready")
+	status.SetKStatusConditions(8)
+	assert.Equal(t, int64(8), status.ObservedGeneration)
+	assert.Len(t, status.Conditions, 1)
+
+	status.SetReadyCondition(metav1.ConditionUnknown, 8, JobSuspendedReason, "suspended")
+	status.SetKStatusConditions(8)
+	assert.Len(t, status.Conditions, 1)
+}
+
+func TestSetKStatusConditionsReasons(t *testing.T) {
+	for _, reason := range []string{JobPendingReason, JobSuspendedReason} {
+		t.Run(reason, func(t *testing.T) {
+			status := &SkiperatorStatus{}
+			status.SetReadyCondition(metav1.ConditionUnknown, 1, reason, "idle")
+			status.SetKStatusConditions(1)
+			assert.Nil(t, meta.FindStatusCondition(status.Conditions, ReconcilingConditionType))
+			assert.Nil(t, meta.FindStatusCondition(status.Conditions, StalledConditionType))
+		})
+	}
+
+	for _, reason := range []string{
+		InvalidConfigReason,
+		InvalidApplicationReason,
+		InvalidSKIPJobReason,
+		ImagePolicyViolationReason,
+		TLSRouteUnavailableReason,
+		NamespaceNotInMeshReason,
+		GatewayAPIConflictReason,
+		DependencyFailedReason,
+		JobFailedReason,
+		MigrationStalledReason,
+	} {
+		t.Run(reason, func(t *testing.T) {
+			status := &SkiperatorStatus{}
+			status.SetReadyCondition(metav1.ConditionFalse, 1, reason, "stalled")
+			status.SetKStatusConditions(1)
+			assert.Nil(t, meta.FindStatusCondition(status.Conditions, ReconcilingConditionType))
+			stalled := meta.FindStatusCondition(status.Conditions, StalledConditionType)
+			if assert.NotNil(t, stalled) {
+				assert.Equal(t, reason, stalled.Reason)
+			}
+		})
+	}
+}
+
+func TestSetKStatusConditionsFromRollout(t *testing.T) {
+	status := &SkiperatorStatus{}
+	status.SetReadyCondition(metav1.ConditionTrue, 3, "Reconciled", "ready")
+	status.SetProgressingCondition(metav1.ConditionTrue, 3, "RollingOut", "1 of 2 pods updated")
+	status.SetRolloutFailedCondition(metav1.ConditionFalse, 3, "NoFailure", "no failure")
+
+	status.SetKStatusConditions(3)
+	reconciling := meta.FindStatusCondition(status.Conditions, ReconcilingConditionType)
+	if assert.NotNil(t, reconciling) {
+		assert.Equal(t, "RollingOut", reconciling.Reason)
+		assert.Equal(t, "1 of 2 pods updated", reconciling.Message)
+	}
+	assert.Nil(t, meta.FindStatusCondition(status.Conditions, StalledConditionType))
+
+	status.SetRolloutFailedCondition(metav1.ConditionTrue, 3, "ImagePullBackOff", "cannot pull image")
+	status.SetKStatusConditions(3)
+	stalled := meta.FindStatusCondition(status.Conditions, StalledConditionType)
+	if assert.NotNil(t, stalled) {
+		assert.Equal(t, "ImagePullBackOff", stalled.Reason)
+		assert.Equal(t, "cannot pull image", stalled.Message)
+	}
+
+	// The Ready condition takes precedence over the rollout
+	status.SetReadyCondition(metav1.ConditionFalse, 3, "InvalidConfig", "invalid")
+	status.SetKStatusConditions(3)
+	assert.Equal(t, "InvalidConfig", meta.FindStatusCondition(status.Conditions, StalledConditionType).Reason)
+	assert.Equal(t, "RollingOut", meta.FindStatusCondition(status.Conditions, ReconcilingConditionType).Reason)
+
+	status.SetReadyCondition(metav1.ConditionTrue, 3, "Reconciled", "ready")
+	status.SetProgressingCondition(metav1.ConditionFalse, 3, "RolloutComplete", "complete")
+	status.SetRolloutFailedCondition(metav1.ConditionFalse, 3, "NoFailure", "no failure")
+	status.SetKStatusConditions(3)
+	assert.Nil(t, meta.FindStatusCondition(status.Conditions, ReconcilingConditionType))
+	assert.Nil(t, meta.FindStatusCondition(status.Conditions, StalledConditionType))
+}
@@ -7,7 +7,8 @@ package common
 import (
 	"github.com/kartverket/skiperator/api/common/istiotypes"
 	"github.com/kartverket/skiperator/api/common/podtypes"
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -139,6 +140,16 @@ func (in *JobSettings) DeepCopyInto(out *JobSettings) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.BackoffLimitPerIndex != nil {
+		in, out := &in.BackoffLimitPerIndex, &out.BackoffLimitPerIndex
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PodFailurePolicy != nil {
+		in, out := &in.PodFailurePolicy, &out.PodFailurePolicy
+		*out = new(v1.PodFailurePolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Suspend != nil {
 		in, out := &in.Suspend, &out.Suspend
 		*out = new(bool)
@@ -149,6 +160,21 @@ func (in *JobSettings) DeepCopyInto(out *JobSettings) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Parallelism != nil {
+		in, out := &in.Parallelism, &out.Parallelism
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Completions != nil {
+		in, out := &in.Completions, &out.Completions
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CompletionMode != nil {
+		in, out := &in.CompletionMode, &out.CompletionMode
+		*out = new(v1.CompletionMode)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobSettings.
@@ -161,6 +187,22 @@ func (in *JobSettings) DeepCopy() *JobSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManualTriggerStatus) DeepCopyInto(out *ManualTriggerStatus) {
+	*out = *in
+	in.TriggeredAt.DeepCopyInto(&out.TriggeredAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManualTriggerStatus.
+func (in *ManualTriggerStatus) DeepCopy() *ManualTriggerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ManualTriggerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SkiperatorStatus) DeepCopyInto(out *SkiperatorStatus) {
 	*out = *in
@@ -174,7 +216,7 @@ func (in *SkiperatorStatus) DeepCopyInto(out *SkiperatorStatus) {
 	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
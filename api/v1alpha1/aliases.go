@@ -44,6 +44,8 @@ type ExternalPort = commonpodtypes.ExternalPort
 // files from env
 type EnvFrom = commonpodtypes.EnvFrom
 type FilesFrom = commonpodtypes.FilesFrom
+type EnvFromKey = commonpodtypes.EnvFromKey
+type SecretStoreSource = commonpodtypes.SecretStoreSource
 
 // GCP
 type GCP = commonpodtypes.GCP
@@ -59,6 +61,18 @@ type ContainerSpec = commonpodtypes.ContainerSpec
 // pod settings
 type PodSettings = commonpodtypes.PodSettings
 
+// lifecycle
+type Lifecycle = commonpodtypes.Lifecycle
+type LifecycleHandler = commonpodtypes.LifecycleHandler
+type GracefulShutdown = commonpodtypes.GracefulShutdown
+
+// security context
+type PodSecurityContext = commonpodtypes.PodSecurityContext
+type ContainerSecurityContext = commonpodtypes.ContainerSecurityContext
+
+// scratch volumes
+type ScratchVolume = commonpodtypes.ScratchVolume
+
 // probe
 type Probe = commonpodtypes.Probe
 
@@ -78,6 +92,7 @@ type JobSettings = commontypes.JobSettings
 type SkiperatorStatus = commontypes.SkiperatorStatus
 type Status = commontypes.Status
 type StatusNames = commontypes.StatusNames
+type ManualTriggerStatus = commontypes.ManualTriggerStatus
 
 const (
 	SYNCED        = commontypes.SYNCED
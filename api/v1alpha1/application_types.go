@@ -35,6 +35,28 @@ type ApplicationStatus struct {
 	// Kind generated for this Application after a successful reconcile.
 	// Used to prevent switching between Deployment and StatefulSet.
 	ApplicationKind ApplicationKind `json:"applicationKind,omitempty"`
+	// Latest ready VolumeSnapshot of each PVC when spec.stateful.backup is set
+	Backups []StatefulBackupStatus `json:"backups,omitempty"`
+	// PVCs whose volume is being expanded to the storage requested in spec.stateful.volumeClaimTemplates
+	VolumeResizes []VolumeClaimResizeStatus `json:"volumeResizes,omitempty"`
+	// Number of pods of the Deployment or StatefulSet
+	Replicas int32 `json:"replicas,omitempty"`
+	// Number of pods that are ready
+	//+kubebuilder:validation:Optional
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// Number of pods that run the current pod template
+	//+kubebuilder:validation:Optional
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+	// Number of pods that have been ready for at least minReadySeconds
+	//+kubebuilder:validation:Optional
+	AvailableReplicas int32 `json:"availableReplicas"`
+	// Image of the application container in the last rollout that became fully available, when
+	// spec.strategy.autoRollback is set
+	LastHealthyImage string `json:"lastHealthyImage,omitempty"`
+	// Rollback of a failed rollout to LastHealthyImage, kept until the spec changes
+	Rollback *RollbackStatus `json:"rollback,omitempty"`
+	// Progress of the rollout when spec.stateful.rollout is staged
+	StatefulRollout *StatefulRolloutStatus `json:"statefulRollout,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -55,9 +77,16 @@ type ApplicationList struct {
 // +kubebuilder:resource:shortName="app"
 // +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
 // +kubebuilder:printcolumn:name="AccessPolicies",type=string,JSONPath=`.status.accessPolicies`,priority=1
 // +kubebuilder:printcolumn:name="Routing",type=string,JSONPath=`.spec.routingProvider`
 // +kubebuilder:printcolumn:name="WorkloadType",type=string,JSONPath=`.status.applicationKind`
+// +kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.status.replicas`
+// +kubebuilder:printcolumn:name="ReadyReplicas",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="UpdatedReplicas",type=integer,JSONPath=`.status.updatedReplicas`
+// +kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`,priority=1
+// +kubebuilder:printcolumn:name="Rollout",type=string,JSONPath=`.status.conditions[?(@.type=="Progressing")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:selectablefield:JSONPath=".spec.routingProvider"
 type Application struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -72,6 +101,11 @@ type Application struct {
 // +kubebuilder:validation:XValidation:rule="!has(self.extraContainers) || self.extraContainers.all(c, self.extraContainers.filter(x, x.name == c.name).size() == 1)",message="extraContainers names must be unique"
 // +kubebuilder:validation:XValidation:rule="!has(self.extraContainers) || self.extraContainers.filter(c, has(c.ingressPort)).size() <= 1",message="at most one extra container may set ingressPort"
 // +kubebuilder:validation:XValidation:rule="!has(self.extraContainers) || self.extraContainers.all(c, !has(c.ingressPort) || c.ingressPort != self.port)",message="extraContainers ingressPort must differ from spec.port"
+// +kubebuilder:validation:XValidation:rule="!has(self.grpc) || self.appProtocol == 'grpc'",message="spec.grpc requires spec.appProtocol=grpc"
+// +kubebuilder:validation:XValidation:rule="!has(self.tcpIngresses) || self.tcpIngresses.all(t, !has(t.targetPort) || t.targetPort == self.port || (has(self.additionalPorts) && self.additionalPorts.exists(p, p.port == t.targetPort)))",message="tcpIngresses targetPort must be spec.port or one of spec.additionalPorts"
+// +kubebuilder:validation:XValidation:rule="self.routingProvider != 'Standard' || !has(self.tcpIngresses) || self.tcpIngresses.all(t, t.protocol == 'TLS')",message="tcpIngresses with protocol TCP are not supported with spec.routingProvider=Standard, since Gateway API serves TCPRoute only on its experimental channel"
+// +kubebuilder:validation:XValidation:rule="!has(self.scratchVolumes) || !has(self.filesFrom) || self.scratchVolumes.all(v, !self.filesFrom.exists(f, f.mountPath == v.path))",message="scratchVolumes paths must not be used as filesFrom mountPath"
+// +kubebuilder:validation:XValidation:rule="!has(self.gracefulShutdown) || !has(self.lifecycle) || !has(self.lifecycle.preStop)",message="spec.gracefulShutdown cannot be combined with spec.lifecycle.preStop"
 // +kubebuilder:validation:XValidation:rule="self.routingProvider != 'Standard' || !has(self.istioSettings) || !has(self.istioSettings.retries)",message="spec.istioSettings.retries is not supported with spec.routingProvider=Standard, since Gateway API serves HTTPRoute retries only on its experimental channel"
 type ApplicationSpec struct {
 	// The image the application will run. This image will be added to a Deployment resource
@@ -84,12 +118,19 @@ type ApplicationSpec struct {
 	//+kubebuilder:validation:Required
 	Port int `json:"port"`
 
-	// Protocol that the application speaks.
+	// Protocol that the application speaks. Use grpc for applications serving gRPC, so that
+	// traffic is routed over HTTP/2 and standard routing generates a GRPCRoute.
 	//
-	//+kubebuilder:validation:Enum=http;tcp;udp
+	//+kubebuilder:validation:Enum=http;grpc;tcp;udp
 	//+kubebuilder:default=http
 	AppProtocol string `json:"appProtocol,omitempty"`
 
+	// GRPC restricts which gRPC services and methods are exposed through the ingresses
+	// when appProtocol is grpc and routingProvider is Standard. All services are exposed if unset.
+	//
+	//+kubebuilder:validation:Optional
+	GRPC *GRPCSettings `json:"grpc,omitempty"`
+
 	// Any external hostnames that route to this application. Using a skip.statkart.no-address
 	// will make the application reachable for kartverket-clients (internal), other addresses
 	// make the app reachable on the internet. Note that other addresses than skip.statkart.no
@@ -103,6 +144,15 @@ type ApplicationSpec struct {
 	//+kubebuilder:validation:Optional
 	Ingresses []string `json:"ingresses,omitempty"`
 
+	// TCPIngresses exposes non-HTTP ports of the application, such as Postgres, MQTT or SFTP,
+	// on the ingress gateways. As with ingresses, the hostname decides whether the internal or
+	// external gateway is used. The gateway must already listen on the requested port.
+	//
+	//+kubebuilder:validation:Optional
+	//+listType=map
+	//+listMapKey=port
+	TCPIngresses []TCPIngress `json:"tcpIngresses,omitempty"`
+
 	// RoutingProvider controls which routing API Skiperator uses for ingresses.
 	// Legacy uses Istio Gateway and VirtualService. Standard uses Kubernetes Gateway API.
 	//
@@ -180,6 +230,15 @@ type ApplicationSpec struct {
 	//+kubebuilder:validation:Optional
 	EnvFrom []EnvFrom `json:"envFrom,omitempty"`
 
+	// Single keys of ConfigMaps or Secrets assigned to environment variables, as a shorter
+	// alternative to env with valueFrom. Missing ConfigMaps, Secrets or keys are reported in the
+	// status. Pods are restarted when a projected value changes.
+	//
+	//+kubebuilder:validation:Optional
+	//+listType=map
+	//+listMapKey=as
+	EnvFromKeys []EnvFromKey `json:"envFromKeys,omitempty"`
+
 	// Mounting volumes into the Deployment are done using the FilesFrom argument
 	//
 	// FilesFrom supports ConfigMaps, Secrets and PVCs. The Application resource
@@ -190,6 +249,24 @@ type ApplicationSpec struct {
 	//+kubebuilder:validation:Optional
 	FilesFrom []FilesFrom `json:"filesFrom,omitempty"`
 
+	// Writable, size-limited volumes for caches and temporary files in the main container,
+	// which otherwise runs with a read-only root filesystem. An entry at /tmp sets the size
+	// limit and medium of the /tmp volume, and mounts it even when TmpVolume is disabled.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:MaxItems=10
+	//+listType=map
+	//+listMapKey=path
+	ScratchVolumes []ScratchVolume `json:"scratchVolumes,omitempty"`
+
+	// Whether a writable emptyDir without a size limit is mounted at /tmp in the main container.
+	// Enabled by default. Disable it for images that do not need a writable /tmp, or add a
+	// scratch volume at /tmp to bound its size instead.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=true
+	TmpVolume *bool `json:"tmpVolume,omitempty"`
+
 	// An optional list of extra port to expose on a pod level basis,
 	// for example so Instana or other APM tools can reach it
 	//
@@ -200,8 +277,9 @@ type ApplicationSpec struct {
 	// container. Each entry is either a regular container (type: standard, the
 	// default) running next to the main container, or an init container
 	// (type: init) that starts first and stays running for the pod lifetime
-	// (a native sidecar). The operator enforces a least-privilege security
-	// context on these containers; it cannot be overridden.
+	// (a native sidecar), or a one-shot init container (type: oneshot) that runs
+	// to completion before the main container starts. The operator enforces a least-privilege security
+	// context on these containers; only the overrides in securityContext are possible.
 	//
 	//+kubebuilder:validation:Optional
 	//+kubebuilder:validation:MaxItems=10
@@ -234,6 +312,19 @@ type ApplicationSpec struct {
 	//+kubebuilder:validation:Optional
 	Startup *Probe `json:"startup,omitempty"`
 
+	// Lifecycle hooks for the main container. PreStop runs before the container is sent
+	// SIGTERM, and PostStart runs right after it has been created.
+	//
+	//+kubebuilder:validation:Optional
+	Lifecycle *Lifecycle `json:"lifecycle,omitempty"`
+
+	// GracefulShutdown keeps the Pod serving for drainSeconds after it has been marked as
+	// terminating, so endpoints and sidecars can stop routing traffic to it before the
+	// application is stopped. Cannot be combined with lifecycle.preStop.
+	//
+	//+kubebuilder:validation:Optional
+	GracefulShutdown *GracefulShutdown `json:"gracefulShutdown,omitempty"`
+
 	// Settings for Maskinporten integration with Digitaliseringsdirektoratet
 	//
 	//+kubebuilder:validation:Optional
@@ -298,6 +389,12 @@ type ApplicationSpec struct {
 	//+kubebuilder:validation:Optional
 	PodSettings *PodSettings `json:"podSettings,omitempty"`
 
+	// SecurityContext overrides the UID and GID the main container runs as, or adds capabilities
+	// to it. The rest of the least-privilege security context cannot be overridden.
+	//
+	//+kubebuilder:validation:Optional
+	SecurityContext *ContainerSecurityContext `json:"securityContext,omitempty"`
+
 	// IstioSettings are used to configure istio specific resources such as telemetry. Currently, adjusting sampling
 	// interval for tracing is the only supported option.
 	// By default, tracing is enabled with a random sampling percentage of 10%.
@@ -312,6 +409,12 @@ type ApplicationSpec struct {
 	//
 	//+kubebuilder:validation:Optional
 	Stateful *StatefulSpec `json:"stateful,omitempty"`
+
+	// PreDeploy runs a Job, e.g. a database migration, before a new image is rolled out. The new image is
+	// only rolled out when the Job has succeeded.
+	//
+	//+kubebuilder:validation:Optional
+	PreDeploy *PreDeploySpec `json:"preDeploy,omitempty"`
 }
 
 // AuthorizationSettings Settings for overriding the default deny of all actuator endpoints. AllowAll will allow any
@@ -337,6 +440,82 @@ type AuthorizationSettings struct {
 	AllowList []string `json:"allowList,omitempty"`
 }
 
+// TCPIngress
+//
+// Exposes one application port on a gateway port. With protocol TLS the gateway routes on
+// the SNI hostname and forwards the TLS stream unterminated, so the application must serve
+// TLS itself. With protocol TCP every connection to the gateway port is forwarded, so the
+// port cannot be shared with other applications.
+//
+// +kubebuilder:object:generate=true
+// +kubebuilder:validation:XValidation:rule="self.port != 80 && self.port != 443",message="ports 80 and 443 are reserved for HTTP ingresses"
+type TCPIngress struct {
+	// Hostname clients connect to. Used for SNI routing with protocol TLS.
+	//
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Pattern=`^([a-z0-9]+(-[a-z0-9]+)*\.)+[a-z]{2,}$`
+	Hostname string `json:"hostname"`
+
+	// Port on the gateway that clients connect to.
+	//
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// Port on the application that receives the traffic. Must be spec.port or one of
+	// spec.additionalPorts. Defaults to spec.port.
+	//
+	//+kubebuilder:validation:Optional
+	TargetPort int32 `json:"targetPort,omitempty"`
+
+	// Valid values are: TLS, TCP. Default is TLS
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=TLS;TCP
+	//+kubebuilder:default=TLS
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// IsTLSPassthrough reports whether the ingress routes on SNI and forwards TLS unterminated.
+func (t TCPIngress) IsTLSPassthrough() bool {
+	return t.Protocol != "TCP"
+}
+
+// GRPCSettings
+//
+// Settings for applications that speak gRPC.
+//
+// +kubebuilder:object:generate=true
+type GRPCSettings struct {
+	// Matches lists the gRPC services and methods routed to the application. A request is
+	// routed if it matches any entry.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:MinItems=1
+	Matches []GRPCMethodMatch `json:"matches,omitempty"`
+}
+
+// GRPCMethodMatch
+//
+// Matches gRPC requests on fully qualified service name and method name. Leaving out
+// method matches every method of the service.
+//
+// +kubebuilder:object:generate=true
+type GRPCMethodMatch struct {
+	// Fully qualified gRPC service name, e.g. orders.v1.OrderService
+	//
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z_0-9]*(\.[A-Za-z_][A-Za-z_0-9]*)*$`
+	Service string `json:"service"`
+
+	// Name of the gRPC method, e.g. GetOrder
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z_0-9]*$`
+	Method string `json:"method,omitempty"`
+}
+
 // +kubebuilder:object:generate=true
 type Replicas struct {
 	// Min represents the minimum number of replicas when load is low.
@@ -368,8 +547,7 @@ type Replicas struct {
 
 // Strategy
 //
-// Object representing a Kubernetes deployment strategy. Currently only contains a Type object,
-// could probably be omitted in favour of directly using the Type.
+// Object representing a Kubernetes deployment strategy, and how failed rollouts are handled.
 //
 // +kubebuilder:object:generate=true
 type Strategy struct {
@@ -379,6 +557,47 @@ type Strategy struct {
 	// +kubebuilder:validation:Enum=RollingUpdate;Recreate
 	// +kubebuilder:default=RollingUpdate
 	Type string `json:"type,omitempty"`
+
+	// When set, a rollout that exceeds its progress deadline or whose new pods keep crashing is rolled back
+	// to the last image that became fully available. The Deployment keeps running that image until the spec
+	// of the Application changes. Not supported for stateful Applications.
+	//
+	//+kubebuilder:validation:Optional
+	AutoRollback *AutoRollback `json:"autoRollback,omitempty"`
+}
+
+// AutoRollback
+//
+// Settings for rolling failed rollouts back to the last healthy image.
+//
+// +kubebuilder:object:generate=true
+type AutoRollback struct {
+	// Number of restarts of a crash looping container in a new pod before the rollout is rolled back
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=3
+	CrashLoopRestarts int32 `json:"crashLoopRestarts,omitempty"`
+}
+
+// RollbackStatus is an automatic rollback of a failed rollout to the last healthy image
+//
+// +kubebuilder:object:generate=true
+type RollbackStatus struct {
+	// Image of the application container in the failed rollout
+	FailedImage string `json:"failedImage"`
+
+	// Image the application container was rolled back to
+	Image string `json:"image"`
+
+	// Generation of the Application whose rollout failed. The rollback is kept until the generation changes.
+	Generation int64 `json:"generation"`
+
+	// Why the rollout failed, ProgressDeadlineExceeded or CrashLoopBackOff
+	Reason string `json:"reason"`
+
+	// Time of the rollback
+	Time metav1.Time `json:"time"`
 }
 
 func NewDefaultReplicas() Replicas {
@@ -464,6 +683,11 @@ func (a *Application) IsStateful() bool {
 	return a.Spec.Stateful != nil && a.Spec.Stateful.Enabled
 }
 
+// HasTmpVolume reports whether the built-in /tmp volume is mounted into the main container.
+func (a *Application) HasTmpVolume() bool {
+	return a.Spec.TmpVolume == nil || *a.Spec.TmpVolume
+}
+
 // IngressTargetPort returns the pod port that receives the application's
 // ingress traffic. This is normally spec.Port, but when an extra container
 // declares an IngressPort (a fronting proxy), traffic is routed to that port
@@ -479,6 +703,24 @@ func (a *Application) IngressTargetPort() int {
 	return a.Spec.Port
 }
 
+// TCPIngressTargetPort returns the Service port a TCP ingress forwards to.
+func (a *Application) TCPIngressTargetPort(ingress TCPIngress) int32 {
+	if ingress.TargetPort != 0 {
+		return ingress.TargetPort
+	}
+	return int32(a.Spec.Port)
+}
+
+// GetTCPGatewayName returns the legacy Istio Gateway resource name for a TCP ingress.
+func (a *Application) GetTCPGatewayName(ingress TCPIngress) string {
+	return fmt.Sprintf("%s-tcp-ingress-%d", a.Name, ingress.Port)
+}
+
+// UsesGRPC reports whether the application serves gRPC on its main port.
+func (a *Application) UsesGRPC() bool {
+	return a.Spec.AppProtocol == "grpc"
+}
+
 func (a *Application) UsesStandardRouting() bool {
 	return a.Spec.RoutingProvider == RoutingProviderStandard
 }
@@ -503,6 +745,9 @@ func (a *Application) GetGatewayNames() ([]string, error) {
 	for _, host := range hosts.AllHosts() {
 		gatewayNames = append(gatewayNames, a.GetGatewayName(host.Hostname))
 	}
+	for _, ingress := range a.Spec.TCPIngresses {
+		gatewayNames = append(gatewayNames, a.GetTCPGatewayName(ingress))
+	}
 	return gatewayNames, nil
 }
 
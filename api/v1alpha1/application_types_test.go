@@ -1,9 +1,11 @@
 package v1alpha1
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
+	commonpodtypes "github.com/kartverket/skiperator/api/common/podtypes"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -53,3 +55,11 @@ func TestApplicationFillDefaultsStatusIsIdempotent(t *testing.T) {
 	assert.NotNil(t, app.Status.SubResources)
 	assert.NotNil(t, app.Status.Conditions)
 }
+
+func TestProbeWithoutPortOmitsPort(t *testing.T) {
+	probe := Probe{Exec: &commonpodtypes.ExecProbe{Command: []string{"pg_isready"}}}
+
+	out, err := json.Marshal(probe)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), `"port"`)
+}
@@ -0,0 +1,48 @@
+package v1alpha1
+
+// PreDeploySpec configures a Job that runs before a new image of the Application is rolled out, typically a
+// database migration. The Job runs with the environment, secrets, files and GCP/Cloud SQL wiring of the
+// Application, and is run once for every image. The Deployment or StatefulSet keeps running the previous
+// pods until the Job has succeeded. A failed Job is reported in the PreDeploySucceeded condition, and is
+// retried by changing the image or the pre-deploy settings.
+//
+// +kubebuilder:object:generate=true
+type PreDeploySpec struct {
+	// Image to run. Defaults to the image of the Application.
+	//
+	//+kubebuilder:validation:Optional
+	Image string `json:"image,omitempty"`
+
+	// Command to run, e.g. the migration command of the image.
+	//
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
+
+	// Resources for the Job container. Defaults to the resources of the Application.
+	//
+	//+kubebuilder:validation:Optional
+	Resources *ResourceRequirements `json:"resources,omitempty"`
+
+	// Number of retries before the Job is considered failed.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:default=0
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// Time the Job may run before it is considered failed.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=600
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+}
+
+// PreDeployImage returns the image the pre-deploy Job runs
+func (a *Application) PreDeployImage() string {
+	if a.Spec.PreDeploy != nil && a.Spec.PreDeploy.Image != "" {
+		return a.Spec.PreDeploy.Image
+	}
+	return a.Spec.Image
+}
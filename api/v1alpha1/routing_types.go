@@ -25,7 +25,9 @@ type RoutingList struct {
 // +kubebuilder:resource:shortName="routing"
 // +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Hostname",type=string,JSONPath=`.spec.hostname`
 // +kubebuilder:printcolumn:name="Routing",type=string,JSONPath=`.spec.routingProvider`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:selectablefield:JSONPath=".spec.routingProvider"
 type Routing struct {
 	metav1.TypeMeta   `json:",inline"`
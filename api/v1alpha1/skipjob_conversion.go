@@ -17,6 +17,8 @@ func (src *SKIPJob) ConvertTo(dstRaw conversion.Hub) error {
 	dst.ObjectMeta = src.ObjectMeta
 	dst.Spec.Job = src.Spec.Job
 	dst.Spec.Cron = src.Spec.Cron
+	dst.Spec.DependsOn = src.Spec.DependsOn
+	dst.Spec.ExtraContainers = src.Spec.ExtraContainers
 	dst.Spec.IstioSettings = src.Spec.IstioSettings
 	dst.Spec.Prometheus = src.Spec.Prometheus
 	dst.Spec.Labels = src.Spec.Labels
@@ -24,7 +26,7 @@ func (src *SKIPJob) ConvertTo(dstRaw conversion.Hub) error {
 
 	flattenContainer(&src.Spec.Container, &dst.Spec)
 
-	dst.Status = src.Status
+	dst.Status = v1beta1.SKIPJobStatus(src.Status)
 
 	return nil
 }
@@ -39,6 +41,8 @@ func (dst *SKIPJob) ConvertFrom(srcRaw conversion.Hub) error {
 	dst.ObjectMeta = src.ObjectMeta
 	dst.Spec.Job = src.Spec.Job
 	dst.Spec.Cron = src.Spec.Cron
+	dst.Spec.DependsOn = src.Spec.DependsOn
+	dst.Spec.ExtraContainers = src.Spec.ExtraContainers
 	dst.Spec.IstioSettings = src.Spec.IstioSettings
 	dst.Spec.Prometheus = src.Spec.Prometheus
 	dst.Spec.Labels = src.Spec.Labels
@@ -46,7 +50,7 @@ func (dst *SKIPJob) ConvertFrom(srcRaw conversion.Hub) error {
 
 	expandContainer(&src.Spec, &dst.Spec.Container)
 
-	dst.Status = src.Status
+	dst.Status = SKIPJobStatus(src.Status)
 	return nil
 }
 
@@ -66,6 +70,7 @@ func flattenContainer(src *ContainerSettings, dst *v1beta1.SKIPJobSpec) {
 	dst.Readiness = src.Readiness
 	dst.Startup = src.Startup
 	dst.EnvFrom = src.EnvFrom
+	dst.EnvFromKeys = src.EnvFromKeys
 	dst.FilesFrom = src.FilesFrom
 	dst.AdditionalPorts = src.AdditionalPorts
 }
@@ -86,6 +91,7 @@ func expandContainer(src *v1beta1.SKIPJobSpec, dst *ContainerSettings) {
 	dst.Readiness = src.Readiness
 	dst.Startup = src.Startup
 	dst.EnvFrom = src.EnvFrom
+	dst.EnvFromKeys = src.EnvFromKeys
 	dst.FilesFrom = src.FilesFrom
 	dst.AdditionalPorts = src.AdditionalPorts
 }
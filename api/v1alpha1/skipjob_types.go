@@ -19,20 +19,32 @@ var (
 	ConditionFailed          = "Failed"
 	SKIPJobReferenceLabelKey = "skiperator.kartverket.no/skipjobName"
 	IsSKIPJobKey             = "skiperator.kartverket.no/skipjob"
+	// Setting this annotation on a scheduled SKIPJob to a new value, e.g. a timestamp, starts a Job from the
+	// CronJob outside of the schedule. The value is recorded in the status when the Job has been started.
+	TriggerAnnotationKey = "skiperator.kartverket.no/trigger"
 )
 
 // SKIPJobStatus defines the observed state of SKIPJob
 // +kubebuilder:object:generate=true
 type SKIPJobStatus struct {
-	//+kubebuilder:validation:Optional
-	Conditions []metav1.Condition `json:"conditions"`
+	SkiperatorStatus `json:",inline"`
+	// Indexes of the latest indexed Job that have succeeded, e.g. "0-3,5". Only set with completionMode Indexed.
+	CompletedIndexes string `json:"completedIndexes,omitempty"`
+	// Indexes of the latest indexed Job that have failed. Only set with completionMode Indexed.
+	FailedIndexes string `json:"failedIndexes,omitempty"`
+	// The latest manual run of a scheduled SKIPJob, started with the skiperator.kartverket.no/trigger annotation.
+	// A trigger is acknowledged when it is recorded here, and the annotation can then be left as is or removed.
+	ManualTrigger *ManualTriggerStatus `json:"manualTrigger,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:object:generate=true
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.summary.status`
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.container.image`
 // +kubebuilder:printcolumn:name="AccessPolicies",type=string,JSONPath=`.status.accessPolicies`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:deprecatedversion:warning="SKIPJob v1alpha1 is deprecated in favor of v1beta1"
 //
 // SKIPJob is the deprecated schema for the SKIPJobs API. Please migrate to v1beta1.
@@ -44,7 +56,7 @@ type SKIPJob struct {
 	Spec SKIPJobSpec `json:"spec"`
 
 	//+kubebuilder:validation:Optional
-	Status SkiperatorStatus `json:"status,omitempty"`
+	Status SKIPJobStatus `json:"status,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -63,6 +75,9 @@ type SKIPJobList struct {
 // The Container field of a SKIPJob is only mutable if the Cron field is set. If unset, you must delete your SKIPJob to change container settings.
 // +kubebuilder:validation:XValidation:rule="(has(oldSelf.cron) && has(self.cron)) || (!has(oldSelf.cron) && !has(self.cron))", message="After creation of a SKIPJob you may not remove the Cron field if it was previously present, or add it if it was previously omitted. Please delete the SKIPJob to change its nature from a one-off/scheduled job."
 // +kubebuilder:validation:XValidation:rule="((!has(self.cron) && (oldSelf.container == self.container)) || has(self.cron))", message="The field Container is immutable for one-off jobs. Please delete your SKIPJob to change the containers settings."
+// +kubebuilder:validation:XValidation:rule="((!has(self.cron) && (has(oldSelf.extraContainers) == has(self.extraContainers)) && (!has(self.extraContainers) || oldSelf.extraContainers == self.extraContainers)) || has(self.cron))", message="The field ExtraContainers is immutable for one-off jobs. Please delete your SKIPJob to change the extra containers."
+// +kubebuilder:validation:XValidation:rule="!has(self.job) || !has(self.job.podFailurePolicy) || !has(self.container.restartPolicy) || self.container.restartPolicy == 'Never'", message="podFailurePolicy requires restartPolicy Never"
+// +kubebuilder:validation:XValidation:rule="!has(self.dependsOn) || !has(self.cron)", message="dependsOn is only supported for one-off jobs"
 type SKIPJobSpec struct {
 	// Settings for the actual Job. If you use a scheduled job, the settings in here will also specify the template of the job.
 	//
@@ -74,12 +89,35 @@ type SKIPJobSpec struct {
 	//+kubebuilder:validation:Optional
 	Cron *CronSettings `json:"cron,omitempty"`
 
+	// Names of other SKIPJobs in the same namespace that must finish successfully before this Job starts.
+	// The Job is created suspended, and started when all of them report Finished. If one of them fails, or the
+	// dependencies form a cycle, the Job is not started and the error is reported in the status.
+	// Only supported for one-off jobs.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:MaxItems=20
+	//+listType=set
+	DependsOn []string `json:"dependsOn,omitempty"`
+
 	// Settings for the Pods running in the job. Fields are mostly the same as an Application, and are (probably) better documented there. Some fields are omitted, but none added.
 	// Once set, you may not change Container without deleting your current SKIPJob
 	//
 	// +kubebuilder:validation:Required
 	Container ContainerSettings `json:"container"`
 
+	// Extra containers to run in the pods of the Job. Standard containers and init containers (type: init) run as
+	// native sidecars, which are stopped when the job container exits, so they do not keep the Job from completing.
+	// One-shot init containers (type: oneshot) run to completion before the job container starts.
+	// Once set, you may not change ExtraContainers without deleting your current SKIPJob.
+	// The operator enforces a least-privilege security context on these containers; only the overrides
+	// in securityContext are possible.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:MaxItems=10
+	//+kubebuilder:validation:XValidation:rule="self.all(c, self.filter(x, x.name == c.name).size() == 1)",message="extraContainers names must be unique"
+	//+kubebuilder:validation:XValidation:rule="self.all(c, !has(c.ingressPort))",message="ingressPort is not supported for SKIPJob extra containers"
+	ExtraContainers []ContainerSpec `json:"extraContainers,omitempty"`
+
 	// IstioSettings are used to configure istio specific resources such as telemetry. Currently, adjusting sampling
 	// interval for tracing is the only supported option.
 	// By default, tracing is enabled with a random sampling percentage of 10%.
@@ -127,6 +165,10 @@ type ContainerSettings struct {
 	//+kubebuilder:validation:Optional
 	EnvFrom []EnvFrom `json:"envFrom,omitempty"`
 	//+kubebuilder:validation:Optional
+	//+listType=map
+	//+listMapKey=as
+	EnvFromKeys []EnvFromKey `json:"envFromKeys,omitempty"`
+	//+kubebuilder:validation:Optional
 	FilesFrom []FilesFrom `json:"filesFrom,omitempty"`
 
 	//+kubebuilder:validation:Optional
@@ -158,10 +200,10 @@ func (skipJob *SKIPJob) KindPostFixedName() string {
 }
 
 func (skipJob *SKIPJob) GetStatus() *SkiperatorStatus {
-	return &skipJob.Status
+	return &skipJob.Status.SkiperatorStatus
 }
 func (skipJob *SKIPJob) SetStatus(status SkiperatorStatus) {
-	skipJob.Status = status
+	skipJob.Status.SkiperatorStatus = status
 }
 
 func (skipJob *SKIPJob) FillDefaultSpec() {
@@ -173,7 +215,8 @@ func (skipJob *SKIPJob) FillDefaultSpec() {
 		skipJob.Spec.Job.TTLSecondsAfterFinished = &DefaultTTLSecondsAfterFinished
 	}
 
-	if skipJob.Spec.Job.BackoffLimit == nil {
+	// With backoffLimitPerIndex Kubernetes does not limit the retries of the Job as a whole unless backoffLimit is set
+	if skipJob.Spec.Job.BackoffLimit == nil && skipJob.Spec.Job.BackoffLimitPerIndex == nil {
 		skipJob.Spec.Job.BackoffLimit = &DefaultBackoffLimit
 	}
 
@@ -1,6 +1,16 @@
 package v1alpha1
 
-import corev1 "k8s.io/api/core/v1"
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Values of StatefulSpec.Rollout
+const (
+	StatefulRolloutStandard = "standard"
+	StatefulRolloutStaged   = "staged"
+)
 
 // StatefulSpec configures the Application to be deployed as a StatefulSet
 // instead of a Deployment. Requires VolumeClaimTemplates. Disallows
@@ -18,6 +28,9 @@ type StatefulSpec struct {
 
 	// Per-pod PersistentVolumeClaims provisioned by the StatefulSet controller.
 	// Each replica gets its own PVC named `<template.metadata.name>-<app>-<ordinal>`.
+	// The storage request of a template can be increased if the StorageClass allows volume
+	// expansion: the existing PVCs are expanded and the StatefulSet is recreated without
+	// restarting its pods. Other changes to the templates only apply to new StatefulSets.
 	//
 	//+kubebuilder:validation:Optional
 	VolumeClaimTemplates []VolumeClaimTemplate `json:"volumeClaimTemplates,omitempty"`
@@ -35,6 +48,22 @@ type StatefulSpec struct {
 	//+kubebuilder:validation:Minimum=0
 	Partition *int32 `json:"partition,omitempty"`
 
+	// How pod template changes are rolled out. With standard, the StatefulSet controller updates the pods
+	// with ordinal >= Partition from the highest ordinal down. With staged, the partition is lowered one
+	// ordinal at a time: the next pod is only updated once the previous one is ready and has stayed ready
+	// for SoakDuration, and the rollout pauses while an updated pod fails readiness. Partition cannot be
+	// set with staged.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=standard;staged
+	//+kubebuilder:default=standard
+	Rollout string `json:"rollout,omitempty"`
+
+	// How long an updated pod has to stay ready before a staged rollout updates the next one
+	//
+	//+kubebuilder:validation:Optional
+	SoakDuration *metav1.Duration `json:"soakDuration,omitempty"`
+
 	// PVC fate when the StatefulSet is deleted. Defaults to Retain.
 	//
 	//+kubebuilder:validation:Optional
@@ -46,6 +75,156 @@ type StatefulSpec struct {
 	//+kubebuilder:validation:Optional
 	//+kubebuilder:validation:Enum=Retain;Delete
 	PVCRetentionWhenScaled string `json:"pvcRetentionWhenScaled,omitempty"`
+
+	// How the pods of the StatefulSet find and reach each other
+	//
+	//+kubebuilder:validation:Optional
+	Network *StatefulNetwork `json:"network,omitempty"`
+
+	// Scheduled VolumeSnapshots of the PVCs of every replica.
+	// Requires the VolumeSnapshot CRDs and a CSI driver with snapshot support.
+	//
+	//+kubebuilder:validation:Optional
+	Backup *StatefulBackup `json:"backup,omitempty"`
+}
+
+// StatefulNetwork configures the peer network of clustered software. Every pod has the stable DNS name
+// `<app>-<ordinal>.<app>-headless.<namespace>.svc.cluster.local` through the headless Service. When set,
+// the NetworkPolicy allows traffic between the pods on the application port, the additional ports and PeerPorts.
+//
+// +kubebuilder:object:generate=true
+type StatefulNetwork struct {
+	// Ports only used between the pods, such as cluster membership or replication ports. They are exposed
+	// on the headless Service and the per-pod Services, but not on the Application Service.
+	//
+	//+kubebuilder:validation:Optional
+	//+listType=map
+	//+listMapKey=name
+	PeerPorts []InternalPort `json:"peerPorts,omitempty"`
+
+	// Whether the headless Service publishes the addresses of pods that are not ready, so peers can find
+	// each other while the cluster forms. Defaults to true.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=true
+	PublishNotReadyAddresses *bool `json:"publishNotReadyAddresses,omitempty"`
+
+	// Generates a Service named `<app>-<ordinal>` for every replica that only selects that pod, for clients
+	// and ingresses that must reach a specific replica. The Application is not reconciled while one of the names
+	// is taken by a Service it does not own, or by another Application.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=false
+	PerPodServices bool `json:"perPodServices,omitempty"`
+}
+
+// StatefulBackup takes a VolumeSnapshot of every PVC of the StatefulSet on a cron schedule and
+// prunes the oldest snapshots beyond Retention. Snapshots are kept when the Application is deleted.
+//
+// +kubebuilder:object:generate=true
+type StatefulBackup struct {
+	// A CronJob string for denoting the schedule of the snapshots. See https://crontab.guru/ for help creating CronJob strings.
+	//
+	//+kubebuilder:validation:Required
+	Schedule string `json:"schedule"`
+
+	// The time zone name for the given schedule, see https://en.wikipedia.org/wiki/List_of_tz_database_time_zones. If not specified,
+	// this will default to UTC.
+	//
+	// Example: "Europe/Oslo"
+	//
+	//+kubebuilder:validation:Optional
+	TimeZone *string `json:"timeZone,omitempty"`
+
+	// VolumeSnapshotClass used for the snapshots
+	//
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:MinLength=1
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName"`
+
+	// Number of snapshots kept per PVC. The latest ready snapshot is always kept.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=7
+	Retention int32 `json:"retention,omitempty"`
+}
+
+// StatefulBackupStatus is the latest ready VolumeSnapshot of a PVC of the StatefulSet
+//
+// +kubebuilder:object:generate=true
+type StatefulBackupStatus struct {
+	// Ordinal of the replica the PVC belongs to
+	Ordinal int32 `json:"ordinal"`
+
+	// Name of the PVC
+	PersistentVolumeClaim string `json:"persistentVolumeClaim"`
+
+	// Name of the latest ready VolumeSnapshot of the PVC
+	VolumeSnapshot string `json:"volumeSnapshot"`
+
+	// Time the snapshot was taken
+	CreationTime metav1.Time `json:"creationTime"`
+}
+
+// StatefulRolloutStatus is the progress of a staged rollout of the StatefulSet
+//
+// +kubebuilder:object:generate=true
+type StatefulRolloutStatus struct {
+	// Revision of the pods that have not been updated yet
+	CurrentRevision string `json:"currentRevision,omitempty"`
+
+	// Revision of the current pod template
+	UpdateRevision string `json:"updateRevision,omitempty"`
+
+	// Pods with an ordinal >= Partition are updated to UpdateRevision
+	Partition int32 `json:"partition"`
+
+	// Number of pods on UpdateRevision
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+
+	// Progressing, Soaking, Paused or Complete
+	State string `json:"state"`
+
+	// Human-readable progress of the rollout
+	Message string `json:"message,omitempty"`
+
+	// Revision of every pod of the StatefulSet
+	Pods []StatefulPodRevision `json:"pods,omitempty"`
+}
+
+// StatefulPodRevision is the revision of a pod of the StatefulSet during a staged rollout
+//
+// +kubebuilder:object:generate=true
+type StatefulPodRevision struct {
+	// Ordinal of the pod
+	Ordinal int32 `json:"ordinal"`
+
+	// Revision the pod runs, empty while it is being created
+	Revision string `json:"revision,omitempty"`
+
+	// Revision the pod is updated to in the current step of the rollout
+	TargetRevision string `json:"targetRevision"`
+
+	// Whether the pod is ready
+	Ready bool `json:"ready"`
+}
+
+// VolumeClaimResizeStatus is the progress of expanding a PVC of the StatefulSet
+//
+// +kubebuilder:object:generate=true
+type VolumeClaimResizeStatus struct {
+	// Name of the PVC
+	Name string `json:"name"`
+
+	// Requested storage
+	Requested resource.Quantity `json:"requested"`
+
+	// Current capacity of the volume
+	Capacity resource.Quantity `json:"capacity,omitempty"`
+
+	// Resize state reported for the PVC, such as ControllerResizeInProgress or NodeResizePending
+	State string `json:"state"`
 }
 
 // VolumeClaimTemplate describes a per-pod PersistentVolumeClaim provisioned by the StatefulSet
@@ -82,4 +261,10 @@ type VolumeClaimTemplate struct {
 	//
 	//+kubebuilder:validation:Optional
 	SubPath string `json:"subPath,omitempty"`
+
+	// Name of a VolumeSnapshot in the namespace to provision PVCs from. Applies to PVCs that
+	// do not exist yet, so replicas added later are bootstrapped from the snapshot.
+	//
+	//+kubebuilder:validation:Optional
+	RestoreFrom string `json:"restoreFrom,omitempty"`
 }
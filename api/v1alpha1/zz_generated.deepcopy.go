@@ -73,11 +73,21 @@ func (in *ApplicationList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 	*out = *in
+	if in.GRPC != nil {
+		in, out := &in.GRPC, &out.GRPC
+		*out = new(GRPCSettings)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Ingresses != nil {
 		in, out := &in.Ingresses, &out.Ingresses
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TCPIngresses != nil {
+		in, out := &in.TCPIngresses, &out.TCPIngresses
+		*out = make([]TCPIngress, len(*in))
+		copy(*out, *in)
+	}
 	if in.Command != nil {
 		in, out := &in.Command, &out.Command
 		*out = make([]string, len(*in))
@@ -93,7 +103,7 @@ func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 		*out = new(v1.JSON)
 		(*in).DeepCopyInto(*out)
 	}
-	out.Strategy = in.Strategy
+	in.Strategy.DeepCopyInto(&out.Strategy)
 	if in.Env != nil {
 		in, out := &in.Env, &out.Env
 		*out = make([]corev1.EnvVar, len(*in))
@@ -104,6 +114,13 @@ func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 	if in.EnvFrom != nil {
 		in, out := &in.EnvFrom, &out.EnvFrom
 		*out = make([]EnvFrom, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFromKeys != nil {
+		in, out := &in.EnvFromKeys, &out.EnvFromKeys
+		*out = make([]EnvFromKey, len(*in))
 		copy(*out, *in)
 	}
 	if in.FilesFrom != nil {
@@ -113,6 +130,18 @@ func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ScratchVolumes != nil {
+		in, out := &in.ScratchVolumes, &out.ScratchVolumes
+		*out = make([]ScratchVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TmpVolume != nil {
+		in, out := &in.TmpVolume, &out.TmpVolume
+		*out = new(bool)
+		**out = **in
+	}
 	if in.AdditionalPorts != nil {
 		in, out := &in.AdditionalPorts, &out.AdditionalPorts
 		*out = make([]InternalPort, len(*in))
@@ -128,16 +157,26 @@ func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 	if in.Liveness != nil {
 		in, out := &in.Liveness, &out.Liveness
 		*out = new(Probe)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Readiness != nil {
 		in, out := &in.Readiness, &out.Readiness
 		*out = new(Probe)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Startup != nil {
 		in, out := &in.Startup, &out.Startup
 		*out = new(Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(Lifecycle)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GracefulShutdown != nil {
+		in, out := &in.GracefulShutdown, &out.GracefulShutdown
+		*out = new(GracefulShutdown)
 		**out = **in
 	}
 	if in.Maskinporten != nil {
@@ -210,6 +249,11 @@ func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 		*out = new(PodSettings)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(ContainerSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.IstioSettings != nil {
 		in, out := &in.IstioSettings, &out.IstioSettings
 		*out = new(IstioSettingsApplication)
@@ -220,6 +264,11 @@ func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 		*out = new(StatefulSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PreDeploy != nil {
+		in, out := &in.PreDeploy, &out.PreDeploy
+		*out = new(PreDeploySpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSpec.
@@ -236,6 +285,30 @@ func (in *ApplicationSpec) DeepCopy() *ApplicationSpec {
 func (in *ApplicationStatus) DeepCopyInto(out *ApplicationStatus) {
 	*out = *in
 	in.SkiperatorStatus.DeepCopyInto(&out.SkiperatorStatus)
+	if in.Backups != nil {
+		in, out := &in.Backups, &out.Backups
+		*out = make([]StatefulBackupStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeResizes != nil {
+		in, out := &in.VolumeResizes, &out.VolumeResizes
+		*out = make([]VolumeClaimResizeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollback != nil {
+		in, out := &in.Rollback, &out.Rollback
+		*out = new(RollbackStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StatefulRollout != nil {
+		in, out := &in.StatefulRollout, &out.StatefulRollout
+		*out = new(StatefulRolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationStatus.
@@ -268,6 +341,21 @@ func (in *AuthorizationSettings) DeepCopy() *AuthorizationSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoRollback) DeepCopyInto(out *AutoRollback) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoRollback.
+func (in *AutoRollback) DeepCopy() *AutoRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerSettings) DeepCopyInto(out *ContainerSettings) {
 	*out = *in
@@ -291,6 +379,13 @@ func (in *ContainerSettings) DeepCopyInto(out *ContainerSettings) {
 	if in.EnvFrom != nil {
 		in, out := &in.EnvFrom, &out.EnvFrom
 		*out = make([]EnvFrom, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFromKeys != nil {
+		in, out := &in.EnvFromKeys, &out.EnvFromKeys
+		*out = make([]EnvFromKey, len(*in))
 		copy(*out, *in)
 	}
 	if in.FilesFrom != nil {
@@ -308,17 +403,17 @@ func (in *ContainerSettings) DeepCopyInto(out *ContainerSettings) {
 	if in.Liveness != nil {
 		in, out := &in.Liveness, &out.Liveness
 		*out = new(Probe)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Readiness != nil {
 		in, out := &in.Readiness, &out.Readiness
 		*out = new(Probe)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Startup != nil {
 		in, out := &in.Startup, &out.Startup
 		*out = new(Probe)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.AccessPolicy != nil {
 		in, out := &in.AccessPolicy, &out.AccessPolicy
@@ -352,6 +447,76 @@ func (in *ContainerSettings) DeepCopy() *ContainerSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GRPCMethodMatch) DeepCopyInto(out *GRPCMethodMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GRPCMethodMatch.
+func (in *GRPCMethodMatch) DeepCopy() *GRPCMethodMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(GRPCMethodMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GRPCSettings) DeepCopyInto(out *GRPCSettings) {
+	*out = *in
+	if in.Matches != nil {
+		in, out := &in.Matches, &out.Matches
+		*out = make([]GRPCMethodMatch, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GRPCSettings.
+func (in *GRPCSettings) DeepCopy() *GRPCSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(GRPCSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreDeploySpec) DeepCopyInto(out *PreDeploySpec) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreDeploySpec.
+func (in *PreDeploySpec) DeepCopy() *PreDeploySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PreDeploySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Replicas) DeepCopyInto(out *Replicas) {
 	*out = *in
@@ -367,6 +532,22 @@ func (in *Replicas) DeepCopy() *Replicas {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollbackStatus) DeepCopyInto(out *RollbackStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollbackStatus.
+func (in *RollbackStatus) DeepCopy() *RollbackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RollbackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Route) DeepCopyInto(out *Route) {
 	*out = *in
@@ -560,7 +741,19 @@ func (in *SKIPJobSpec) DeepCopyInto(out *SKIPJobSpec) {
 		*out = new(CronSettings)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	in.Container.DeepCopyInto(&out.Container)
+	if in.ExtraContainers != nil {
+		in, out := &in.ExtraContainers, &out.ExtraContainers
+		*out = make([]ContainerSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.IstioSettings != nil {
 		in, out := &in.IstioSettings, &out.IstioSettings
 		*out = new(IstioSettingsBase)
@@ -593,12 +786,11 @@ func (in *SKIPJobSpec) DeepCopy() *SKIPJobSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SKIPJobStatus) DeepCopyInto(out *SKIPJobStatus) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.SkiperatorStatus.DeepCopyInto(&out.SkiperatorStatus)
+	if in.ManualTrigger != nil {
+		in, out := &in.ManualTrigger, &out.ManualTrigger
+		*out = new(ManualTriggerStatus)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -612,6 +804,102 @@ func (in *SKIPJobStatus) DeepCopy() *SKIPJobStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatefulBackup) DeepCopyInto(out *StatefulBackup) {
+	*out = *in
+	if in.TimeZone != nil {
+		in, out := &in.TimeZone, &out.TimeZone
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulBackup.
+func (in *StatefulBackup) DeepCopy() *StatefulBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(StatefulBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatefulBackupStatus) DeepCopyInto(out *StatefulBackupStatus) {
+	*out = *in
+	in.CreationTime.DeepCopyInto(&out.CreationTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulBackupStatus.
+func (in *StatefulBackupStatus) DeepCopy() *StatefulBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StatefulBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatefulNetwork) DeepCopyInto(out *StatefulNetwork) {
+	*out = *in
+	if in.PeerPorts != nil {
+		in, out := &in.PeerPorts, &out.PeerPorts
+		*out = make([]InternalPort, len(*in))
+		copy(*out, *in)
+	}
+	if in.PublishNotReadyAddresses != nil {
+		in, out := &in.PublishNotReadyAddresses, &out.PublishNotReadyAddresses
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulNetwork.
+func (in *StatefulNetwork) DeepCopy() *StatefulNetwork {
+	if in == nil {
+		return nil
+	}
+	out := new(StatefulNetwork)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatefulPodRevision) DeepCopyInto(out *StatefulPodRevision) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulPodRevision.
+func (in *StatefulPodRevision) DeepCopy() *StatefulPodRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(StatefulPodRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatefulRolloutStatus) DeepCopyInto(out *StatefulRolloutStatus) {
+	*out = *in
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]StatefulPodRevision, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulRolloutStatus.
+func (in *StatefulRolloutStatus) DeepCopy() *StatefulRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StatefulRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatefulSpec) DeepCopyInto(out *StatefulSpec) {
 	*out = *in
@@ -627,6 +915,21 @@ func (in *StatefulSpec) DeepCopyInto(out *StatefulSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.SoakDuration != nil {
+		in, out := &in.SoakDuration, &out.SoakDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(StatefulNetwork)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(StatefulBackup)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulSpec.
@@ -642,6 +945,11 @@ func (in *StatefulSpec) DeepCopy() *StatefulSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Strategy) DeepCopyInto(out *Strategy) {
 	*out = *in
+	if in.AutoRollback != nil {
+		in, out := &in.AutoRollback, &out.AutoRollback
+		*out = new(AutoRollback)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Strategy.
@@ -654,6 +962,38 @@ func (in *Strategy) DeepCopy() *Strategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TCPIngress) DeepCopyInto(out *TCPIngress) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TCPIngress.
+func (in *TCPIngress) DeepCopy() *TCPIngress {
+	if in == nil {
+		return nil
+	}
+	out := new(TCPIngress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeClaimResizeStatus) DeepCopyInto(out *VolumeClaimResizeStatus) {
+	*out = *in
+	out.Requested = in.Requested.DeepCopy()
+	out.Capacity = in.Capacity.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeClaimResizeStatus.
+func (in *VolumeClaimResizeStatus) DeepCopy() *VolumeClaimResizeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeClaimResizeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeClaimTemplate) DeepCopyInto(out *VolumeClaimTemplate) {
 	*out = *in
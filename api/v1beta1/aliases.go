@@ -44,6 +44,8 @@ type ExternalPort = commonpodtypes.ExternalPort
 // files from env
 type EnvFrom = commonpodtypes.EnvFrom
 type FilesFrom = commonpodtypes.FilesFrom
+type EnvFromKey = commonpodtypes.EnvFromKey
+type SecretStoreSource = commonpodtypes.SecretStoreSource
 
 // GCP
 type GCP = commonpodtypes.GCP
@@ -53,9 +55,15 @@ type CloudSQLProxySettings = commonpodtypes.CloudSQLProxySettings
 // internal port
 type InternalPort = commonpodtypes.InternalPort
 
+// extra containers
+type ContainerSpec = commonpodtypes.ContainerSpec
+
 // pod settings
 type PodSettings = commonpodtypes.PodSettings
 
+// container security context
+type ContainerSecurityContext = commonpodtypes.ContainerSecurityContext
+
 // probe
 type Probe = commonpodtypes.Probe
 
@@ -75,6 +83,7 @@ type JobSettings = commontypes.JobSettings
 type SkiperatorStatus = commontypes.SkiperatorStatus
 type Status = commontypes.Status
 type StatusNames = commontypes.StatusNames
+type ManualTriggerStatus = commontypes.ManualTriggerStatus
 
 const (
 	SYNCED        = commontypes.SYNCED
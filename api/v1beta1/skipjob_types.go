@@ -20,13 +20,22 @@ var (
 	ConditionFailed          = "Failed"
 	SKIPJobReferenceLabelKey = "skiperator.kartverket.no/skipjobName"
 	IsSKIPJobKey             = "skiperator.kartverket.no/skipjob"
+	// Setting this annotation on a scheduled SKIPJob to a new value, e.g. a timestamp, starts a Job from the
+	// CronJob outside of the schedule. The value is recorded in the status when the Job has been started.
+	TriggerAnnotationKey = "skiperator.kartverket.no/trigger"
 )
 
 // SKIPJobStatus defines the observed state of SKIPJob
 // +kubebuilder:object:generate=true
 type SKIPJobStatus struct {
-	//+kubebuilder:validation:Optional
-	Conditions []metav1.Condition `json:"conditions"`
+	SkiperatorStatus `json:",inline"`
+	// Indexes of the latest indexed Job that have succeeded, e.g. "0-3,5". Only set with completionMode Indexed.
+	CompletedIndexes string `json:"completedIndexes,omitempty"`
+	// Indexes of the latest indexed Job that have failed. Only set with completionMode Indexed.
+	FailedIndexes string `json:"failedIndexes,omitempty"`
+	// The latest manual run of a scheduled SKIPJob, started with the skiperator.kartverket.no/trigger annotation.
+	// A trigger is acknowledged when it is recorded here, and the annotation can then be left as is or removed.
+	ManualTrigger *ManualTriggerStatus `json:"manualTrigger,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -34,7 +43,10 @@ type SKIPJobStatus struct {
 // +kubebuilder:object:generate=true
 // +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.cron.schedule`
 // +kubebuilder:printcolumn:name="AccessPolicies",type=string,JSONPath=`.status.accessPolicies`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:storageversion
 //
 // SKIPJob is the supported schema for the SKIPJobs API.
@@ -46,7 +58,7 @@ type SKIPJob struct {
 	Spec SKIPJobSpec `json:"spec"`
 
 	//+kubebuilder:validation:Optional
-	Status SkiperatorStatus `json:"status,omitempty"`
+	Status SKIPJobStatus `json:"status,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -63,7 +75,9 @@ type SKIPJobList struct {
 // +kubebuilder:object:generate=true
 // A SKIPJob is either defined as a one-off or a scheduled job. If the Cron field is set for SKIPJob, it may not be removed. If the Cron field is unset, it may not be added.
 // +kubebuilder:validation:XValidation:rule="(has(oldSelf.cron) && has(self.cron)) || (!has(oldSelf.cron) && !has(self.cron))", message="After creation of a SKIPJob you may not remove the Cron field if it was previously present, or add it if it was previously omitted. Please delete the SKIPJob to change its nature from a one-off/scheduled job."
-// +kubebuilder:validation:XValidation:rule="((!has(self.cron) && oldSelf.image == self.image && oldSelf.priority == self.priority && (has(oldSelf.command) == has(self.command)) && (!has(self.command) || oldSelf.command == self.command) && (has(oldSelf.resources) == has(self.resources)) && (!has(self.resources) || oldSelf.resources == self.resources) && (has(oldSelf.env) == has(self.env)) && (!has(self.env) || oldSelf.env == self.env) && (has(oldSelf.envFrom) == has(self.envFrom)) && (!has(self.envFrom) || oldSelf.envFrom == self.envFrom) && (has(oldSelf.filesFrom) == has(self.filesFrom)) && (!has(self.filesFrom) || oldSelf.filesFrom == self.filesFrom) && (has(oldSelf.additionalPorts) == has(self.additionalPorts)) && (!has(self.additionalPorts) || oldSelf.additionalPorts == self.additionalPorts) && (has(oldSelf.liveness) == has(self.liveness)) && (!has(self.liveness) || oldSelf.liveness == self.liveness) && (has(oldSelf.readiness) == has(self.readiness)) && (!has(self.readiness) || oldSelf.readiness == self.readiness) && (has(oldSelf.startup) == has(self.startup)) && (!has(self.startup) || oldSelf.startup == self.startup) && (has(oldSelf.accessPolicy) == has(self.accessPolicy)) && (!has(self.accessPolicy) || oldSelf.accessPolicy == self.accessPolicy) && (has(oldSelf.gcp) == has(self.gcp)) && (!has(self.gcp) || oldSelf.gcp == self.gcp) && (has(oldSelf.restartPolicy) == has(self.restartPolicy)) && (!has(self.restartPolicy) || oldSelf.restartPolicy == self.restartPolicy) && (has(oldSelf.podSettings) == has(self.podSettings)) && (!has(self.podSettings) || oldSelf.podSettings == self.podSettings)) || has(self.cron))", message="The container configuration is immutable for one-off jobs. Please delete your SKIPJob to change image, command, resources, networking, probes, env/files, or pod settings."
+// +kubebuilder:validation:XValidation:rule="((!has(self.cron) && oldSelf.image == self.image && oldSelf.priority == self.priority && (has(oldSelf.command) == has(self.command)) && (!has(self.command) || oldSelf.command == self.command) && (has(oldSelf.resources) == has(self.resources)) && (!has(self.resources) || oldSelf.resources == self.resources) && (has(oldSelf.env) == has(self.env)) && (!has(self.env) || oldSelf.env == self.env) && (has(oldSelf.envFrom) == has(self.envFrom)) && (!has(self.envFrom) || oldSelf.envFrom == self.envFrom) && (has(oldSelf.envFromKeys) == has(self.envFromKeys)) && (!has(self.envFromKeys) || oldSelf.envFromKeys == self.envFromKeys) && (has(oldSelf.filesFrom) == has(self.filesFrom)) && (!has(self.filesFrom) || oldSelf.filesFrom == self.filesFrom) && (has(oldSelf.additionalPorts) == has(self.additionalPorts)) && (!has(self.additionalPorts) || oldSelf.additionalPorts == self.additionalPorts) && (has(oldSelf.liveness) == has(self.liveness)) && (!has(self.liveness) || oldSelf.liveness == self.liveness) && (has(oldSelf.readiness) == has(self.readiness)) && (!has(self.readiness) || oldSelf.readiness == self.readiness) && (has(oldSelf.startup) == has(self.startup)) && (!has(self.startup) || oldSelf.startup == self.startup) && (has(oldSelf.accessPolicy) == has(self.accessPolicy)) && (!has(self.accessPolicy) || oldSelf.accessPolicy == self.accessPolicy) && (has(oldSelf.gcp) == has(self.gcp)) && (!has(self.gcp) || oldSelf.gcp == self.gcp) && (has(oldSelf.restartPolicy) == has(self.restartPolicy)) && (!has(self.restartPolicy) || oldSelf.restartPolicy == self.restartPolicy) && (has(oldSelf.podSettings) == has(self.podSettings)) && (!has(self.podSettings) || oldSelf.podSettings == self.podSettings) && (has(oldSelf.extraContainers) == has(self.extraContainers)) && (!has(self.extraContainers) || oldSelf.extraContainers == self.extraContainers)) || has(self.cron))", message="The container configuration is immutable for one-off jobs. Please delete your SKIPJob to change image, command, resources, networking, probes, env/files, pod settings, or extra containers."
+// +kubebuilder:validation:XValidation:rule="!has(self.job) || !has(self.job.podFailurePolicy) || !has(self.restartPolicy) || self.restartPolicy == 'Never'", message="podFailurePolicy requires restartPolicy Never"
+// +kubebuilder:validation:XValidation:rule="!has(self.dependsOn) || !has(self.cron)", message="dependsOn is only supported for one-off jobs"
 type SKIPJobSpec struct {
 	// Settings for the actual Job. If you use a scheduled job, the settings in here will also specify the template of the job.
 	//
@@ -75,6 +89,16 @@ type SKIPJobSpec struct {
 	//+kubebuilder:validation:Optional
 	Cron *CronSettings `json:"cron,omitempty"`
 
+	// Names of other SKIPJobs in the same namespace that must finish successfully before this Job starts.
+	// The Job is created suspended, and started when all of them report Finished. If one of them fails, or the
+	// dependencies form a cycle, the Job is not started and the error is reported in the status.
+	// Only supported for one-off jobs.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:MaxItems=20
+	//+listType=set
+	DependsOn []string `json:"dependsOn,omitempty"`
+
 	// IstioSettings are used to configure istio specific resources such as telemetry. Currently, adjusting sampling
 	// interval for tracing is the only supported option.
 	// By default, tracing is enabled with a random sampling percentage of 10%.
@@ -118,6 +142,13 @@ type SKIPJobSpec struct {
 	Env []corev1.EnvVar `json:"env,omitempty"`
 	//+kubebuilder:validation:Optional
 	EnvFrom []EnvFrom `json:"envFrom,omitempty"`
+	// Single keys of ConfigMaps or Secrets assigned to environment variables. Missing ConfigMaps,
+	// Secrets or keys are reported in the status.
+	//
+	//+kubebuilder:validation:Optional
+	//+listType=map
+	//+listMapKey=as
+	EnvFromKeys []EnvFromKey `json:"envFromKeys,omitempty"`
 	//+kubebuilder:validation:Optional
 	FilesFrom []FilesFrom `json:"filesFrom,omitempty"`
 
@@ -143,6 +174,24 @@ type SKIPJobSpec struct {
 
 	//+kubebuilder:validation:Optional
 	PodSettings *PodSettings `json:"podSettings,omitempty"`
+
+	// SecurityContext overrides the UID and GID the job container runs as, or adds capabilities
+	// to it. The rest of the least-privilege security context cannot be overridden.
+	//
+	//+kubebuilder:validation:Optional
+	SecurityContext *ContainerSecurityContext `json:"securityContext,omitempty"`
+
+	// Extra containers to run in the pods of the Job. Standard containers and init containers (type: init) run as
+	// native sidecars, which are stopped when the job container exits, so they do not keep the Job from completing.
+	// One-shot init containers (type: oneshot) run to completion before the job container starts.
+	// The operator enforces a least-privilege security context on these containers; only the overrides
+	// in securityContext are possible.
+	//
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:MaxItems=10
+	//+kubebuilder:validation:XValidation:rule="self.all(c, self.filter(x, x.name == c.name).size() == 1)",message="extraContainers names must be unique"
+	//+kubebuilder:validation:XValidation:rule="self.all(c, !has(c.ingressPort))",message="ingressPort is not supported for SKIPJob extra containers"
+	ExtraContainers []ContainerSpec `json:"extraContainers,omitempty"`
 }
 
 func (skipJob *SKIPJob) KindPostFixedName() string {
@@ -150,10 +199,10 @@ func (skipJob *SKIPJob) KindPostFixedName() string {
 }
 
 func (skipJob *SKIPJob) GetStatus() *SkiperatorStatus {
-	return &skipJob.Status
+	return &skipJob.Status.SkiperatorStatus
 }
 func (skipJob *SKIPJob) SetStatus(status SkiperatorStatus) {
-	skipJob.Status = status
+	skipJob.Status.SkiperatorStatus = status
 }
 
 func (skipJob *SKIPJob) FillDefaultSpec() {
@@ -165,7 +214,8 @@ func (skipJob *SKIPJob) FillDefaultSpec() {
 		skipJob.Spec.Job.TTLSecondsAfterFinished = &DefaultTTLSecondsAfterFinished
 	}
 
-	if skipJob.Spec.Job.BackoffLimit == nil {
+	// With backoffLimitPerIndex Kubernetes does not limit the retries of the Job as a whole unless backoffLimit is set
+	if skipJob.Spec.Job.BackoffLimit == nil && skipJob.Spec.Job.BackoffLimitPerIndex == nil {
 		skipJob.Spec.Job.BackoffLimit = &DefaultBackoffLimit
 	}
 
@@ -5,8 +5,7 @@
 package v1beta1
 
 import (
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -82,6 +81,11 @@ func (in *SKIPJobSpec) DeepCopyInto(out *SKIPJobSpec) {
 		*out = new(CronSettings)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.IstioSettings != nil {
 		in, out := &in.IstioSettings, &out.IstioSettings
 		*out = new(IstioSettingsBase)
@@ -111,7 +115,7 @@ func (in *SKIPJobSpec) DeepCopyInto(out *SKIPJobSpec) {
 	}
 	if in.Env != nil {
 		in, out := &in.Env, &out.Env
-		*out = make([]corev1.EnvVar, len(*in))
+		*out = make([]v1.EnvVar, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -119,6 +123,13 @@ func (in *SKIPJobSpec) DeepCopyInto(out *SKIPJobSpec) {
 	if in.EnvFrom != nil {
 		in, out := &in.EnvFrom, &out.EnvFrom
 		*out = make([]EnvFrom, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFromKeys != nil {
+		in, out := &in.EnvFromKeys, &out.EnvFromKeys
+		*out = make([]EnvFromKey, len(*in))
 		copy(*out, *in)
 	}
 	if in.FilesFrom != nil {
@@ -136,17 +147,17 @@ func (in *SKIPJobSpec) DeepCopyInto(out *SKIPJobSpec) {
 	if in.Liveness != nil {
 		in, out := &in.Liveness, &out.Liveness
 		*out = new(Probe)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Readiness != nil {
 		in, out := &in.Readiness, &out.Readiness
 		*out = new(Probe)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Startup != nil {
 		in, out := &in.Startup, &out.Startup
 		*out = new(Probe)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.AccessPolicy != nil {
 		in, out := &in.AccessPolicy, &out.AccessPolicy
@@ -160,7 +171,7 @@ func (in *SKIPJobSpec) DeepCopyInto(out *SKIPJobSpec) {
 	}
 	if in.RestartPolicy != nil {
 		in, out := &in.RestartPolicy, &out.RestartPolicy
-		*out = new(corev1.RestartPolicy)
+		*out = new(v1.RestartPolicy)
 		**out = **in
 	}
 	if in.PodSettings != nil {
@@ -168,6 +179,18 @@ func (in *SKIPJobSpec) DeepCopyInto(out *SKIPJobSpec) {
 		*out = new(PodSettings)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(ContainerSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraContainers != nil {
+		in, out := &in.ExtraContainers, &out.ExtraContainers
+		*out = make([]ContainerSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SKIPJobSpec.
@@ -183,12 +206,11 @@ func (in *SKIPJobSpec) DeepCopy() *SKIPJobSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SKIPJobStatus) DeepCopyInto(out *SKIPJobStatus) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.SkiperatorStatus.DeepCopyInto(&out.SkiperatorStatus)
+	if in.ManualTrigger != nil {
+		in, out := &in.ManualTrigger, &out.ManualTrigger
+		*out = new(ManualTriggerStatus)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
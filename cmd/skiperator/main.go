@@ -29,6 +29,7 @@ import (
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -118,6 +119,7 @@ func main() {
 
 	detectK8sVersion(kubeconfig)
 	requireGatewayAPI(ctx, kubeconfig)
+	tlsRouteAvailable := detectTLSRoute(ctx, kubeconfig)
 
 	pprofBindAddr := ""
 	if activeConfig.EnableProfiling {
@@ -174,6 +176,7 @@ func main() {
 		Metrics:                 metricsserver.Options{BindAddress: ":8181"},
 		LeaderElectionID:        "skiperator",
 		PprofBindAddress:        pprofBindAddr,
+		Cache:                   cache.Options{ByObject: controllers.CacheByObject()},
 	}
 	if activeConfig.EnableWebhooks {
 		opts.WebhookServer = webhookServer
@@ -204,8 +207,9 @@ func main() {
 
 	// Setup all controllers
 	err = (&controllers.ApplicationReconciler{
-		ReconcilerBase:   common.NewFromManager(mgr, mgr.GetEventRecorderFor("application-controller")),
-		SkiperatorConfig: activeConfig,
+		ReconcilerBase:    common.NewFromManager(mgr, mgr.GetEventRecorderFor("application-controller")),
+		SkiperatorConfig:  activeConfig,
+		TLSRouteAvailable: tlsRouteAvailable,
 	}).SetupWithManager(mgr, activeConfig.ConcurrentReconciles)
 	if err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Application")
@@ -339,6 +343,7 @@ func requireGatewayAPI(ctx context.Context, kubeconfig *rest.Config) {
 		xc,
 		k8sfeatures.CRDRequirement{Name: "gateways.gateway.networking.k8s.io", Versions: []string{"v1"}},
 		k8sfeatures.CRDRequirement{Name: "httproutes.gateway.networking.k8s.io", Versions: []string{"v1"}},
+		k8sfeatures.CRDRequirement{Name: "grpcroutes.gateway.networking.k8s.io", Versions: []string{"v1"}},
 		k8sfeatures.CRDRequirement{Name: "listenersets.gateway.networking.k8s.io", Versions: []string{"v1"}},
 	)
 	if err != nil {
@@ -347,3 +352,20 @@ func requireGatewayAPI(ctx context.Context, kubeconfig *rest.Config) {
 	}
 	setupLog.Info("detected Gateway API v1 with ListenerSet support")
 }
+
+// detectTLSRoute reports whether the cluster serves the TLSRoute CRD. It is only part of the Gateway API
+// experimental channel, so TLS passthrough ingresses with standard routing are rejected without it.
+func detectTLSRoute(ctx context.Context, kubeconfig *rest.Config) bool {
+	xc, err := apiextensionsclient.NewForConfig(kubeconfig)
+	if err != nil {
+		setupLog.Error(err, "could not create API extensions client")
+		os.Exit(1)
+	}
+
+	if err := k8sfeatures.CheckCRDPresent(ctx, xc, "tlsroutes.gateway.networking.k8s.io", "v1"); err != nil {
+		setupLog.Info("TLSRoute is not available, TLS passthrough ingresses with standard routing are disabled", "reason", err.Error())
+		return false
+	}
+	setupLog.Info("detected Gateway API TLSRoute support")
+	return true
+}
@@ -25,23 +25,79 @@ type RegistrySecretRef struct {
 	SecretKey  string `json:"secretKey,omitempty"` // The secret key containing the auth to the image registry
 }
 
+// SchedulingPolicy restricts which nodes teams may target through podSettings. Without a
+// policy, any node labels and tolerations may be used.
+type SchedulingPolicy struct {
+	AllowedNodeLabels     []string `json:"allowedNodeLabels,omitempty"`     // Node label keys that may be used in nodeSelector, affinity and topology keys
+	AllowedTolerationKeys []string `json:"allowedTolerationKeys,omitempty"` // Taint keys that may be tolerated. Tolerations without a key are never allowed
+}
+
+// SecurityContextPolicy limits how teams may relax the hardened security context Skiperator
+// sets on containers. Without a policy, any non-root ID may be requested but no capabilities
+// may be added.
+type SecurityContextPolicy struct {
+	AllowedCapabilities []string  `json:"allowedCapabilities,omitempty"` // Capabilities that may be added to containers, ie NET_BIND_SERVICE
+	AllowedIDRanges     []IDRange `json:"allowedIDRanges,omitempty"`     // UIDs and GIDs that may be requested for runAsUser, runAsGroup and fsGroup. Empty allows any non-root ID
+}
+
+type IDRange struct {
+	Min int64 `json:"min"` // First ID in the range
+	Max int64 `json:"max"` // Last ID in the range
+}
+
+// ImagePolicy restricts which container images may be run. It applies to the main image,
+// extra containers and the CloudSQL proxy sidecar.
+type ImagePolicy struct {
+	AllowedRegistries      []string `json:"allowedRegistries,omitempty"`      // Registries or repository paths that images may come from, ie ghcr.io/kartverket/. Matched on whole path segments of the normalized image name, so docker.io/library matches nginx. Empty allows all registries
+	ForbidLatestTag        bool     `json:"forbidLatestTag,omitempty"`        // Reject images tagged latest, and images without a tag or digest
+	RequireDigestNamespace string   `json:"requireDigestNamespace,omitempty"` // Label selector for namespaces where images must be pinned by digest, ie skip.kartverket.no/environment=production
+}
+
+// ImageVerificationPolicy lists the cosign signers trusted for images from each registry.
+// Images that do not match any registry prefix are not verified, unless RequireMatch is set.
+type ImageVerificationPolicy struct {
+	Registries  []RegistryVerification `json:"registries"`            // Trusted signers per registry, the longest matching prefix is used
+	FulcioRoots []string               `json:"fulcioRoots,omitempty"` // PEM-encoded root and intermediate certificates that keyless signing certificates must chain to
+	// PEM-encoded public keys of the Rekor transparency logs that keyless signatures must be recorded in.
+	// Required with keyless identities, as the signing certificate is only checked at the time Rekor recorded the signature.
+	RekorPublicKeys []string `json:"rekorPublicKeys,omitempty"`
+	// Rejects images that do not match any registry prefix, instead of running them unverified
+	RequireMatch bool `json:"requireMatch,omitempty"`
+}
+
+type RegistryVerification struct {
+	Prefix       string            `json:"prefix"`                 // Registry or repository path the policy applies to, ie ghcr.io/kartverket/ for ghcr.io/kartverket/app but not ghcr.io/kartverket-evil/app
+	PublicKeys   []string          `json:"publicKeys,omitempty"`   // PEM-encoded cosign public keys
+	Identities   []KeylessIdentity `json:"identities,omitempty"`   // Keyless signer identities from Fulcio certificates
+	Attestations []string          `json:"attestations,omitempty"` // In-toto predicate types that must be attested by a trusted signer, ie https://slsa.dev/provenance/v1
+}
+
+type KeylessIdentity struct {
+	Issuer        string `json:"issuer"`        // OIDC issuer of the signing certificate, ie https://token.actions.githubusercontent.com
+	SubjectRegExp string `json:"subjectRegExp"` // Regular expression the certificate subject (email or URI) must match in full
+}
+
 // SkiperatorConfig holds various configuration options for Skiperator that may differ across
 // environments or deployments (public cloud, local development or on-premises).
 type SkiperatorConfig struct {
-	TopologyKeys                []string            `json:"topologyKeys,omitempty"`                // What node labels to set when configuring pod topology spread constraints, see https://kubernetes.io/docs/concepts/scheduling-eviction/topology-spread-constraints/
-	LeaderElection              bool                `json:"leaderElection,omitempty"`              // Whether to enable leader election, must be set to true if number of Skiperator replicas > 1
-	LeaderElectionNamespace     string              `json:"leaderElectionNamespace,omitempty"`     // Namespace for leader election
-	ConcurrentReconciles        int                 `json:"concurrentReconciles,omitempty"`        // Number of concurrent reconciles that Skiperator will perform. May incur performance degradation if set too high or too low.
-	IsDeployment                bool                `json:"isDeployment,omitempty"`                // Set to true if deploying to a cluster, else set to false. Prevents running local testing against actual Kubernetes clusters
-	LogLevel                    string              `json:"logLevel,omitempty"`                    // Permitted values: info, debug, error
-	EnableProfiling             bool                `json:"enableProfiling,omitempty"`             // Enables the use of pprof to visualize and analyze profiling data of Skiperator
-	RegistrySecretRefs          []RegistrySecretRef `json:"registrySecretRefs,omitempty"`          // List of URLS and access tokens for container registries that will be inserted into all Skiperator-managed application namespaces
-	ClusterCIDRExclusionEnabled bool                `json:"clusterCIDRExclusionEnabled,omitempty"` // Set to true to prevent Skiperator-managed applications from reaching certain CIDR ranges like cluster nodes, control plane etc.
-	ClusterCIDRMap              SKIPClusterList     `json:"clusterCIDRMap,omitempty"`              // Map of the CIDR ranges to block traffic from Skiperator-managed application namespaces
-	EnableLocallyBuiltImages    bool                `json:"enableLocallyBuiltImages,omitempty"`    // Whether to enable Skiperator to allow the use of locally built container images for development purposes
-	GCPIdentityProvider         string              `json:"gcpIdentityProvider,omitempty"`         // Provider for Workload Identity Federation (WIF)
-	GCPWorkloadIdentityPool     string              `json:"gcpWorkloadIdentityPool,omitempty"`     // Identity pool for Workload Identity Federation (WIF)
-	EnableWebhooks              bool                `json:"enableWebhooks,omitempty"`              // Whether to enable webhooks for SKIPJob resources
+	TopologyKeys                []string                 `json:"topologyKeys,omitempty"`                // What node labels to set when configuring pod topology spread constraints, see https://kubernetes.io/docs/concepts/scheduling-eviction/topology-spread-constraints/
+	LeaderElection              bool                     `json:"leaderElection,omitempty"`              // Whether to enable leader election, must be set to true if number of Skiperator replicas > 1
+	LeaderElectionNamespace     string                   `json:"leaderElectionNamespace,omitempty"`     // Namespace for leader election
+	ConcurrentReconciles        int                      `json:"concurrentReconciles,omitempty"`        // Number of concurrent reconciles that Skiperator will perform. May incur performance degradation if set too high or too low.
+	IsDeployment                bool                     `json:"isDeployment,omitempty"`                // Set to true if deploying to a cluster, else set to false. Prevents running local testing against actual Kubernetes clusters
+	LogLevel                    string                   `json:"logLevel,omitempty"`                    // Permitted values: info, debug, error
+	EnableProfiling             bool                     `json:"enableProfiling,omitempty"`             // Enables the use of pprof to visualize and analyze profiling data of Skiperator
+	RegistrySecretRefs          []RegistrySecretRef      `json:"registrySecretRefs,omitempty"`          // List of URLS and access tokens for container registries that will be inserted into all Skiperator-managed application namespaces
+	ClusterCIDRExclusionEnabled bool                     `json:"clusterCIDRExclusionEnabled,omitempty"` // Set to true to prevent Skiperator-managed applications from reaching certain CIDR ranges like cluster nodes, control plane etc.
+	ClusterCIDRMap              SKIPClusterList          `json:"clusterCIDRMap,omitempty"`              // Map of the CIDR ranges to block traffic from Skiperator-managed application namespaces
+	EnableLocallyBuiltImages    bool                     `json:"enableLocallyBuiltImages,omitempty"`    // Whether to enable Skiperator to allow the use of locally built container images for development purposes
+	GCPIdentityProvider         string                   `json:"gcpIdentityProvider,omitempty"`         // Provider for Workload Identity Federation (WIF)
+	GCPWorkloadIdentityPool     string                   `json:"gcpWorkloadIdentityPool,omitempty"`     // Identity pool for Workload Identity Federation (WIF)
+	EnableWebhooks              bool                     `json:"enableWebhooks,omitempty"`              // Whether to enable webhooks for SKIPJob resources
+	SchedulingPolicy            *SchedulingPolicy        `json:"schedulingPolicy,omitempty"`            // Restricts the node labels and taints teams may use in podSettings
+	ImageVerification           *ImageVerificationPolicy `json:"imageVerification,omitempty"`           // Requires container images to carry cosign signatures and attestations from trusted signers
+	ImagePolicy                 *ImagePolicy             `json:"imagePolicy,omitempty"`                 // Restricts which registries and tags container images may use
+	SecurityContextPolicy       *SecurityContextPolicy   `json:"securityContextPolicy,omitempty"`       // Restricts the UIDs, GIDs and capabilities teams may request in security context overrides
 }
 
 var (
@@ -95,6 +151,10 @@ func parseConfig(cm *corev1.ConfigMap) error {
 		GCPIdentityProvider:         "",
 		GCPWorkloadIdentityPool:     "",
 		EnableWebhooks:              false,
+		SchedulingPolicy:            nil,
+		ImageVerification:           nil,
+		ImagePolicy:                 nil,
+		SecurityContextPolicy:       nil,
 	}
 
 	if err := dec.Decode(&cfg); err != nil {
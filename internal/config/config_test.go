@@ -37,6 +37,41 @@ func TestCustomTopologyKeys(t *testing.T) {
 	assert.Contains(t, GetActiveConfig().TopologyKeys, "skip.kartverket.no/unit-test")
 }
 
+func TestSchedulingPolicy(t *testing.T) {
+	cm := mockConfigMap(emptyConfig, nil)
+	assert.NoError(t, parseConfig(cm))
+	assert.Nil(t, GetActiveConfig().SchedulingPolicy)
+
+	cm = mockConfigMap(SkiperatorConfig{
+		SchedulingPolicy: &SchedulingPolicy{
+			AllowedNodeLabels:     []string{"skip.kartverket.no/pool"},
+			AllowedTolerationKeys: []string{"skip.kartverket.no/spot"},
+		},
+	}, nil)
+	assert.NoError(t, parseConfig(cm))
+	assert.Equal(t, []string{"skip.kartverket.no/pool"}, GetActiveConfig().SchedulingPolicy.AllowedNodeLabels)
+	assert.Equal(t, []string{"skip.kartverket.no/spot"}, GetActiveConfig().SchedulingPolicy.AllowedTolerationKeys)
+}
+
+func TestImageVerification(t *testing.T) {
+	cm := mockConfigMap(SkiperatorConfig{
+		ImageVerification: &ImageVerificationPolicy{
+			Registries: []RegistryVerification{{
+				Prefix:       "ghcr.io/kartverket/",
+				Identities:   []KeylessIdentity{{Issuer: "https://token.actions.githubusercontent.com", SubjectRegExp: "https://github.com/kartverket/.*"}},
+				Attestations: []string{"https://slsa.dev/provenance/v1"},
+			}},
+		},
+	}, nil)
+	assert.NoError(t, parseConfig(cm))
+
+	registries := GetActiveConfig().ImageVerification.Registries
+	assert.Len(t, registries, 1)
+	assert.Equal(t, "ghcr.io/kartverket/", registries[0].Prefix)
+	assert.Equal(t, "https://token.actions.githubusercontent.com", registries[0].Identities[0].Issuer)
+	assert.Equal(t, []string{"https://slsa.dev/provenance/v1"}, registries[0].Attestations)
+}
+
 func TestUnknownKey(t *testing.T) {
 	cm := mockConfigMap(emptyConfig, map[string]any{
 		"someUnknownKey": "someValue",
@@ -11,17 +11,20 @@ import (
 
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/kartverket/skiperator/api/common/digdirator"
+	commontypes "github.com/kartverket/skiperator/api/common"
 	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
 	"github.com/kartverket/skiperator/internal/config"
 	"github.com/kartverket/skiperator/internal/controllers/common"
 	jwtAuth "github.com/kartverket/skiperator/pkg/auth"
 	"github.com/kartverket/skiperator/pkg/gwapi"
+	"github.com/kartverket/skiperator/pkg/imagepolicy"
 	"github.com/kartverket/skiperator/pkg/k8sfeatures"
 	"github.com/kartverket/skiperator/pkg/log"
 	"github.com/kartverket/skiperator/pkg/mesh"
 	"github.com/kartverket/skiperator/pkg/reconciliation"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/certificate"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/deployment"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/externalsecret"
 	gatewayapigenerator "github.com/kartverket/skiperator/pkg/resourcegenerator/gatewayapi"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/gcp/auth"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/hpa"
@@ -38,6 +41,7 @@ import (
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/maskinporten"
 	networkpolicy "github.com/kartverket/skiperator/pkg/resourcegenerator/networkpolicy/dynamic"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/pdb"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/predeploy"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/prometheus"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/resourceutils"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/service"
@@ -53,6 +57,7 @@ import (
 	telemetryv1 "istio.io/client-go/pkg/apis/telemetry/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	policyv1 "k8s.io/api/policy/v1"
@@ -75,9 +80,14 @@ import (
 // +kubebuilder:rbac:groups=skiperator.kartverket.no,resources=applications;applications/status,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=core,resources=services;configmaps;serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;patch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.istio.io,resources=gateways;serviceentries;virtualservices,verbs=get;list;watch;create;update;patch;delete
@@ -86,15 +96,20 @@ import (
 // +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
-// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=listenersets;httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=listenersets;httproutes;grpcroutes;tlsroutes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=podmonitors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=nais.io,resources=maskinportenclients;idportenclients,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=external-secrets.io,resources=externalsecrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
 
 type ApplicationReconciler struct {
 	common.ReconcilerBase
 	config.SkiperatorConfig
+	// TLSRouteAvailable is set when the cluster serves the TLSRoute CRD, which TLS passthrough ingresses
+	// with standard routing require. TLSRoute is only installed with the Gateway API experimental channel.
+	TLSRouteAvailable bool
 }
 
 const applicationFinalizer = "skip.statkart.no/finalizer"
@@ -102,10 +117,14 @@ const applicationFinalizer = "skip.statkart.no/finalizer"
 var hostMatchExpression = regexp.MustCompile(`^([a-z0-9]+(-[a-z0-9]+)*\.)+[a-z]{2,}$`)
 
 func (r *ApplicationReconciler) SetupWithManager(mgr ctrl.Manager, concurrentReconciles int) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := indexEnvFromKeysSources(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&skiperatorv1alpha1.Application{}).
 		Owns(&appsv1.Deployment{}, builder.WithPredicates(common.DeploymentPredicate)).
 		Owns(&appsv1.StatefulSet{}, builder.WithPredicates(common.StatefulSetPredicate)).
+		Owns(&batchv1.Job{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&istionetworkingv1.ServiceEntry{}).
@@ -126,7 +145,14 @@ func (r *ApplicationReconciler) SetupWithManager(mgr ctrl.Manager, concurrentRec
 		Owns(&pov1.ServiceMonitor{}).
 		Owns(&gatewayapiv1.ListenerSet{}).
 		Owns(&gatewayapiv1.HTTPRoute{}).
+		Owns(&gatewayapiv1.GRPCRoute{})
+	if r.TLSRouteAvailable {
+		controllerBuilder = controllerBuilder.Owns(&gatewayapiv1.TLSRoute{})
+	}
+	return controllerBuilder.
 		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(handleDigdiratorSecret)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.handleEnvFromKeysSource)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.handleEnvFromKeysSource)).
 		Watches(&certmanagerv1.Certificate{}, handler.EnqueueRequestsFromMapFunc(handleApplicationCertRequest)).
 		WithEventFilter(
 			predicate.And(
@@ -211,7 +237,13 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req reconcile.Req
 
 	if err := validateIngresses(application); err != nil {
 		rLog.Error(err, "invalid ingress in application manifest")
-		r.SetErrorState(ctx, application, err, "invalid ingress in application manifest", "InvalidApplication")
+		r.SetErrorState(ctx, application, err, "invalid ingress in application manifest", commontypes.InvalidApplicationReason)
+		return common.DoNotRequeue()
+	}
+
+	if err := validateTLSPassthroughIngresses(application, r.TLSRouteAvailable); err != nil {
+		rLog.Error(err, "TLS passthrough is not available")
+		r.SetErrorState(ctx, application, err, "TLS passthrough is not available", commontypes.TLSRouteUnavailableReason)
 		return common.DoNotRequeue()
 	}
 
@@ -234,28 +266,89 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req reconcile.Req
 
 	if err := common.ValidateContainerImageString(application); err != nil {
 		rLog.Error(err, "invalid container image in application manifest")
-		r.SetErrorState(ctx, application, err, "invalid container image in application manifest", "InvalidApplication")
+		r.SetErrorState(ctx, application, err, "invalid container image in application manifest", commontypes.InvalidApplicationReason)
+		return common.DoNotRequeue()
+	}
+	if application.Spec.PreDeploy != nil {
+		if err := common.ValidateImageString(application.PreDeployImage()); err != nil {
+			rLog.Error(err, "invalid pre-deploy image in application manifest")
+			r.SetErrorState(ctx, application, err, "invalid pre-deploy image in application manifest", commontypes.InvalidApplicationReason)
+			return common.DoNotRequeue()
+		}
+	}
+
+	imagePolicyErrs, err := r.ValidateImagePolicy(ctx, application, r.SkiperatorConfig.ImagePolicy)
+	if err != nil {
+		rLog.Error(err, "failed to evaluate image policy")
+		r.SetErrorState(ctx, application, err, "failed to evaluate image policy", "ImagePolicyFailure")
+		return common.RequeueWithError(err)
+	}
+	if len(imagePolicyErrs) > 0 {
+		err := errors.NewInvalid(application.GroupVersionKind().GroupKind(), application.Name, imagePolicyErrs)
+		rLog.Error(err, "container images violate the image policy")
+		r.SetErrorState(ctx, application, err, "container images violate the image policy", commontypes.ImagePolicyViolationReason)
 		return common.DoNotRequeue()
 	}
 
 	if err := validateExtraContainers(application); err != nil {
 		rLog.Error(err, "invalid extra container in application manifest")
-		r.SetErrorState(ctx, application, err, "invalid extra container in application manifest", "InvalidApplication")
+		r.SetErrorState(ctx, application, err, "invalid extra container in application manifest", commontypes.InvalidApplicationReason)
+		return common.DoNotRequeue()
+	}
+
+	if errs := common.ValidatePodSettings(application.Spec.PodSettings, r.SkiperatorConfig.SchedulingPolicy); len(errs) > 0 {
+		err := errors.NewInvalid(application.GroupVersionKind().GroupKind(), application.Name, errs)
+		rLog.Error(err, "pod settings violate the scheduling policy")
+		r.SetErrorState(ctx, application, err, "pod settings violate the scheduling policy", commontypes.InvalidApplicationReason)
+		return common.DoNotRequeue()
+	}
+
+	if err := validateSecurityContexts(application, r.SkiperatorConfig.SecurityContextPolicy); err != nil {
+		rLog.Error(err, "security context overrides violate the security context policy")
+		r.SetErrorState(ctx, application, err, "security context overrides violate the security context policy", commontypes.InvalidApplicationReason)
 		return common.DoNotRequeue()
 	}
 
 	if err := validateStatefulUnchanged(application); err != nil {
 		rLog.Error(err, "spec.stateful changed")
-		r.SetErrorState(ctx, application, err, "spec.stateful cannot be changed", "InvalidApplication")
+		r.SetErrorState(ctx, application, err, "spec.stateful cannot be changed", commontypes.InvalidApplicationReason)
 		return common.DoNotRequeue()
 	}
 
 	if err := validateApplicationStatefulFields(application); err != nil {
 		rLog.Error(err, "invalid application spec")
-		r.SetErrorState(ctx, application, err, "invalid application spec", "InvalidApplication")
+		r.SetErrorState(ctx, application, err, "invalid application spec", commontypes.InvalidApplicationReason)
 		return common.DoNotRequeue()
 	}
 
+	perPodServiceErrs, err := r.perPodServiceConflicts(ctx, application)
+	if err != nil {
+		rLog.Error(err, "failed to check per-pod services")
+		r.SetErrorState(ctx, application, err, "failed to check per-pod services", "PerPodServiceFailure")
+		return common.RequeueWithError(err)
+	}
+	if len(perPodServiceErrs) > 0 {
+		err := errors.NewInvalid(application.GroupVersionKind().GroupKind(), application.Name, perPodServiceErrs)
+		rLog.Error(err, "per-pod services conflict with existing resources")
+		r.SetErrorState(ctx, application, err, "per-pod services conflict with existing resources", "PerPodServiceConflict")
+		// The conflicting resources may be removed later
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	envFromKeysErrs, envFromKeysHash, err := r.ResolveEnvFromKeys(ctx, application.Namespace, application.Spec.EnvFromKeys)
+	if err != nil {
+		rLog.Error(err, "failed to resolve envFromKeys")
+		r.SetErrorState(ctx, application, err, "failed to resolve envFromKeys", "EnvFromKeysFailure")
+		return common.RequeueWithError(err)
+	}
+	if len(envFromKeysErrs) > 0 {
+		err := errors.NewInvalid(application.GroupVersionKind().GroupKind(), application.Name, envFromKeysErrs)
+		rLog.Error(err, "envFromKeys reference missing ConfigMaps, Secrets or keys")
+		r.SetErrorState(ctx, application, err, "envFromKeys reference missing ConfigMaps, Secrets or keys", "EnvFromKeyNotFound")
+		// The ConfigMap or Secret may be created later
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+
 	//We try to feed the access policy with port values dynamically,
 	//if unsuccessfull we just don't set ports, and rely on podselectors
 	r.UpdateAccessPolicy(ctx, application)
@@ -270,6 +363,7 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req reconcile.Req
 	}
 
 	reconciliationApp := reconciliation.NewApplicationReconciliation(ctx, application, rLog, meshMode, r.GetRestConfig(), authConfigs, r.SkiperatorConfig)
+	reconciliationApp.SetEnvFromKeysHash(envFromKeysHash)
 	routingState, err := gwapi.EvaluateRoutingState(ctx, r.GetClient(), application, application.GetStatus())
 	if err != nil {
 		// A failed routing-state lookup must not be read as "legacy absent":
@@ -280,6 +374,42 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req reconcile.Req
 	}
 	reconciliationApp.SetGenerateLegacyRouting(routingState.GenerateLegacyRouting)
 
+	preDeployCondition, err := r.resolvePreDeploy(ctx, application, reconciliationApp)
+	if err != nil {
+		rLog.Error(err, "failed to resolve pre-deploy job")
+		r.SetErrorState(ctx, application, err, "failed to resolve pre-deploy job", "PreDeployFailure")
+		return common.RequeueWithError(err)
+	}
+
+	// Volume claim templates are pinned to the current image while a pre-deploy Job holds the rollout
+	var volumeResize *volumeClaimResize
+	if !reconciliationApp.RolloutHeld() {
+		volumeResize, err = r.planVolumeClaimResize(ctx, application)
+		if err != nil {
+			if errors.IsInvalid(err) {
+				rLog.Error(err, "invalid volume claim template resize")
+				r.SetErrorState(ctx, application, err, "volume claim templates cannot be resized", commontypes.InvalidApplicationReason)
+				return common.DoNotRequeue()
+			}
+			rLog.Error(err, "failed to resize volume claims")
+			r.SetErrorState(ctx, application, err, "failed to resize volume claims", "VolumeResizeFailure")
+			return common.RequeueWithError(err)
+		}
+	}
+
+	rolloutRequeueAfter, err := r.resolveStagedRollout(ctx, application, reconciliationApp)
+	if err != nil {
+		rLog.Error(err, "failed to resolve staged rollout")
+		r.SetErrorState(ctx, application, err, "failed to resolve staged rollout", "StagedRolloutFailure")
+		return common.RequeueWithError(err)
+	}
+
+	if err := r.resolveAutoRollback(ctx, application, reconciliationApp); err != nil {
+		rLog.Error(err, "failed to resolve automatic rollback")
+		r.SetErrorState(ctx, application, err, "failed to resolve automatic rollback", "AutoRollbackFailure")
+		return common.RequeueWithError(err)
+	}
+
 	// Prime migration status (start time + stall detection) from current
 	// readiness before generating resources, so the migration clock advances and
 	// stalls are surfaced even if resource generation keeps failing. The status
@@ -290,8 +420,10 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req reconcile.Req
 
 	//TODO status and conditions in application object
 	funcs := []reconciliationFunc{
+		imagepolicy.Verify,
 		certificate.Generate,
 		service.Generate,
+		externalsecret.Generate,
 		auth.Generate,
 		serviceentry.Generate,
 		gateway.Generate,
@@ -310,6 +442,7 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req reconcile.Req
 		prometheus.Generate,
 		idporten.Generate,
 		maskinporten.Generate,
+		predeploy.Generate,
 	}
 	if application.IsStateful() {
 		funcs = append(funcs, statefulset.Generate)
@@ -343,6 +476,22 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req reconcile.Req
 		return common.RequeueWithError(err)
 	}
 
+	// PVCs are only provisioned from snapshots once the workload that mounts them has been generated and verified
+	if err = r.createRestoredClaims(ctx, application); err != nil {
+		rLog.Error(err, "failed to restore volumes from snapshots")
+		r.SetErrorState(ctx, application, err, "failed to restore volumes from snapshots", "VolumeRestoreFailure")
+		return common.RequeueWithError(err)
+	}
+
+	// The StatefulSet is only deleted once its replacement has been generated and verified, and is created again below
+	if volumeResize != nil {
+		if err = r.resizeVolumeClaims(ctx, application, volumeResize); err != nil {
+			rLog.Error(err, "failed to resize volume claims")
+			r.SetErrorState(ctx, application, err, "failed to resize volume claims", "VolumeResizeFailure")
+			return common.RequeueWithError(err)
+		}
+	}
+
 	processor := resourceprocessor.NewResourceProcessor(r.GetClient(), resourceschemas.GetApplicationSchemas(r.GetScheme()), r.GetScheme())
 
 	if errs := processor.Process(reconciliationApp); len(errs) > 0 {
@@ -354,10 +503,74 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req reconcile.Req
 		return common.RequeueWithError(err)
 	}
 
+	backupRequeueAfter, err := r.reconcileBackups(ctx, application)
+	if err != nil {
+		rLog.Error(err, "failed to take volume snapshots")
+		r.SetErrorState(ctx, application, err, "failed to take volume snapshots", "VolumeSnapshotFailure")
+		return common.RequeueWithError(err)
+	}
+	// Snapshots are taken on schedule and staged rollouts advance as pods become ready, not on changes to the Application
+	requeueAfter := minRequeue(backupRequeueAfter, rolloutRequeueAfter)
+	// A pre-deploy Job with changed immutable fields has been deleted, and is created again once the deletion has finished
+	if reconciliationApp.RecreatePending() {
+		requeueAfter = minRequeue(requeueAfter, 5*time.Second)
+	}
+
+	unsyncedSecrets, err := externalsecret.Unsynced(ctx, r.GetClient(), reconciliationApp.GetResources())
+	if err != nil {
+		rLog.Error(err, "failed to check external secrets")
+		r.SetErrorState(ctx, application, err, "failed to check external secrets", "ExternalSecretFailure")
+		return common.RequeueWithError(err)
+	}
+	if len(unsyncedSecrets) > 0 {
+		r.SetProgressingState(ctx, application, fmt.Sprintf("Waiting for external secrets to sync: %s", strings.Join(unsyncedSecrets, ", ")))
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	// Pre-deploy Job changes requeue the Application
+	if preDeployCondition != nil && preDeployCondition.Status == metav1.ConditionFalse {
+		err := goerrors.New(preDeployCondition.Message)
+		rLog.Error(err, "pre-deploy job failed")
+		r.SetErrorState(ctx, application, err, "pre-deploy job failed, the previous image is kept", preDeployCondition.Reason)
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}
+	if preDeployCondition != nil && preDeployCondition.Status == metav1.ConditionUnknown {
+		r.SetProgressingState(ctx, application, preDeployCondition.Message)
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	initContainersPending, err := r.updateInitContainersCondition(ctx, application)
+	if err != nil {
+		rLog.Error(err, "failed to check init containers")
+		r.SetErrorState(ctx, application, err, "failed to check init containers", "InitContainersFailure")
+		return common.RequeueWithError(err)
+	}
+
+	rolloutProgressing, err := r.updateRolloutStatus(ctx, application)
+	if err != nil {
+		rLog.Error(err, "failed to check rollout")
+		r.SetErrorState(ctx, application, err, "failed to check rollout", "RolloutStatusFailure")
+		return common.RequeueWithError(err)
+	}
+
+	volumeResizesPending, err := r.updateVolumeResizesStatus(ctx, application)
+	if err != nil {
+		rLog.Error(err, "failed to check volume resizes")
+		r.SetErrorState(ctx, application, err, "failed to check volume resizes", "VolumeResizeFailure")
+		return common.RequeueWithError(err)
+	}
+
 	r.setSyncedApplicationState(ctx, application, "Application has been reconciled", routingState)
 	if application.UsesStandardRouting() && !routingState.Readiness.Ready {
 		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
 	}
+	if initContainersPending || volumeResizesPending || rolloutProgressing || rollbackPending(application) {
+		return reconcile.Result{RequeueAfter: minRequeue(30*time.Second, requeueAfter)}, nil
+	}
+
+	if requeueAfter > 0 {
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}
 
 	return common.DoNotRequeue()
 }
@@ -380,6 +593,7 @@ func (r *ApplicationReconciler) setSyncedApplicationState(ctx context.Context, a
 
 func (r *ApplicationReconciler) updateApplicationStatus(ctx context.Context, app *skiperatorv1alpha1.Application) {
 	key := client.ObjectKeyFromObject(app)
+	app.GetStatus().SetKStatusConditions(app.GetGeneration())
 	app.GetStatus().SortConditions()
 	desiredStatus := app.Status.DeepCopy()
 
@@ -591,6 +805,23 @@ func validateIngresses(application *skiperatorv1alpha1.Application) error {
 	return nil
 }
 
+// validateTLSPassthroughIngresses rejects TLS passthrough ingresses with standard routing when the cluster
+// does not serve the TLSRoute CRD. Availability is detected when the operator starts.
+func validateTLSPassthroughIngresses(application *skiperatorv1alpha1.Application, tlsRouteAvailable bool) error {
+	if tlsRouteAvailable || !application.UsesStandardRouting() {
+		return nil
+	}
+	for i, ingress := range application.Spec.TCPIngresses {
+		if ingress.IsTLSPassthrough() {
+			return errors.NewInvalid(application.GroupVersionKind().GroupKind(), application.Name, field.ErrorList{
+				field.Invalid(field.NewPath("spec").Child("tcpIngresses").Index(i), ingress.Hostname,
+					"TLS passthrough with spec.routingProvider=Standard requires the TLSRoute CRD from the Gateway API experimental channel, which is not installed in the cluster. Skiperator must be restarted after installing it"),
+			})
+		}
+	}
+	return nil
+}
+
 // validateExtraContainers covers the extra-container rules that CRD CEL
 // validation cannot express: image references are parsed with the OCI registry
 // library, and the container name is compared against the application name
@@ -622,6 +853,21 @@ func validateExtraContainers(application *skiperatorv1alpha1.Application) error
 	return nil
 }
 
+// validateSecurityContexts checks the pod-wide and per-container security context
+// overrides against the cluster's security context policy.
+func validateSecurityContexts(application *skiperatorv1alpha1.Application, policy *config.SecurityContextPolicy) error {
+	errs := common.ValidatePodSecurityContext(application.Spec.PodSettings, policy)
+	errs = append(errs, common.ValidateContainerSecurityContext(field.NewPath("spec").Child("securityContext"), application.Spec.SecurityContext, policy)...)
+	for i, c := range application.Spec.ExtraContainers {
+		errs = append(errs, common.ValidateContainerSecurityContext(field.NewPath("spec").Child("extraContainers").Index(i).Child("securityContext"), c.SecurityContext, policy)...)
+	}
+
+	if len(errs) > 0 {
+		return errors.NewInvalid(application.GroupVersionKind().GroupKind(), application.Name, errs)
+	}
+	return nil
+}
+
 func (r *ApplicationReconciler) getAuthConfigsForApplication(ctx context.Context, application *skiperatorv1alpha1.Application) (*jwtAuth.AuthConfigs, error) {
 	var authConfigs jwtAuth.AuthConfigs
 
@@ -777,5 +1023,34 @@ func validateApplicationStatefulFields(app *skiperatorv1alpha1.Application) erro
 		}
 	}
 
+	if network := app.Spec.Stateful.Network; network != nil {
+		// Peer ports share the headless Service with the application ports
+		usedNames := map[string]bool{"http": true, mesh.MetricsPortName.StrVal: true}
+		usedPorts := map[int32]bool{int32(app.Spec.Port): true, mesh.MetricsPortNumber.IntVal: true}
+		for _, p := range app.Spec.AdditionalPorts {
+			usedNames[p.Name] = true
+			usedPorts[p.Port] = true
+		}
+		for _, p := range network.PeerPorts {
+			if usedNames[p.Name] || usedPorts[p.Port] {
+				return fmt.Errorf("spec.stateful.network.peerPorts: port %s (%d) is already exposed by the application", p.Name, p.Port)
+			}
+		}
+	}
+
+	if app.Spec.Strategy.AutoRollback != nil {
+		return fmt.Errorf("spec.strategy.autoRollback is not supported for stateful workloads")
+	}
+
+	if app.Spec.Stateful.Rollout == skiperatorv1alpha1.StatefulRolloutStaged && app.Spec.Stateful.Partition != nil {
+		return fmt.Errorf("spec.stateful.partition cannot be set with spec.stateful.rollout=staged, the partition is managed by the rollout")
+	}
+
+	if app.Spec.Stateful.Backup != nil {
+		if _, _, err := backupSchedule(app.Spec.Stateful.Backup); err != nil {
+			return fmt.Errorf("invalid spec.stateful.backup.schedule: %w", err)
+		}
+	}
+
 	return nil
 }
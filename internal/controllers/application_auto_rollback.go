@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	commontypes "github.com/kartverket/skiperator/api/common"
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Default restarts of a crash looping container of a new pod before its rollout is rolled back
+const defaultCrashLoopRollbackRestarts = 3
+
+// resolveAutoRollback records the image of the Deployment of an Application with spec.strategy.autoRollback
+// once its rollout has become fully available, and rolls a failed rollout back to that image. The rollback is
+// kept until the generation of the Application changes.
+func (r *ApplicationReconciler) resolveAutoRollback(ctx context.Context, application *skiperatorv1alpha1.Application, reconciliationApp reconciliation.Reconciliation) error {
+	if application.IsStateful() || application.Spec.Strategy.AutoRollback == nil {
+		application.Status.LastHealthyImage = ""
+		application.Status.Rollback = nil
+		meta.RemoveStatusCondition(&application.Status.Conditions, commontypes.RolledBackConditionType)
+		return nil
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := r.GetClient().Get(ctx, client.ObjectKeyFromObject(application), deployment); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get deployment: %w", err)
+		}
+		deployment = nil
+	}
+	var podTemplateHash string
+	var pods []corev1.Pod
+	if deployment != nil {
+		newReplicaSet, err := r.getNewReplicaSet(ctx, deployment)
+		if err != nil {
+			return err
+		}
+		if newReplicaSet != nil {
+			podTemplateHash = newReplicaSet.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+		}
+		podList := corev1.PodList{}
+		if err := r.GetClient().List(ctx, &podList, client.InNamespace(application.Namespace), client.MatchingLabels(util.GetPodAppSelector(application.Name))); err != nil {
+			return fmt.Errorf("failed to list pods: %w", err)
+		}
+		pods = podList.Items
+	}
+
+	previous := application.Status.Rollback
+	lastHealthyImage, rollback := planAutoRollback(time.Now(), application, deployment, podTemplateHash, pods)
+	application.Status.LastHealthyImage = lastHealthyImage
+	application.Status.Rollback = rollback
+
+	if rollback == nil {
+		application.GetStatus().SetRolledBackCondition(metav1.ConditionFalse, application.GetGeneration(), "RunningSpecImage", "The Deployment runs the image in spec")
+		return nil
+	}
+	message := fmt.Sprintf("Running %s instead of %s, which failed to roll out: %s", rollback.Image, rollback.FailedImage, rollback.Reason)
+	application.GetStatus().SetRolledBackCondition(metav1.ConditionTrue, application.GetGeneration(), "RolledBack", message)
+	if previous == nil || previous.Generation != rollback.Generation {
+		r.EmitWarningEvent(application, "RolledBack", fmt.Sprintf("Rolled back to %s after the rollout of %s failed: %s", rollback.Image, rollback.FailedImage, rollback.Reason))
+	}
+	reconciliationApp.RollBackImage(rollback.Image)
+	return nil
+}
+
+// planAutoRollback returns the last healthy image of the application container and the rollback of the
+// Application. The image of the Deployment is healthy once its rollout is complete and available. A rollout of
+// another image fails when the Deployment exceeds its progress deadline, or a container of a pod of the new
+// ReplicaSet with the given pod-template-hash is crash looping after spec.strategy.autoRollback.crashLoopRestarts
+// restarts.
+func planAutoRollback(now time.Time, application *skiperatorv1alpha1.Application, deployment *appsv1.Deployment, podTemplateHash string, pods []corev1.Pod) (string, *skiperatorv1alpha1.RollbackStatus) {
+	lastHealthyImage := application.Status.LastHealthyImage
+	rollback := application.Status.Rollback
+	// A new spec is rolled out again
+	if rollback != nil && rollback.Generation != application.GetGeneration() {
+		rollback = nil
+	}
+	if deployment == nil || rollback != nil {
+		return lastHealthyImage, rollback
+	}
+
+	image := containerImage(deployment.Spec.Template.Spec.Containers, application.Name)
+	state := deploymentRolloutState(deployment, podTemplateHash, pods)
+	if state.progressing.reason == "RolloutComplete" && state.available.status == metav1.ConditionTrue {
+		return image, nil
+	}
+	if lastHealthyImage == "" || image == lastHealthyImage {
+		return lastHealthyImage, nil
+	}
+
+	reason := ""
+	if deploymentProgressDeadlineExceeded(deployment) {
+		reason = "ProgressDeadlineExceeded"
+	} else if podTemplateHash != "" && crashLooping(pods, podTemplateHash, crashLoopRollbackRestarts(application)) {
+		reason = "CrashLoopBackOff"
+	}
+	if reason == "" {
+		return lastHealthyImage, nil
+	}
+	return lastHealthyImage, &skiperatorv1alpha1.RollbackStatus{
+		FailedImage: image,
+		Image:       lastHealthyImage,
+		Generation:  application.GetGeneration(),
+		Reason:      reason,
+		Time:        metav1.NewTime(now),
+	}
+}
+
+// crashLooping reports whether a container of a pod with the pod-template-hash is crash looping after
+// the given number of restarts
+func crashLooping(pods []corev1.Pod, podTemplateHash string, restarts int32) bool {
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey] != podTemplateHash {
+			continue
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			waiting := containerStatus.State.Waiting
+			if waiting != nil && waiting.Reason == "CrashLoopBackOff" && containerStatus.RestartCount >= restarts {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rollbackPending reports whether a failed rollout of an Application with spec.strategy.autoRollback has not
+// been rolled back yet. Changes to the Deployment status do not trigger reconciles.
+func rollbackPending(application *skiperatorv1alpha1.Application) bool {
+	return application.Spec.Strategy.AutoRollback != nil && application.Status.LastHealthyImage != "" && application.Status.Rollback == nil &&
+		meta.IsStatusConditionTrue(application.Status.Conditions, commontypes.RolloutFailedConditionType)
+}
+
+// crashLoopRollbackRestarts returns the restarts of a crash looping container of a new pod before the rollout
+// of the Application is rolled back
+func crashLoopRollbackRestarts(application *skiperatorv1alpha1.Application) int32 {
+	if application.Spec.Strategy.AutoRollback == nil || application.Spec.Strategy.AutoRollback.CrashLoopRestarts < 1 {
+		return defaultCrashLoopRollbackRestarts
+	}
+	return application.Spec.Strategy.AutoRollback.CrashLoopRestarts
+}
+
+// containerImage returns the image of the container with the given name
+func containerImage(containers []corev1.Container, name string) string {
+	for _, container := range containers {
+		if container.Name == name {
+			return container.Image
+		}
+	}
+	return ""
+}
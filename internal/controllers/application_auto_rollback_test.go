@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	commontypes "github.com/kartverket/skiperator/api/common"
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/internal/config"
+	controllercommon "github.com/kartverket/skiperator/internal/controllers/common"
+	"github.com/kartverket/skiperator/pkg/log"
+	"github.com/kartverket/skiperator/pkg/mesh"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/resourceschemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	healthyImage = "ghcr.io/kartverket/web@sha256:1111"
+	brokenImage  = "ghcr.io/kartverket/web@sha256:2222"
+)
+
+func autoRollbackApplication(lastHealthyImage string) *skiperatorv1alpha1.Application {
+	application := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a", Generation: 4},
+		Spec:       skiperatorv1alpha1.ApplicationSpec{Strategy: skiperatorv1alpha1.Strategy{AutoRollback: &skiperatorv1alpha1.AutoRollback{}}},
+	}
+	application.Status.LastHealthyImage = lastHealthyImage
+	return application
+}
+
+func rollbackDeployment(image string, updated int32, available int32) *appsv1.Deployment {
+	deployment := rolloutDeployment(updated, available)
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "web", Image: image}}
+	return deployment
+}
+
+func TestPlanAutoRollbackRecordsHealthyImage(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+	lastHealthyImage, rollback := planAutoRollback(now, autoRollbackApplication(""), rollbackDeployment(healthyImage, 2, 2), "new", nil)
+	assert.Equal(t, healthyImage, lastHealthyImage)
+	assert.Nil(t, rollback)
+
+	// A rollout in progress is not healthy yet
+	lastHealthyImage, rollback = planAutoRollback(now, autoRollbackApplication(healthyImage), rollbackDeployment(brokenImage, 1, 2), "new", nil)
+	assert.Equal(t, healthyImage, lastHealthyImage)
+	assert.Nil(t, rollback)
+}
+
+func TestPlanAutoRollbackOnCrashLoop(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	pod := crashingPod("web-new-abcde", map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "new"}, "CrashLoopBackOff")
+	pod.Status.ContainerStatuses[0].RestartCount = defaultCrashLoopRollbackRestarts - 1
+
+	_, rollback := planAutoRollback(now, autoRollbackApplication(healthyImage), rollbackDeployment(brokenImage, 1, 2), "new", []corev1.Pod{pod})
+	assert.Nil(t, rollback)
+
+	pod.Status.ContainerStatuses[0].RestartCount = defaultCrashLoopRollbackRestarts
+	lastHealthyImage, rollback := planAutoRollback(now, autoRollbackApplication(healthyImage), rollbackDeployment(brokenImage, 1, 2), "new", []corev1.Pod{pod})
+	assert.Equal(t, healthyImage, lastHealthyImage)
+	require.NotNil(t, rollback)
+	assert.Equal(t, skiperatorv1alpha1.RollbackStatus{
+		FailedImage: brokenImage,
+		Image:       healthyImage,
+		Generation:  4,
+		Reason:      "CrashLoopBackOff",
+		Time:        metav1.NewTime(now),
+	}, *rollback)
+
+	// Without a healthy image there is nothing to roll back to
+	_, rollback = planAutoRollback(now, autoRollbackApplication(""), rollbackDeployment(brokenImage, 1, 2), "new", []corev1.Pod{pod})
+	assert.Nil(t, rollback)
+
+	// The number of restarts is configurable
+	application := autoRollbackApplication(healthyImage)
+	application.Spec.Strategy.AutoRollback.CrashLoopRestarts = defaultCrashLoopRollbackRestarts + 2
+	_, rollback = planAutoRollback(now, application, rollbackDeployment(brokenImage, 1, 2), "new", []corev1.Pod{pod})
+	assert.Nil(t, rollback)
+
+	pod.Status.ContainerStatuses[0].RestartCount = defaultCrashLoopRollbackRestarts + 2
+	_, rollback = planAutoRollback(now, application, rollbackDeployment(brokenImage, 1, 2), "new", []corev1.Pod{pod})
+	assert.NotNil(t, rollback)
+}
+
+func TestPlanAutoRollbackOnProgressDeadline(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	deployment := rollbackDeployment(brokenImage, 1, 2)
+	deployment.Status.Conditions = append(deployment.Status.Conditions, appsv1.DeploymentCondition{
+		Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded",
+	})
+
+	_, rollback := planAutoRollback(now, autoRollbackApplication(healthyImage), deployment, "new", nil)
+	require.NotNil(t, rollback)
+	assert.Equal(t, "ProgressDeadlineExceeded", rollback.Reason)
+}
+
+func TestPlanAutoRollbackKeptUntilSpecChanges(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	application := autoRollbackApplication(healthyImage)
+	application.Status.Rollback = &skiperatorv1alpha1.RollbackStatus{FailedImage: brokenImage, Image: healthyImage, Generation: 4, Reason: "CrashLoopBackOff"}
+
+	_, rollback := planAutoRollback(now, application, rollbackDeployment(healthyImage, 2, 2), "old", nil)
+	assert.Equal(t, application.Status.Rollback, rollback)
+
+	application.Generation = 5
+	_, rollback = planAutoRollback(now, application, rollbackDeployment(healthyImage, 2, 2), "old", nil)
+	assert.Nil(t, rollback)
+}
+
+func TestResolveAutoRollback(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	deployment := rollbackDeployment(brokenImage, 1, 2)
+	deployment.Status.Conditions = append(deployment.Status.Conditions, appsv1.DeploymentCondition{
+		Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded",
+	})
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &ApplicationReconciler{ReconcilerBase: controllercommon.NewReconcilerBase(c, nil, scheme, nil, recorder)}
+	application := autoRollbackApplication(healthyImage)
+	reconciliationApp := reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+
+	require.NoError(t, r.resolveAutoRollback(context.Background(), application, reconciliationApp))
+	assert.Equal(t, healthyImage, reconciliationApp.RolledBackImage())
+	assert.True(t, meta.IsStatusConditionTrue(application.Status.Conditions, commontypes.RolledBackConditionType))
+	assert.Len(t, recorder.Events, 1)
+
+	// The event is only emitted when rolling back
+	reconciliationApp = reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+	require.NoError(t, r.resolveAutoRollback(context.Background(), application, reconciliationApp))
+	assert.Equal(t, healthyImage, reconciliationApp.RolledBackImage())
+	assert.Len(t, recorder.Events, 1)
+
+	application.Spec.Strategy.AutoRollback = nil
+	reconciliationApp = reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+	require.NoError(t, r.resolveAutoRollback(context.Background(), application, reconciliationApp))
+	assert.Empty(t, reconciliationApp.RolledBackImage())
+	assert.Nil(t, application.Status.Rollback)
+	assert.Nil(t, meta.FindStatusCondition(application.Status.Conditions, commontypes.RolledBackConditionType))
+}
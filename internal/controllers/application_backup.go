@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/statefulset"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/volumesnapshot"
+	"github.com/kartverket/skiperator/pkg/util"
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Snapshots that are being taken and PVCs that are not bound yet are checked again after this interval
+const backupPendingRequeue = 30 * time.Second
+
+// backupClaim is a bound PVC of a stateful Application
+type backupClaim struct {
+	name    string
+	ordinal int32
+	created time.Time
+}
+
+// backupPlan is what reconcileBackups has to do to bring the snapshots of the PVCs up to date
+type backupPlan struct {
+	create       []backupClaim
+	prune        []*unstructured.Unstructured
+	status       []skiperatorv1alpha1.StatefulBackupStatus
+	requeueAfter time.Duration
+}
+
+// backupSchedule parses the schedule of spec.stateful.backup in its time zone, which is UTC if it is not set
+func backupSchedule(backup *skiperatorv1alpha1.StatefulBackup) (cron.Schedule, *time.Location, error) {
+	schedule, err := cron.ParseStandard(backup.Schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schedule %q: %w", backup.Schedule, err)
+	}
+	location := time.UTC
+	if backup.TimeZone != nil {
+		if location, err = time.LoadLocation(*backup.TimeZone); err != nil {
+			return nil, nil, fmt.Errorf("invalid time zone %q: %w", *backup.TimeZone, err)
+		}
+	}
+	return schedule, location, nil
+}
+
+// reconcileBackups takes the VolumeSnapshots of the PVCs of a stateful Application that are due, prunes the
+// snapshots beyond the retention and records the latest ready snapshot of each PVC in the status. It returns
+// when the Application has to be reconciled again for the next snapshot, or zero without a backup.
+func (r *ApplicationReconciler) reconcileBackups(ctx context.Context, application *skiperatorv1alpha1.Application) (time.Duration, error) {
+	if !application.IsStateful() || application.Spec.Stateful.Backup == nil {
+		application.Status.Backups = nil
+		return 0, nil
+	}
+	backup := application.Spec.Stateful.Backup
+	schedule, location, err := backupSchedule(backup)
+	if err != nil {
+		return 0, err
+	}
+
+	claims, unbound, err := r.getBackupClaims(ctx, application)
+	if err != nil {
+		return 0, err
+	}
+
+	snapshots := unstructured.UnstructuredList{}
+	snapshots.SetGroupVersionKind(volumesnapshot.GroupVersionKind.GroupVersion().WithKind(volumesnapshot.GroupVersionKind.Kind + "List"))
+	if err := r.GetClient().List(ctx, &snapshots, client.InNamespace(application.Namespace), client.MatchingLabels(application.GetDefaultLabels())); err != nil {
+		return 0, fmt.Errorf("failed to list volume snapshots: %w", err)
+	}
+
+	now := time.Now().In(location)
+	plan := planBackups(now, schedule, int(backup.Retention), claims, snapshots.Items)
+
+	for _, claim := range plan.create {
+		snapshot := volumesnapshot.New(application, claim.name, claim.ordinal, now)
+		if err := r.GetClient().Create(ctx, snapshot); err != nil && !errors.IsAlreadyExists(err) {
+			return 0, fmt.Errorf("failed to create volume snapshot of %s: %w", claim.name, err)
+		}
+		r.EmitNormalEvent(application, "VolumeSnapshotCreated", fmt.Sprintf("Created volume snapshot %s of %s", snapshot.GetName(), claim.name))
+	}
+	for _, snapshot := range plan.prune {
+		if err := r.GetClient().Delete(ctx, snapshot); client.IgnoreNotFound(err) != nil {
+			return 0, fmt.Errorf("failed to delete volume snapshot %s: %w", snapshot.GetName(), err)
+		}
+	}
+	application.Status.Backups = plan.status
+
+	requeueAfter := plan.requeueAfter
+	if unbound {
+		requeueAfter = min(requeueAfter, backupPendingRequeue)
+	}
+	return requeueAfter, nil
+}
+
+// getBackupClaims returns the bound PVCs of the replicas of the Application, and whether some of them do not
+// exist or are not bound yet
+func (r *ApplicationReconciler) getBackupClaims(ctx context.Context, application *skiperatorv1alpha1.Application) ([]backupClaim, bool, error) {
+	replicas, err := skiperatorv1alpha1.GetStaticReplicas(application.Spec.Replicas)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var claims []backupClaim
+	unbound := false
+	for ordinal := int32(0); ordinal < int32(replicas); ordinal++ {
+		for _, template := range application.Spec.Stateful.VolumeClaimTemplates {
+			pvc := &corev1.PersistentVolumeClaim{}
+			key := client.ObjectKey{Namespace: application.Namespace, Name: statefulset.ClaimName(template.Name, application.Name, ordinal)}
+			if err := r.GetClient().Get(ctx, key, pvc); err != nil {
+				if errors.IsNotFound(err) {
+					unbound = true
+					continue
+				}
+				return nil, false, fmt.Errorf("failed to get persistent volume claim %s: %w", key.Name, err)
+			}
+			if pvc.Status.Phase != corev1.ClaimBound {
+				unbound = true
+				continue
+			}
+			claims = append(claims, backupClaim{name: pvc.Name, ordinal: ordinal, created: pvc.CreationTimestamp.Time})
+		}
+	}
+	return claims, unbound, nil
+}
+
+// planBackups decides which PVCs are due a snapshot at now and which snapshots to prune. A PVC is due when the
+// schedule has passed since its latest snapshot, or since it was created. The newest snapshots up to retention
+// are kept per PVC, including the one being taken, and so is the latest ready one.
+func planBackups(now time.Time, schedule cron.Schedule, retention int, claims []backupClaim, snapshots []unstructured.Unstructured) backupPlan {
+	plan := backupPlan{}
+
+	byClaim := map[string][]*unstructured.Unstructured{}
+	for i := range snapshots {
+		name := volumesnapshot.ClaimName(&snapshots[i])
+		byClaim[name] = append(byClaim[name], &snapshots[i])
+	}
+	for _, claimSnapshots := range byClaim {
+		slices.SortFunc(claimSnapshots, func(a, b *unstructured.Unstructured) int {
+			return b.GetCreationTimestamp().Compare(a.GetCreationTimestamp().Time)
+		})
+	}
+
+	creating := map[string]bool{}
+	for _, claim := range claims {
+		last := claim.created
+		claimSnapshots := byClaim[claim.name]
+		if len(claimSnapshots) > 0 {
+			last = claimSnapshots[0].GetCreationTimestamp().Time
+			if !volumesnapshot.IsReady(claimSnapshots[0]) {
+				plan.requeueAfter = minRequeue(plan.requeueAfter, backupPendingRequeue)
+			}
+		}
+
+		next := schedule.Next(last.In(now.Location()))
+		if !next.IsZero() && !next.After(now) {
+			plan.create = append(plan.create, claim)
+			creating[claim.name] = true
+			next = schedule.Next(now)
+		}
+		if !next.IsZero() {
+			plan.requeueAfter = minRequeue(plan.requeueAfter, next.Sub(now))
+		}
+	}
+
+	for _, claimName := range slices.Sorted(maps.Keys(byClaim)) {
+		claimSnapshots := byClaim[claimName]
+		keep := retention
+		if creating[claimName] {
+			keep--
+		}
+
+		var latestReady *unstructured.Unstructured
+		for _, snapshot := range claimSnapshots {
+			if volumesnapshot.IsReady(snapshot) {
+				latestReady = snapshot
+				break
+			}
+		}
+		for i, snapshot := range claimSnapshots {
+			if i >= keep && snapshot != latestReady {
+				plan.prune = append(plan.prune, snapshot)
+			}
+		}
+
+		if latestReady != nil {
+			plan.status = append(plan.status, skiperatorv1alpha1.StatefulBackupStatus{
+				Ordinal:               volumesnapshot.Ordinal(latestReady),
+				PersistentVolumeClaim: claimName,
+				VolumeSnapshot:        latestReady.GetName(),
+				CreationTime:          volumesnapshot.CreationTime(latestReady),
+			})
+		}
+	}
+	slices.SortStableFunc(plan.status, func(a, b skiperatorv1alpha1.StatefulBackupStatus) int {
+		return cmp.Compare(a.Ordinal, b.Ordinal)
+	})
+
+	return plan
+}
+
+// minRequeue returns the earliest of two requeue intervals, where zero means no requeue
+func minRequeue(a, b time.Duration) time.Duration {
+	if a == 0 {
+		return b
+	}
+	if b == 0 {
+		return a
+	}
+	return min(a, b)
+}
+
+// createRestoredClaims provisions the PVCs of the replicas that do not exist yet from the VolumeSnapshot in
+// restoreFrom of their volume claim template. The StatefulSet controller adopts the PVCs by name. PVCs are looked up
+// in the cache first, so only missing PVCs are created.
+func (r *ApplicationReconciler) createRestoredClaims(ctx context.Context, application *skiperatorv1alpha1.Application) error {
+	if !application.IsStateful() {
+		return nil
+	}
+	replicas, err := skiperatorv1alpha1.GetStaticReplicas(application.Spec.Replicas)
+	if err != nil {
+		return err
+	}
+
+	for _, template := range application.Spec.Stateful.VolumeClaimTemplates {
+		if template.RestoreFrom == "" {
+			continue
+		}
+		for ordinal := int32(0); ordinal < int32(replicas); ordinal++ {
+			pvc := restoredClaim(application, template, ordinal)
+			err := r.GetClient().Get(ctx, client.ObjectKeyFromObject(pvc), &corev1.PersistentVolumeClaim{})
+			if err == nil {
+				continue
+			}
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to get persistent volume claim %s: %w", pvc.Name, err)
+			}
+			if err := r.GetClient().Create(ctx, pvc); err != nil {
+				if errors.IsAlreadyExists(err) {
+					continue
+				}
+				return fmt.Errorf("failed to create persistent volume claim %s: %w", pvc.Name, err)
+			}
+			r.EmitNormalEvent(application, "VolumeRestored", fmt.Sprintf("Provisioned %s from volume snapshot %s", pvc.Name, template.RestoreFrom))
+		}
+	}
+	return nil
+}
+
+// restoredClaim returns the PVC the StatefulSet controller would provision from template, with the
+// VolumeSnapshot in restoreFrom as its data source
+func restoredClaim(application *skiperatorv1alpha1.Application, template skiperatorv1alpha1.VolumeClaimTemplate, ordinal int32) *corev1.PersistentVolumeClaim {
+	labels := maps.Clone(template.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	maps.Copy(labels, util.GetPodAppSelector(application.Name))
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   application.Namespace,
+			Name:        statefulset.ClaimName(template.Name, application.Name, ordinal),
+			Labels:      labels,
+			Annotations: maps.Clone(template.Annotations),
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+	pvc.Spec.DataSource = volumesnapshot.DataSource(template.RestoreFrom)
+	return pvc
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	controllercommon "github.com/kartverket/skiperator/internal/controllers/common"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/volumesnapshot"
+	"github.com/kartverket/skiperator/pkg/resourceschemas"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func backupSnapshot(name string, claimName string, created time.Time, ready bool) unstructured.Unstructured {
+	snapshot := unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumesnapshot.GroupVersionKind)
+	snapshot.SetName(name)
+	snapshot.SetCreationTimestamp(metav1.NewTime(created))
+	snapshot.SetAnnotations(map[string]string{volumesnapshot.AnnotationKeyOrdinal: "0"})
+	snapshot.Object["spec"] = map[string]any{"source": map[string]any{"persistentVolumeClaimName": claimName}}
+	snapshot.Object["status"] = map[string]any{"readyToUse": ready}
+	return snapshot
+}
+
+func snapshotNames(snapshots []*unstructured.Unstructured) []string {
+	var names []string
+	for _, snapshot := range snapshots {
+		names = append(names, snapshot.GetName())
+	}
+	return names
+}
+
+func TestBackupSchedule(t *testing.T) {
+	backup := &skiperatorv1alpha1.StatefulBackup{Schedule: "0 3 * * *"}
+	_, location, err := backupSchedule(backup)
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, location)
+
+	backup.TimeZone = new("Europe/Oslo")
+	schedule, location, err := backupSchedule(backup)
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Oslo", location.String())
+	next := schedule.Next(time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC).In(location))
+	assert.Equal(t, time.Date(2026, time.October, 20, 1, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestPlanBackups(t *testing.T) {
+	schedule, err := cron.ParseStandard("0 3 * * *")
+	require.NoError(t, err)
+	now := time.Date(2026, time.October, 19, 3, 0, 30, 0, time.UTC)
+	claim := backupClaim{name: "data-db-0", ordinal: 0, created: now.Add(-72 * time.Hour)}
+
+	// Not due since yesterday's snapshot
+	yesterday := backupSnapshot("data-db-0-yesterday", "data-db-0", now.Add(-24*time.Hour), true)
+	plan := planBackups(now.Add(-time.Minute), schedule, 2, []backupClaim{claim}, []unstructured.Unstructured{yesterday})
+	assert.Empty(t, plan.create)
+	assert.Empty(t, plan.prune)
+	assert.Equal(t, 30*time.Second, plan.requeueAfter)
+	require.Len(t, plan.status, 1)
+	assert.Equal(t, int32(0), plan.status[0].Ordinal)
+	assert.Equal(t, "data-db-0", plan.status[0].PersistentVolumeClaim)
+	assert.Equal(t, "data-db-0-yesterday", plan.status[0].VolumeSnapshot)
+	assert.True(t, plan.status[0].CreationTime.Equal(new(metav1.NewTime(now.Add(-24*time.Hour)))))
+
+	// Due, and the oldest snapshot beyond the retention is pruned
+	older := backupSnapshot("data-db-0-older", "data-db-0", now.Add(-48*time.Hour), true)
+	plan = planBackups(now, schedule, 2, []backupClaim{claim}, []unstructured.Unstructured{older, yesterday})
+	assert.Equal(t, []backupClaim{claim}, plan.create)
+	assert.Equal(t, []string{"data-db-0-older"}, snapshotNames(plan.prune))
+	assert.Equal(t, 24*time.Hour-30*time.Second, plan.requeueAfter)
+}
+
+func TestPlanBackupsKeepsLatestReadySnapshot(t *testing.T) {
+	schedule, err := cron.ParseStandard("0 * * * *")
+	require.NoError(t, err)
+	now := time.Date(2026, time.October, 19, 12, 0, 30, 0, time.UTC)
+	claim := backupClaim{name: "data-db-0", ordinal: 0, created: now.Add(-72 * time.Hour)}
+
+	ready := backupSnapshot("data-db-0-ready", "data-db-0", now.Add(-3*time.Hour), true)
+	failed := backupSnapshot("data-db-0-failed", "data-db-0", now.Add(-2*time.Hour), false)
+	taking := backupSnapshot("data-db-0-taking", "data-db-0", now.Add(-10*time.Second), false)
+
+	plan := planBackups(now, schedule, 1, []backupClaim{claim}, []unstructured.Unstructured{ready, failed, taking})
+	assert.Empty(t, plan.create)
+	assert.Equal(t, []string{"data-db-0-failed"}, snapshotNames(plan.prune))
+	assert.Equal(t, backupPendingRequeue, plan.requeueAfter)
+	require.Len(t, plan.status, 1)
+	assert.Equal(t, "data-db-0-ready", plan.status[0].VolumeSnapshot)
+}
+
+func TestPlanBackupsFirstSnapshotAfterClaimIsCreated(t *testing.T) {
+	schedule, err := cron.ParseStandard("*/5 * * * *")
+	require.NoError(t, err)
+	now := time.Date(2026, time.October, 19, 12, 3, 0, 0, time.UTC)
+
+	plan := planBackups(now, schedule, 7, []backupClaim{{name: "data-db-1", ordinal: 1, created: now.Add(-time.Minute)}}, nil)
+	assert.Empty(t, plan.create)
+	assert.Empty(t, plan.status)
+	assert.Equal(t, 2*time.Minute, plan.requeueAfter)
+
+	plan = planBackups(now, schedule, 7, []backupClaim{{name: "data-db-1", ordinal: 1, created: now.Add(-10 * time.Minute)}}, nil)
+	assert.Len(t, plan.create, 1)
+}
+
+func TestRestoredClaim(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "ns"}}
+	template := skiperatorv1alpha1.VolumeClaimTemplate{
+		Name:        "data",
+		Labels:      map[string]string{"tier": "storage"},
+		RestoreFrom: "data-db-0-20261019030000",
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources:   corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+		},
+	}
+
+	pvc := restoredClaim(application, template, 2)
+	assert.Equal(t, "data-db-2", pvc.Name)
+	assert.Equal(t, map[string]string{"tier": "storage", "app": "db"}, pvc.Labels)
+	assert.Equal(t, "VolumeSnapshot", pvc.Spec.DataSource.Kind)
+	assert.Equal(t, "snapshot.storage.k8s.io", *pvc.Spec.DataSource.APIGroup)
+	assert.Equal(t, "data-db-0-20261019030000", pvc.Spec.DataSource.Name)
+	assert.Nil(t, template.Spec.DataSource)
+}
+
+func TestCreateRestoredClaims(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	application := statefulApplication("1Gi")
+	application.Spec.Replicas = &apiextensionsv1.JSON{Raw: []byte("2")}
+	application.Spec.Stateful.VolumeClaimTemplates[0].RestoreFrom = "data-db-0-20261019030000"
+	existing := volumeClaim("data-db-0", "1Gi", "1Gi", "standard")
+
+	var created []string
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			created = append(created, obj.GetName())
+			return c.Create(ctx, obj, opts...)
+		},
+	}).Build()
+	r := &ApplicationReconciler{ReconcilerBase: controllercommon.NewReconcilerBase(c, nil, scheme, nil, record.NewFakeRecorder(10))}
+
+	// Only the missing PVC is created
+	require.NoError(t, r.createRestoredClaims(context.Background(), application))
+	assert.Equal(t, []string{"data-db-1"}, created)
+	pvc := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "data-db-1"}, pvc))
+	assert.Equal(t, "data-db-0-20261019030000", pvc.Spec.DataSource.Name)
+
+	require.NoError(t, r.createRestoredClaims(context.Background(), application))
+	assert.Equal(t, []string{"data-db-1"}, created)
+}
+
+func TestValidateApplicationStatefulFieldsBackupSchedule(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{Spec: skiperatorv1alpha1.ApplicationSpec{
+		Stateful: &skiperatorv1alpha1.StatefulSpec{
+			Enabled:              true,
+			VolumeClaimTemplates: []skiperatorv1alpha1.VolumeClaimTemplate{{Name: "data", MountPath: "/data"}},
+			Backup:               &skiperatorv1alpha1.StatefulBackup{Schedule: "0 3 * * *", VolumeSnapshotClassName: "csi"},
+		},
+	}}
+	assert.NoError(t, validateApplicationStatefulFields(application))
+
+	application.Spec.Stateful.Backup.TimeZone = new("Mars/Olympus_Mons")
+	assert.Error(t, validateApplicationStatefulFields(application))
+
+	application.Spec.Stateful.Backup.TimeZone = nil
+	application.Spec.Stateful.Backup.Schedule = "0 3 * *"
+	assert.Error(t, validateApplicationStatefulFields(application))
+}
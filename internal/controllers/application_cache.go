@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// applicationWorkloadSelector matches the pods and ReplicaSets of Application workloads, which get the default
+// Application labels from their pod template
+var applicationWorkloadSelector = labels.SelectorFromSet(labels.Set{"skiperator.kartverket.no/controller": "application"})
+
+// CacheByObject restricts the Pod and ReplicaSet informers started by the rollout status, init container and
+// auto rollback lists to Application workloads, so the manager does not cache every pod in the cluster
+func CacheByObject() map[client.Object]cache.ByObject {
+	return map[client.Object]cache.ByObject{
+		&corev1.Pod{}:        {Label: applicationWorkloadSelector},
+		&appsv1.ReplicaSet{}: {Label: applicationWorkloadSelector},
+	}
+}
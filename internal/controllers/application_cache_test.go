@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"testing"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/resourceutils"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestApplicationWorkloadSelector(t *testing.T) {
+	app := &skiperatorv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"}}
+	app.Spec.Labels = map[string]string{"skiperator.kartverket.no/controller": "other"}
+	podTemplate := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": app.Name}}}
+	resourceutils.SetApplicationLabels(&podTemplate, app)
+
+	assert.True(t, applicationWorkloadSelector.Matches(labels.Set(podTemplate.Labels)))
+	assert.False(t, applicationWorkloadSelector.Matches(labels.Set{"app": app.Name}))
+}
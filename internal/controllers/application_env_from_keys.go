@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Field indexes of the Secrets and ConfigMaps that Applications project keys from with envFromKeys
+const (
+	envFromKeysSecretIndex    = "spec.envFromKeys.secret"
+	envFromKeysConfigMapIndex = "spec.envFromKeys.configMap"
+)
+
+// indexEnvFromKeysSources indexes Applications on the Secrets and ConfigMaps their envFromKeys refer to,
+// so a change to one of them only looks up the Applications that use it.
+func indexEnvFromKeysSources(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx, &skiperatorv1alpha1.Application{}, envFromKeysSecretIndex, envFromKeysSecrets); err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &skiperatorv1alpha1.Application{}, envFromKeysConfigMapIndex, envFromKeysConfigMaps)
+}
+
+func envFromKeysSecrets(obj client.Object) []string {
+	return envFromKeysSourceNames(obj, func(envFromKey skiperatorv1alpha1.EnvFromKey) string { return envFromKey.Secret })
+}
+
+func envFromKeysConfigMaps(obj client.Object) []string {
+	return envFromKeysSourceNames(obj, func(envFromKey skiperatorv1alpha1.EnvFromKey) string { return envFromKey.ConfigMap })
+}
+
+func envFromKeysSourceNames(obj client.Object, sourceName func(skiperatorv1alpha1.EnvFromKey) string) []string {
+	application, ok := obj.(*skiperatorv1alpha1.Application)
+	if !ok {
+		return nil
+	}
+	var names []string
+	seen := map[string]struct{}{}
+	for _, envFromKey := range application.Spec.EnvFromKeys {
+		name := sourceName(envFromKey)
+		if _, ok := seen[name]; ok || name == "" {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names
+}
+
+// handleEnvFromKeysSource enqueues the Applications in the namespace that project keys from the
+// changed ConfigMap or Secret, so the env-from-keys hash is updated and the Pods are restarted.
+func (r *ApplicationReconciler) handleEnvFromKeysSource(ctx context.Context, obj client.Object) []reconcile.Request {
+	index := envFromKeysConfigMapIndex
+	if _, isSecret := obj.(*corev1.Secret); isSecret {
+		index = envFromKeysSecretIndex
+	}
+
+	applications := skiperatorv1alpha1.ApplicationList{}
+	if err := r.GetClient().List(ctx, &applications, client.InNamespace(obj.GetNamespace()), client.MatchingFields{index: obj.GetName()}); err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(applications.Items))
+	for _, application := range applications.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: application.Namespace, Name: application.Name},
+		})
+	}
+	return requests
+}
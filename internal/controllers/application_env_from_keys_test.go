@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	controllercommon "github.com/kartverket/skiperator/internal/controllers/common"
+	"github.com/kartverket/skiperator/pkg/resourceschemas"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandleEnvFromKeysSource(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	usesSecret := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "uses-secret", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			EnvFromKeys: []skiperatorv1alpha1.EnvFromKey{{Secret: "db", Key: "password", As: "DB_PASSWORD"}},
+		},
+	}
+	usesConfigMap := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "uses-config-map", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			EnvFromKeys: []skiperatorv1alpha1.EnvFromKey{{ConfigMap: "db", Key: "host", As: "DB_HOST"}},
+		},
+	}
+	otherNamespace := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-namespace", Namespace: "team-b"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			EnvFromKeys: []skiperatorv1alpha1.EnvFromKey{{Secret: "db", Key: "password", As: "DB_PASSWORD"}},
+		},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(usesSecret, usesConfigMap, otherNamespace).
+		WithIndex(&skiperatorv1alpha1.Application{}, envFromKeysSecretIndex, envFromKeysSecrets).
+		WithIndex(&skiperatorv1alpha1.Application{}, envFromKeysConfigMapIndex, envFromKeysConfigMaps).
+		Build()
+	reconciler := &ApplicationReconciler{ReconcilerBase: controllercommon.NewReconcilerBase(client, nil, scheme, nil, nil)}
+
+	requests := reconciler.handleEnvFromKeysSource(context.Background(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"}})
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "uses-secret", requests[0].Name)
+
+	requests = reconciler.handleEnvFromKeysSource(context.Background(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"}})
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "uses-config-map", requests[0].Name)
+
+	requests = reconciler.handleEnvFromKeysSource(context.Background(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unused", Namespace: "team-a"}})
+	assert.Empty(t, requests)
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	commontypes "github.com/kartverket/skiperator/api/common"
+	"github.com/kartverket/skiperator/api/common/podtypes"
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// updateInitContainersCondition sets the InitContainersSucceeded condition from the one-shot init containers of the
+// Application's pods. It returns true while they have not succeeded, as Pod changes do not trigger reconciles.
+func (r *ApplicationReconciler) updateInitContainersCondition(ctx context.Context, application *skiperatorv1alpha1.Application) (bool, error) {
+	var oneshotContainers []string
+	for _, c := range application.Spec.ExtraContainers {
+		if c.Type == podtypes.ContainerTypeOneshot {
+			oneshotContainers = append(oneshotContainers, c.Name)
+		}
+	}
+	if len(oneshotContainers) == 0 {
+		meta.RemoveStatusCondition(&application.Status.Conditions, commontypes.InitContainersSucceededType)
+		return false, nil
+	}
+
+	pods := corev1.PodList{}
+	if err := r.GetClient().List(ctx, &pods, client.InNamespace(application.Namespace), client.MatchingLabels(util.GetPodAppSelector(application.Name))); err != nil {
+		return false, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	status, reason, message := initContainersState(pods.Items, oneshotContainers)
+	application.GetStatus().SetInitContainersSucceededCondition(status, application.GetGeneration(), reason, message)
+	return status != metav1.ConditionTrue && len(pods.Items) > 0, nil
+}
+
+// initContainersState summarises the one-shot init containers of the pods. They have failed if one of them has
+// exited with an error, including one that is being restarted, and are running if one of them has not completed.
+func initContainersState(pods []corev1.Pod, oneshotContainers []string) (metav1.ConditionStatus, string, string) {
+	running := len(pods) == 0
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		seen := 0
+		for _, containerStatus := range pod.Status.InitContainerStatuses {
+			if !slices.Contains(oneshotContainers, containerStatus.Name) {
+				continue
+			}
+			seen++
+
+			terminated := containerStatus.State.Terminated
+			if terminated == nil {
+				// A container that has failed and is waiting to be restarted, or is retrying
+				terminated = containerStatus.LastTerminationState.Terminated
+			}
+			if terminated != nil && terminated.ExitCode != 0 {
+				detail := terminated.Message
+				if detail == "" {
+					detail = terminated.Reason
+				}
+				return metav1.ConditionFalse, "InitContainerFailed",
+					fmt.Sprintf("Init container %s in pod %s failed with exit code %d: %s", containerStatus.Name, pod.Name, terminated.ExitCode, detail)
+			}
+			if containerStatus.State.Terminated == nil {
+				running = true
+			}
+		}
+		// Statuses are missing until the pod has been scheduled
+		if seen < len(oneshotContainers) {
+			running = true
+		}
+	}
+
+	if running {
+		return metav1.ConditionUnknown, "InitContainersRunning", "Waiting for init containers to complete"
+	}
+	return metav1.ConditionTrue, "InitContainersSucceeded", "Init containers have completed"
+}
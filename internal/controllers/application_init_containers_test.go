@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func initContainerPod(name string, statuses ...corev1.ContainerStatus) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.PodStatus{InitContainerStatuses: statuses},
+	}
+}
+
+func TestInitContainersState(t *testing.T) {
+	completed := corev1.ContainerStatus{Name: "migrate", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}}
+	running := corev1.ContainerStatus{Name: "migrate", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+	crashLooping := corev1.ContainerStatus{
+		Name:                 "migrate",
+		State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 3, Message: "relation already exists"}},
+	}
+	sidecar := corev1.ContainerStatus{Name: "config-loader", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+	oneshot := []string{"migrate"}
+
+	status, reason, _ := initContainersState([]corev1.Pod{initContainerPod("a", completed, sidecar), initContainerPod("b", completed)}, oneshot)
+	assert.Equal(t, metav1.ConditionTrue, status)
+	assert.Equal(t, "InitContainersSucceeded", reason)
+
+	status, reason, _ = initContainersState([]corev1.Pod{initContainerPod("a", completed), initContainerPod("b", running)}, oneshot)
+	assert.Equal(t, metav1.ConditionUnknown, status)
+	assert.Equal(t, "InitContainersRunning", reason)
+
+	status, reason, _ = initContainersState([]corev1.Pod{initContainerPod("a", completed), initContainerPod("b")}, oneshot)
+	assert.Equal(t, metav1.ConditionUnknown, status)
+	assert.Equal(t, "InitContainersRunning", reason)
+
+	status, reason, message := initContainersState([]corev1.Pod{initContainerPod("a", completed), initContainerPod("b", crashLooping)}, oneshot)
+	assert.Equal(t, metav1.ConditionFalse, status)
+	assert.Equal(t, "InitContainerFailed", reason)
+	assert.Equal(t, "Init container migrate in pod b failed with exit code 3: relation already exists", message)
+}
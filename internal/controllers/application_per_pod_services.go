@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/statefulset"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// perPodServiceConflicts reports the per-pod Services of a stateful Application whose names are already taken. The
+// Services are named after the pods, so they must not replace a Service that belongs to something else, such as
+// the Service of an Application named after one of the pods.
+func (r *ApplicationReconciler) perPodServiceConflicts(ctx context.Context, application *skiperatorv1alpha1.Application) (field.ErrorList, error) {
+	if !application.IsStateful() || application.Spec.Stateful.Network == nil || !application.Spec.Stateful.Network.PerPodServices {
+		return nil, nil
+	}
+	replicas, err := skiperatorv1alpha1.GetStaticReplicas(application.Spec.Replicas)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs field.ErrorList
+	path := field.NewPath("spec", "stateful", "network", "perPodServices")
+	for ordinal := int32(0); ordinal < int32(replicas); ordinal++ {
+		key := client.ObjectKey{Namespace: application.Namespace, Name: statefulset.PodName(application.Name, ordinal)}
+
+		service := &corev1.Service{}
+		if err := r.GetClient().Get(ctx, key, service); err == nil {
+			if !metav1.IsControlledBy(service, application) {
+				errs = append(errs, field.Forbidden(path, fmt.Sprintf("service %s already exists and is not owned by this application", key.Name)))
+				continue
+			}
+		} else if !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get service %s: %w", key.Name, err)
+		}
+
+		if err := r.GetClient().Get(ctx, key, &skiperatorv1alpha1.Application{}); err == nil {
+			errs = append(errs, field.Forbidden(path, fmt.Sprintf("service %s is the service of application %s", key.Name, key.Name)))
+		} else if !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get application %s: %w", key.Name, err)
+		}
+	}
+	return errs, nil
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	controllercommon "github.com/kartverket/skiperator/internal/controllers/common"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/resourceutils"
+	"github.com/kartverket/skiperator/pkg/resourceschemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPerPodServiceConflicts(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	application := statefulApplication("1Gi")
+	application.UID = "db-uid"
+	application.Spec.Replicas = &apiextensionsv1.JSON{Raw: []byte("3")}
+	application.Spec.Stateful.Network = &skiperatorv1alpha1.StatefulNetwork{PerPodServices: true}
+
+	owned := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "team-a"}}
+	require.NoError(t, resourceutils.SetOwnerReference(application, owned, scheme))
+	foreign := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "db-1", Namespace: "team-a"}}
+	other := &skiperatorv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: "db-2", Namespace: "team-a"}}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(owned, foreign, other).Build()
+	r := &ApplicationReconciler{ReconcilerBase: controllercommon.NewReconcilerBase(c, nil, scheme, nil, nil)}
+
+	errs, err := r.perPodServiceConflicts(context.Background(), application)
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Detail, "service db-1 already exists")
+	assert.Contains(t, errs[1].Detail, "application db-2")
+
+	application.Spec.Replicas = &apiextensionsv1.JSON{Raw: []byte("1")}
+	errs, err = r.perPodServiceConflicts(context.Background(), application)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	application.Spec.Stateful.Network = nil
+	application.Spec.Replicas = &apiextensionsv1.JSON{Raw: []byte("3")}
+	errs, err = r.perPodServiceConflicts(context.Background(), application)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
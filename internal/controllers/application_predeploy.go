@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	commontypes "github.com/kartverket/skiperator/api/common"
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/predeploy"
+	"github.com/kartverket/skiperator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// resolvePreDeploy sets the PreDeploySucceeded condition from the pre-deploy Job for the current image of the
+// Application. The images are pinned to the digests they resolve to, so the Job and the workload run the same image
+// and a tag moved to a new image runs a new Job. While the Job has not succeeded, the rollout of the workload is
+// held at its current pod template. It returns the condition, or nil if the Application has no pre-deploy Job.
+func (r *ApplicationReconciler) resolvePreDeploy(ctx context.Context, application *skiperatorv1alpha1.Application, reconciliationApp reconciliation.Reconciliation) (*metav1.Condition, error) {
+	if application.Spec.PreDeploy == nil {
+		meta.RemoveStatusCondition(&application.Status.Conditions, commontypes.PreDeploySucceededType)
+		return nil, nil
+	}
+
+	if !r.SkiperatorConfig.EnableLocallyBuiltImages {
+		for _, image := range []string{application.Spec.Image, application.PreDeployImage()} {
+			pinned, err := util.ResolveImageDigest(ctx, reconciliationApp.GetLogger().GetLogger(), r.GetRestConfig(), application, image)
+			if err != nil {
+				// Exclude dummy image used in tests, like the workload generators do
+				if strings.Contains(err.Error(), "https://index.docker.io/v2/library/image/manifests/latest") {
+					continue
+				}
+				return nil, fmt.Errorf("failed to resolve digest of image %s: %w", image, err)
+			}
+			reconciliationApp.PinImage(image, pinned)
+		}
+	}
+
+	jobName, err := predeploy.JobName(application, reconciliationApp.PinnedImages())
+	if err != nil {
+		return nil, err
+	}
+	job := &batchv1.Job{}
+	if err := r.GetClient().Get(ctx, client.ObjectKey{Namespace: application.Namespace, Name: jobName}, job); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get pre-deploy job: %w", err)
+		}
+		// Created in this reconcile
+		job = nil
+	}
+
+	status, reason, message := preDeployJobState(jobName, job)
+	application.GetStatus().SetPreDeploySucceededCondition(status, application.GetGeneration(), reason, message)
+
+	if status != metav1.ConditionTrue {
+		heldPodTemplate, err := r.getWorkloadPodTemplate(ctx, application)
+		if err != nil {
+			return nil, err
+		}
+		reconciliationApp.HoldRollout(heldPodTemplate)
+	}
+
+	return meta.FindStatusCondition(application.Status.Conditions, commontypes.PreDeploySucceededType), nil
+}
+
+// preDeployJobState summarises the pre-deploy Job, which is nil if it does not exist yet
+func preDeployJobState(jobName string, job *batchv1.Job) (metav1.ConditionStatus, string, string) {
+	if job != nil {
+		if failed, reason, message := isFailedJob(job); failed {
+			return metav1.ConditionFalse, "PreDeployFailed", fmt.Sprintf("Pre-deploy job %s failed: %s: %s", jobName, reason, message)
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Type == batchv1.JobComplete && condition.Status == corev1.ConditionTrue {
+				return metav1.ConditionTrue, "PreDeploySucceeded", fmt.Sprintf("Pre-deploy job %s has succeeded", jobName)
+			}
+		}
+	}
+	return metav1.ConditionUnknown, "PreDeployRunning", fmt.Sprintf("Waiting for pre-deploy job %s to succeed before rolling out", jobName)
+}
+
+// getWorkloadPodTemplate returns the pod template of the current Deployment or StatefulSet of the Application,
+// or nil if it has not been created yet
+func (r *ApplicationReconciler) getWorkloadPodTemplate(ctx context.Context, application *skiperatorv1alpha1.Application) (*corev1.PodTemplateSpec, error) {
+	key := client.ObjectKeyFromObject(application)
+	if application.IsStateful() {
+		sts := &appsv1.StatefulSet{}
+		if err := r.GetClient().Get(ctx, key, sts); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to get statefulset: %w", err)
+		}
+		return &sts.Spec.Template, nil
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := r.GetClient().Get(ctx, key, deployment); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get deployment: %w", err)
+	}
+	return &deployment.Spec.Template, nil
+}
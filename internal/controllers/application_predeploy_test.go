@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	stdlog "log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/internal/config"
+	controllercommon "github.com/kartverket/skiperator/internal/controllers/common"
+	"github.com/kartverket/skiperator/pkg/log"
+	"github.com/kartverket/skiperator/pkg/mesh"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/predeploy"
+	"github.com/kartverket/skiperator/pkg/resourceschemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPreDeployJobState(t *testing.T) {
+	status, reason, _ := preDeployJobState("orders-predeploy-abc", nil)
+	assert.Equal(t, metav1.ConditionUnknown, status)
+	assert.Equal(t, "PreDeployRunning", reason)
+
+	status, reason, _ = preDeployJobState("orders-predeploy-abc", &batchv1.Job{})
+	assert.Equal(t, metav1.ConditionUnknown, status)
+	assert.Equal(t, "PreDeployRunning", reason)
+
+	complete := &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}}}
+	status, reason, _ = preDeployJobState("orders-predeploy-abc", complete)
+	assert.Equal(t, metav1.ConditionTrue, status)
+	assert.Equal(t, "PreDeploySucceeded", reason)
+
+	failed := &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+		{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"},
+	}}}
+	status, reason, message := preDeployJobState("orders-predeploy-abc", failed)
+	assert.Equal(t, metav1.ConditionFalse, status)
+	assert.Equal(t, "PreDeployFailed", reason)
+	assert.Equal(t, "Pre-deploy job orders-predeploy-abc failed: BackoffLimitExceeded: Job has reached the specified backoff limit", message)
+}
+
+// pushPreDeployImage pushes a random image to the tag and returns the image pinned to its digest
+func pushPreDeployImage(t *testing.T, tag string) string {
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(tag)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	return tag + "@" + digest.String()
+}
+
+func TestResolvePreDeploy(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(stdlog.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+	image := strings.TrimPrefix(server.URL, "http://") + "/kartverket/orders:1.1"
+	pinned := pushPreDeployImage(t, image)
+
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	application := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Image:     image,
+			PreDeploy: &skiperatorv1alpha1.PreDeploySpec{Command: []string{"./migrate"}},
+		},
+	}
+	jobName, err := predeploy.JobName(application, map[string]string{image: pinned})
+	require.NoError(t, err)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "orders", Image: "ghcr.io/kartverket/orders:1.0"}},
+		}}},
+	}
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "team-a"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment, job).Build()
+	reconciler := &ApplicationReconciler{ReconcilerBase: controllercommon.NewReconcilerBase(c, nil, scheme, nil, nil)}
+
+	// The Job is running, so the Deployment keeps the previous image
+	r := reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+	condition, err := reconciler.resolvePreDeploy(context.Background(), application, r)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+	assert.True(t, r.RolloutHeld())
+	assert.Equal(t, "ghcr.io/kartverket/orders:1.0", r.HeldPodTemplate().Spec.Containers[0].Image)
+	assert.Equal(t, pinned, r.PinnedImages()[image])
+
+	// The Job has succeeded, so the new image is rolled out
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	require.NoError(t, c.Status().Update(context.Background(), job))
+	r = reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+	condition, err = reconciler.resolvePreDeploy(context.Background(), application, r)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.False(t, r.RolloutHeld())
+
+	// The tag is moved to a new image, so a new Job must succeed before it is rolled out
+	moved := pushPreDeployImage(t, image)
+	r = reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+	condition, err = reconciler.resolvePreDeploy(context.Background(), application, r)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+	assert.True(t, r.RolloutHeld())
+	assert.Equal(t, moved, r.PinnedImages()[image])
+
+	// Without preDeploy the condition is removed
+	application.Spec.PreDeploy = nil
+	condition, err = reconciler.resolvePreDeploy(context.Background(), application, r)
+	require.NoError(t, err)
+	assert.Nil(t, condition)
+	assert.Empty(t, application.Status.Conditions)
+}
@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	commontypes "github.com/kartverket/skiperator/api/common"
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The Deployment controller annotates a Deployment and its ReplicaSets with the revision of the pod template
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// workloadCondition is a condition of the Application derived from its Deployment or StatefulSet
+type workloadCondition struct {
+	status  metav1.ConditionStatus
+	reason  string
+	message string
+}
+
+// rolloutState is the rollout of the current pod template of an Application
+type rolloutState struct {
+	replicas          int32
+	readyReplicas     int32
+	updatedReplicas   int32
+	availableReplicas int32
+	available         workloadCondition
+	progressing       workloadCondition
+	failed            workloadCondition
+}
+
+// updateRolloutStatus sets the Available, Progressing and RolloutFailed conditions and the replica counts of
+// the Application from its Deployment or StatefulSet and the pods of the current pod template. It returns true
+// while the rollout is progressing, as changes to pods do not trigger reconciles.
+func (r *ApplicationReconciler) updateRolloutStatus(ctx context.Context, application *skiperatorv1alpha1.Application) (bool, error) {
+	pods := corev1.PodList{}
+	if err := r.GetClient().List(ctx, &pods, client.InNamespace(application.Namespace), client.MatchingLabels(util.GetPodAppSelector(application.Name))); err != nil {
+		return false, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var state rolloutState
+	key := client.ObjectKeyFromObject(application)
+	if application.IsStateful() {
+		sts := &appsv1.StatefulSet{}
+		if err := r.GetClient().Get(ctx, key, sts); err != nil {
+			if !errors.IsNotFound(err) {
+				return false, fmt.Errorf("failed to get statefulset: %w", err)
+			}
+			sts = nil
+		}
+		state = statefulSetRolloutState(time.Now(), application.Name, sts, pods.Items)
+	} else {
+		deployment := &appsv1.Deployment{}
+		if err := r.GetClient().Get(ctx, key, deployment); err != nil {
+			if !errors.IsNotFound(err) {
+				return false, fmt.Errorf("failed to get deployment: %w", err)
+			}
+			deployment = nil
+		}
+		var podTemplateHash string
+		if deployment != nil {
+			newReplicaSet, err := r.getNewReplicaSet(ctx, deployment)
+			if err != nil {
+				return false, err
+			}
+			if newReplicaSet != nil {
+				podTemplateHash = newReplicaSet.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+			}
+		}
+		state = deploymentRolloutState(deployment, podTemplateHash, pods.Items)
+	}
+
+	wasFailed := meta.IsStatusConditionTrue(application.Status.Conditions, commontypes.RolloutFailedConditionType)
+	status := application.GetStatus()
+	generation := application.GetGeneration()
+	status.SetAvailableCondition(state.available.status, generation, state.available.reason, state.available.message)
+	status.SetProgressingCondition(state.progressing.status, generation, state.progressing.reason, state.progressing.message)
+	status.SetRolloutFailedCondition(state.failed.status, generation, state.failed.reason, state.failed.message)
+	application.Status.Replicas = state.replicas
+	application.Status.ReadyReplicas = state.readyReplicas
+	application.Status.UpdatedReplicas = state.updatedReplicas
+	application.Status.AvailableReplicas = state.availableReplicas
+	if state.failed.status == metav1.ConditionTrue && !wasFailed {
+		r.EmitWarningEvent(application, "RolloutFailed", state.failed.message)
+	}
+
+	return state.progressing.status != metav1.ConditionFalse, nil
+}
+
+// getNewReplicaSet returns the ReplicaSet of the current pod template of the Deployment, or nil if the
+// Deployment controller has not created it yet
+func (r *ApplicationReconciler) getNewReplicaSet(ctx context.Context, deployment *appsv1.Deployment) (*appsv1.ReplicaSet, error) {
+	replicaSets := appsv1.ReplicaSetList{}
+	if err := r.GetClient().List(ctx, &replicaSets, client.InNamespace(deployment.Namespace), client.MatchingLabels(util.GetPodAppSelector(deployment.Name))); err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	revision := deployment.Annotations[deploymentRevisionAnnotation]
+	for i := range replicaSets.Items {
+		replicaSet := &replicaSets.Items[i]
+		if metav1.IsControlledBy(replicaSet, deployment) && revision != "" && replicaSet.Annotations[deploymentRevisionAnnotation] == revision {
+			return replicaSet, nil
+		}
+	}
+	return nil, nil
+}
+
+// deploymentRolloutState derives the rollout from the Deployment, which is nil if it has not been created
+// yet, and the pods of its new ReplicaSet with the given pod-template-hash. The rollout has failed when the
+// Deployment has exceeded its progress deadline, or a container of a new pod cannot start.
+func deploymentRolloutState(deployment *appsv1.Deployment, podTemplateHash string, pods []corev1.Pod) rolloutState {
+	if deployment == nil {
+		return pendingRolloutState("Deployment")
+	}
+	state := rolloutState{
+		replicas:          deployment.Status.Replicas,
+		readyReplicas:     deployment.Status.ReadyReplicas,
+		updatedReplicas:   deployment.Status.UpdatedReplicas,
+		availableReplicas: deployment.Status.AvailableReplicas,
+		available:         workloadCondition{metav1.ConditionUnknown, "Pending", "Waiting for the Deployment controller"},
+		failed:            workloadCondition{metav1.ConditionFalse, "NoFailure", "No failed pods in the rollout"},
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable {
+			state.available = workloadCondition{metav1.ConditionStatus(condition.Status), condition.Reason, condition.Message}
+		}
+	}
+
+	var newPods []corev1.Pod
+	for _, pod := range pods {
+		if podTemplateHash != "" && pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey] == podTemplateHash {
+			newPods = append(newPods, pod)
+		}
+	}
+	if failure, failed := newPodsFailure(time.Time{}, newPods, 0); failed {
+		state.failed = failure
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	switch {
+	case deployment.Status.ObservedGeneration < deployment.Generation:
+		state.progressing = workloadCondition{metav1.ConditionTrue, "RollingOut", "Waiting for the Deployment controller to observe the pod template"}
+	case deploymentProgressDeadlineExceeded(deployment):
+		message := fmt.Sprintf("Deployment has exceeded its progress deadline of %ds", deploymentProgressDeadline(deployment))
+		state.progressing = workloadCondition{metav1.ConditionFalse, "ProgressDeadlineExceeded", message}
+		state.failed = workloadCondition{metav1.ConditionTrue, "ProgressDeadlineExceeded", message}
+	case state.updatedReplicas >= desired && state.replicas == state.updatedReplicas && state.availableReplicas == state.updatedReplicas:
+		state.progressing = workloadCondition{metav1.ConditionFalse, "RolloutComplete", fmt.Sprintf("All %d pods have been updated and are available", state.updatedReplicas)}
+	default:
+		state.progressing = workloadCondition{metav1.ConditionTrue, "RollingOut",
+			fmt.Sprintf("%d of %d pods updated, %d available", state.updatedReplicas, desired, state.availableReplicas)}
+	}
+	return state
+}
+
+// deploymentProgressDeadlineExceeded reports whether the Deployment controller has given up on the rollout
+func deploymentProgressDeadlineExceeded(deployment *appsv1.Deployment) bool {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing {
+			return condition.Status == corev1.ConditionFalse && condition.Reason == "ProgressDeadlineExceeded"
+		}
+	}
+	return false
+}
+
+func deploymentProgressDeadline(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.ProgressDeadlineSeconds == nil {
+		return 600
+	}
+	return *deployment.Spec.ProgressDeadlineSeconds
+}
+
+// statefulSetRolloutState derives the rollout from the StatefulSet of the Application, which is nil if it
+// has not been created yet, and its pods. StatefulSets have no progress deadline, so the rollout has also
+// failed when a pod of the update revision has not become ready within stagedRolloutReadyTimeout.
+func statefulSetRolloutState(now time.Time, applicationName string, sts *appsv1.StatefulSet, pods []corev1.Pod) rolloutState {
+	if sts == nil {
+		return pendingRolloutState("StatefulSet")
+	}
+	state := rolloutState{
+		replicas:          sts.Status.Replicas,
+		readyReplicas:     sts.Status.ReadyReplicas,
+		updatedReplicas:   sts.Status.UpdatedReplicas,
+		availableReplicas: sts.Status.AvailableReplicas,
+		failed:            workloadCondition{metav1.ConditionFalse, "NoFailure", "No failed pods in the rollout"},
+	}
+
+	desired := int32(1)
+	if sts.Spec.Replicas != nil {
+		desired = *sts.Spec.Replicas
+	}
+	if state.availableReplicas >= desired {
+		state.available = workloadCondition{metav1.ConditionTrue, "MinimumReplicasAvailable", fmt.Sprintf("StatefulSet has %d available pods", state.availableReplicas)}
+	} else {
+		state.available = workloadCondition{metav1.ConditionFalse, "MinimumReplicasUnavailable",
+			fmt.Sprintf("%d of %d pods are available", state.availableReplicas, desired)}
+	}
+
+	var newPods []corev1.Pod
+	for _, pod := range pods {
+		if _, ok := podOrdinal(applicationName, pod.Name); ok && pod.Labels[appsv1.ControllerRevisionHashLabelKey] == sts.Status.UpdateRevision {
+			newPods = append(newPods, pod)
+		}
+	}
+	if failure, failed := newPodsFailure(now, newPods, stagedRolloutReadyTimeout); failed {
+		state.failed = failure
+	}
+
+	// Pods below the partition are not updated
+	toUpdate := desired
+	if strategy := sts.Spec.UpdateStrategy.RollingUpdate; strategy != nil && strategy.Partition != nil {
+		toUpdate = max(desired-*strategy.Partition, 0)
+	}
+	switch {
+	case sts.Status.ObservedGeneration < sts.Generation:
+		state.progressing = workloadCondition{metav1.ConditionTrue, "RollingOut", "Waiting for the StatefulSet controller to observe the pod template"}
+	case sts.Status.CurrentRevision == sts.Status.UpdateRevision && state.readyReplicas >= desired:
+		state.progressing = workloadCondition{metav1.ConditionFalse, "RolloutComplete", fmt.Sprintf("All %d pods have been updated and are ready", desired)}
+	case sts.Status.CurrentRevision != sts.Status.UpdateRevision && toUpdate < desired && state.updatedReplicas >= toUpdate && state.readyReplicas >= desired:
+		state.progressing = workloadCondition{metav1.ConditionFalse, "PartitionRolledOut",
+			fmt.Sprintf("%d of %d pods have been updated, the rest are below the partition", state.updatedReplicas, desired)}
+	default:
+		state.progressing = workloadCondition{metav1.ConditionTrue, "RollingOut",
+			fmt.Sprintf("%d of %d pods updated, %d ready", state.updatedReplicas, desired, state.readyReplicas)}
+	}
+	return state
+}
+
+// newPodsFailure reports the first pod of the current pod template with a container that cannot start, or
+// that has not become ready within readyTimeout when it is not zero
+func newPodsFailure(now time.Time, pods []corev1.Pod, readyTimeout time.Duration) (workloadCondition, bool) {
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if _, ready := podReadySince(pod); ready {
+			continue
+		}
+		if name, reason, failed := failedContainer(pod); failed {
+			return workloadCondition{metav1.ConditionTrue, reason, fmt.Sprintf("Container %s of pod %s is in %s", name, pod.Name, reason)}, true
+		}
+		if readyTimeout > 0 && !pod.CreationTimestamp.IsZero() && now.Sub(pod.CreationTimestamp.Time) > readyTimeout {
+			return workloadCondition{metav1.ConditionTrue, "ProgressDeadlineExceeded", fmt.Sprintf("Pod %s has not become ready in %s", pod.Name, readyTimeout)}, true
+		}
+	}
+	return workloadCondition{}, false
+}
+
+// pendingRolloutState is the rollout of a workload that has not been created yet
+func pendingRolloutState(kind string) rolloutState {
+	return rolloutState{
+		available:   workloadCondition{metav1.ConditionUnknown, "Pending", fmt.Sprintf("Waiting for the %s to be created", kind)},
+		progressing: workloadCondition{metav1.ConditionTrue, "RollingOut", fmt.Sprintf("Waiting for the %s to be created", kind)},
+		failed:      workloadCondition{metav1.ConditionFalse, "NoFailure", "No failed pods in the rollout"},
+	}
+}
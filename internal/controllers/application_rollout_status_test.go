@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	commontypes "github.com/kartverket/skiperator/api/common"
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	controllercommon "github.com/kartverket/skiperator/internal/controllers/common"
+	"github.com/kartverket/skiperator/pkg/resourceschemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func rolloutDeployment(updated int32, available int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a", Generation: 2, UID: "web-uid",
+			Annotations: map[string]string{deploymentRevisionAnnotation: "2"}},
+		Spec: appsv1.DeploymentSpec{Replicas: new(int32(2))},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           max(updated, 2),
+			UpdatedReplicas:    updated,
+			ReadyReplicas:      available,
+			AvailableReplicas:  available,
+			Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue, Reason: "MinimumReplicasAvailable", Message: "Deployment has minimum availability."},
+			},
+		},
+	}
+}
+
+func crashingPod(name string, labels map[string]string, reason string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a", Labels: labels},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "web",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}},
+			}},
+		},
+	}
+}
+
+func TestDeploymentRolloutState(t *testing.T) {
+	state := deploymentRolloutState(rolloutDeployment(2, 2), "new", nil)
+	assert.Equal(t, metav1.ConditionTrue, state.available.status)
+	assert.Equal(t, "MinimumReplicasAvailable", state.available.reason)
+	assert.Equal(t, metav1.ConditionFalse, state.progressing.status)
+	assert.Equal(t, "RolloutComplete", state.progressing.reason)
+	assert.Equal(t, metav1.ConditionFalse, state.failed.status)
+	assert.Equal(t, int32(2), state.updatedReplicas)
+
+	state = deploymentRolloutState(rolloutDeployment(1, 2), "new", nil)
+	assert.Equal(t, metav1.ConditionTrue, state.progressing.status)
+	assert.Equal(t, "1 of 2 pods updated, 2 available", state.progressing.message)
+
+	state = deploymentRolloutState(nil, "", nil)
+	assert.Equal(t, metav1.ConditionUnknown, state.available.status)
+	assert.Equal(t, metav1.ConditionTrue, state.progressing.status)
+}
+
+func TestDeploymentRolloutStateFailed(t *testing.T) {
+	// Only the pods of the new ReplicaSet fail the rollout
+	pods := []corev1.Pod{
+		crashingPod("web-old", map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "old"}, "CrashLoopBackOff"),
+		crashingPod("web-new", map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "new"}, "ImagePullBackOff"),
+	}
+	state := deploymentRolloutState(rolloutDeployment(1, 2), "new", pods)
+	assert.Equal(t, metav1.ConditionTrue, state.failed.status)
+	assert.Equal(t, "ImagePullBackOff", state.failed.reason)
+	assert.Equal(t, "Container web of pod web-new is in ImagePullBackOff", state.failed.message)
+	assert.Equal(t, metav1.ConditionTrue, state.progressing.status)
+
+	deployment := rolloutDeployment(1, 2)
+	deployment.Status.Conditions = append(deployment.Status.Conditions, appsv1.DeploymentCondition{
+		Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded",
+	})
+	state = deploymentRolloutState(deployment, "new", nil)
+	assert.Equal(t, metav1.ConditionTrue, state.failed.status)
+	assert.Equal(t, "ProgressDeadlineExceeded", state.failed.reason)
+	assert.Equal(t, metav1.ConditionFalse, state.progressing.status)
+	assert.Equal(t, "ProgressDeadlineExceeded", state.progressing.reason)
+}
+
+func TestStatefulSetRolloutState(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	sts := rolloutStatefulSet(0)
+	sts.Spec.Replicas = new(int32(3))
+	sts.Status.Replicas = 3
+	sts.Status.ReadyReplicas = 3
+	sts.Status.AvailableReplicas = 3
+	sts.Status.UpdatedReplicas = 3
+	sts.Status.CurrentRevision = "db-new"
+
+	state := statefulSetRolloutState(now, "db", sts, nil)
+	assert.Equal(t, metav1.ConditionTrue, state.available.status)
+	assert.Equal(t, "RolloutComplete", state.progressing.reason)
+	assert.Equal(t, metav1.ConditionFalse, state.failed.status)
+
+	// A partition that keeps the lower ordinals on the current revision
+	sts.Spec.UpdateStrategy.RollingUpdate.Partition = new(int32(2))
+	sts.Status.CurrentRevision = "db-old"
+	sts.Status.UpdatedReplicas = 1
+	state = statefulSetRolloutState(now, "db", sts, nil)
+	assert.Equal(t, metav1.ConditionFalse, state.progressing.status)
+	assert.Equal(t, "PartitionRolledOut", state.progressing.reason)
+
+	// The pod of the update revision cannot start
+	sts.Status.ReadyReplicas = 2
+	sts.Status.AvailableReplicas = 2
+	pods := []corev1.Pod{
+		crashingPod("db-2", map[string]string{appsv1.ControllerRevisionHashLabelKey: "db-new"}, "CrashLoopBackOff"),
+		crashingPod("db-predeploy-abcde", map[string]string{appsv1.ControllerRevisionHashLabelKey: "db-new"}, "ErrImagePull"),
+	}
+	state = statefulSetRolloutState(now, "db", sts, pods)
+	assert.Equal(t, metav1.ConditionFalse, state.available.status)
+	assert.Equal(t, metav1.ConditionTrue, state.progressing.status)
+	assert.Equal(t, metav1.ConditionTrue, state.failed.status)
+	assert.Equal(t, "CrashLoopBackOff", state.failed.reason)
+	assert.Contains(t, state.failed.message, "db-2")
+}
+
+func TestUpdateRolloutStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	deployment := rolloutDeployment(1, 2)
+	controller := true
+	newReplicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-new", Namespace: "team-a",
+		Labels:          map[string]string{"app": "web", appsv1.DefaultDeploymentUniqueLabelKey: "new"},
+		Annotations:     map[string]string{deploymentRevisionAnnotation: "2"},
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "web-uid", Controller: &controller}},
+	}}
+	pod := crashingPod("web-new-abcde", map[string]string{"app": "web", appsv1.DefaultDeploymentUniqueLabelKey: "new"}, "CrashLoopBackOff")
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment, newReplicaSet, &pod).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &ApplicationReconciler{ReconcilerBase: controllercommon.NewReconcilerBase(c, nil, scheme, nil, recorder)}
+	application := &skiperatorv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"}}
+
+	progressing, err := r.updateRolloutStatus(context.Background(), application)
+	require.NoError(t, err)
+	assert.True(t, progressing)
+	assert.Equal(t, int32(1), application.Status.UpdatedReplicas)
+	assert.Equal(t, int32(2), application.Status.ReadyReplicas)
+	assert.True(t, meta.IsStatusConditionTrue(application.Status.Conditions, commontypes.AvailableConditionType))
+	assert.True(t, meta.IsStatusConditionTrue(application.Status.Conditions, commontypes.ProgressingConditionType))
+	failed := meta.FindStatusCondition(application.Status.Conditions, commontypes.RolloutFailedConditionType)
+	require.NotNil(t, failed)
+	assert.Equal(t, metav1.ConditionTrue, failed.Status)
+	assert.Equal(t, "CrashLoopBackOff", failed.Reason)
+	assert.Len(t, recorder.Events, 1)
+
+	// The event is only emitted when the rollout starts failing
+	_, err = r.updateRolloutStatus(context.Background(), application)
+	require.NoError(t, err)
+	assert.Len(t, recorder.Events, 1)
+}
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/statefulset"
+	"github.com/kartverket/skiperator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// States of a staged rollout in the Application status
+const (
+	stagedRolloutProgressing = "Progressing"
+	stagedRolloutSoaking     = "Soaking"
+	stagedRolloutPaused      = "Paused"
+	stagedRolloutComplete    = "Complete"
+)
+
+const (
+	// An updated pod that has not become ready after this long pauses the rollout, like the default progress
+	// deadline of a Deployment
+	stagedRolloutReadyTimeout = 10 * time.Minute
+	// Changes to pods do not trigger reconciles, so a rollout in progress is checked again after this interval
+	stagedRolloutRequeue = 10 * time.Second
+)
+
+// Waiting reasons of containers that will not become ready without a change to the pod
+var failedContainerReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// stagedRolloutPlan is the partition of the next step of a staged rollout and its progress
+type stagedRolloutPlan struct {
+	partition    int32
+	status       *skiperatorv1alpha1.StatefulRolloutStatus
+	requeueAfter time.Duration
+}
+
+// resolveStagedRollout sets the partition of the StatefulSet of an Application with a staged rollout. The
+// partition is kept at the number of replicas between rollouts, so a new pod template does not update any pod
+// until the partition is lowered here. It returns when the Application has to be reconciled again to advance
+// the rollout, or zero when it is complete.
+func (r *ApplicationReconciler) resolveStagedRollout(ctx context.Context, application *skiperatorv1alpha1.Application, reconciliationApp reconciliation.Reconciliation) (time.Duration, error) {
+	if !application.IsStateful() || application.Spec.Stateful.Rollout != skiperatorv1alpha1.StatefulRolloutStaged {
+		application.Status.StatefulRollout = nil
+		return 0, nil
+	}
+	replicas, err := skiperatorv1alpha1.GetStaticReplicas(application.Spec.Replicas)
+	if err != nil {
+		return 0, err
+	}
+
+	var sts *appsv1.StatefulSet
+	pods := map[int32]*corev1.Pod{}
+	live := &appsv1.StatefulSet{}
+	if err := r.GetClient().Get(ctx, client.ObjectKeyFromObject(application), live); err == nil {
+		sts = live
+		podList := corev1.PodList{}
+		if err := r.GetClient().List(ctx, &podList, client.InNamespace(application.Namespace), client.MatchingLabels(util.GetPodAppSelector(application.Name))); err != nil {
+			return 0, fmt.Errorf("failed to list pods: %w", err)
+		}
+		for i := range podList.Items {
+			if ordinal, ok := podOrdinal(application.Name, podList.Items[i].Name); ok {
+				pods[ordinal] = &podList.Items[i]
+			}
+		}
+	} else if !errors.IsNotFound(err) {
+		return 0, fmt.Errorf("failed to get statefulset: %w", err)
+	}
+
+	var soak time.Duration
+	if application.Spec.Stateful.SoakDuration != nil {
+		soak = application.Spec.Stateful.SoakDuration.Duration
+	}
+
+	plan := planStagedRollout(time.Now(), int32(replicas), soak, sts, pods)
+	previous := application.Status.StatefulRollout
+	if plan.status != nil && plan.status.State == stagedRolloutPaused && (previous == nil || previous.State != stagedRolloutPaused) {
+		r.EmitWarningEvent(application, "StagedRolloutPaused", plan.status.Message)
+	}
+	application.Status.StatefulRollout = plan.status
+	reconciliationApp.SetStatefulPartition(&plan.partition)
+	return plan.requeueAfter, nil
+}
+
+// planStagedRollout decides the partition of the StatefulSet sts, which is nil if it has not been created yet.
+// Going from the highest ordinal down, every updated pod has to be ready for soak before the next pod is
+// updated. The rollout pauses at an updated pod that fails readiness, and is complete when all pods are updated.
+func planStagedRollout(now time.Time, replicas int32, soak time.Duration, sts *appsv1.StatefulSet, pods map[int32]*corev1.Pod) stagedRolloutPlan {
+	// A new StatefulSet creates all pods from the current template
+	if sts == nil {
+		return stagedRolloutPlan{partition: 0, requeueAfter: stagedRolloutRequeue}
+	}
+
+	partition := replicas
+	if strategy := sts.Spec.UpdateStrategy.RollingUpdate; strategy != nil && strategy.Partition != nil {
+		partition = min(*strategy.Partition, replicas)
+	}
+	status := &skiperatorv1alpha1.StatefulRolloutStatus{
+		CurrentRevision: sts.Status.CurrentRevision,
+		UpdateRevision:  sts.Status.UpdateRevision,
+		State:           stagedRolloutProgressing,
+	}
+	plan := stagedRolloutPlan{partition: partition, status: status, requeueAfter: stagedRolloutRequeue}
+
+	// The revisions are only known once the StatefulSet controller has observed the current template
+	if sts.Status.ObservedGeneration < sts.Generation || sts.Status.UpdateRevision == "" {
+		status.Message = "Waiting for the StatefulSet controller to observe the pod template"
+		plan.status = withPodRevisions(status, partition, replicas, pods)
+		return plan
+	}
+
+	// The StatefulSet controller sets the current revision to the update revision once all pods are updated
+	// and ready, and pods created without a rollout in progress get the current revision
+	complete := true
+	for ordinal := replicas - 1; sts.Status.CurrentRevision != sts.Status.UpdateRevision && ordinal >= 0; ordinal-- {
+		pod := pods[ordinal]
+		name := statefulset.PodName(sts.Name, ordinal)
+		if pod == nil || pod.DeletionTimestamp != nil {
+			plan.partition = ordinal
+			status.Message = fmt.Sprintf("Waiting for %s to be created", name)
+			complete = false
+			break
+		}
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != sts.Status.UpdateRevision {
+			plan.partition = ordinal
+			status.Message = fmt.Sprintf("Updating %s to revision %s", name, sts.Status.UpdateRevision)
+			complete = false
+			break
+		}
+
+		readySince, ready := podReadySince(pod)
+		if !ready {
+			plan.partition = ordinal
+			complete = false
+			if reason, failed := podFailing(now, pod); failed {
+				status.State = stagedRolloutPaused
+				status.Message = fmt.Sprintf("Paused at %s, which fails readiness: %s", name, reason)
+			} else {
+				status.Message = fmt.Sprintf("Waiting for %s to become ready", name)
+			}
+			break
+		}
+		if soaked := now.Sub(readySince); soaked < soak {
+			plan.partition = ordinal
+			status.State = stagedRolloutSoaking
+			status.Message = fmt.Sprintf("Soaking %s for %s", name, (soak - soaked).Round(time.Second))
+			plan.requeueAfter = soak - soaked
+			complete = false
+			break
+		}
+	}
+
+	if complete {
+		// Armed for the next pod template, which is not rolled out until the partition is lowered
+		plan.partition = replicas
+		plan.requeueAfter = 0
+		status.State = stagedRolloutComplete
+		status.Message = fmt.Sprintf("Revision %s has been rolled out", sts.Status.UpdateRevision)
+	}
+	plan.status = withPodRevisions(status, plan.partition, replicas, pods)
+	return plan
+}
+
+// withPodRevisions records the revision and target revision of every pod of the StatefulSet in status
+func withPodRevisions(status *skiperatorv1alpha1.StatefulRolloutStatus, partition int32, replicas int32, pods map[int32]*corev1.Pod) *skiperatorv1alpha1.StatefulRolloutStatus {
+	status.Partition = partition
+	status.UpdatedReplicas = 0
+	status.Pods = nil
+	for ordinal := int32(0); ordinal < replicas; ordinal++ {
+		podRevision := skiperatorv1alpha1.StatefulPodRevision{Ordinal: ordinal, TargetRevision: status.CurrentRevision}
+		if ordinal >= partition {
+			podRevision.TargetRevision = status.UpdateRevision
+		}
+		if pod := pods[ordinal]; pod != nil {
+			podRevision.Revision = pod.Labels[appsv1.ControllerRevisionHashLabelKey]
+			_, podRevision.Ready = podReadySince(pod)
+		}
+		if podRevision.Revision != "" && podRevision.Revision == status.UpdateRevision {
+			status.UpdatedReplicas++
+		}
+		status.Pods = append(status.Pods, podRevision)
+	}
+	return status
+}
+
+// podReadySince reports whether the pod is ready, and since when
+func podReadySince(pod *corev1.Pod) (time.Time, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.LastTransitionTime.Time, condition.Status == corev1.ConditionTrue
+		}
+	}
+	return time.Time{}, false
+}
+
+// podFailing reports why a pod that is not ready will not become ready: one of its containers cannot start,
+// or it has not become ready within stagedRolloutReadyTimeout
+func podFailing(now time.Time, pod *corev1.Pod) (string, bool) {
+	if name, reason, failed := failedContainer(pod); failed {
+		return fmt.Sprintf("container %s is in %s", name, reason), true
+	}
+	if !pod.CreationTimestamp.IsZero() && now.Sub(pod.CreationTimestamp.Time) > stagedRolloutReadyTimeout {
+		return fmt.Sprintf("not ready after %s", stagedRolloutReadyTimeout), true
+	}
+	return "", false
+}
+
+// failedContainer returns the name and waiting reason of a container of the pod that cannot start
+func failedContainer(pod *corev1.Pod) (string, string, bool) {
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, containerStatus := range statuses {
+			if waiting := containerStatus.State.Waiting; waiting != nil && failedContainerReasons[waiting.Reason] {
+				return containerStatus.Name, waiting.Reason, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// podOrdinal returns the ordinal of a pod of the StatefulSet of the Application from its name
+func podOrdinal(applicationName string, podName string) (int32, bool) {
+	suffix, ok := strings.CutPrefix(podName, applicationName+"-")
+	if !ok {
+		return 0, false
+	}
+	ordinal, err := strconv.ParseInt(suffix, 10, 32)
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
+	return int32(ordinal), true
+}
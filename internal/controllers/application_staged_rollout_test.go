@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/statefulset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func rolloutStatefulSet(partition int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a", Generation: 2},
+		Spec: appsv1.StatefulSetSpec{UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+			Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition},
+		}},
+		Status: appsv1.StatefulSetStatus{ObservedGeneration: 2, CurrentRevision: "db-old", UpdateRevision: "db-new"},
+	}
+}
+
+func rolloutPod(ordinal int32, revision string, created time.Time, readySince *time.Time) *corev1.Pod {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              statefulset.PodName("db", ordinal),
+		CreationTimestamp: metav1.NewTime(created),
+		Labels:            map[string]string{appsv1.ControllerRevisionHashLabelKey: revision},
+	}}
+	condition := corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(created)}
+	if readySince != nil {
+		condition.Status = corev1.ConditionTrue
+		condition.LastTransitionTime = metav1.NewTime(*readySince)
+	}
+	pod.Status.Conditions = []corev1.PodCondition{condition}
+	return pod
+}
+
+func TestPlanStagedRolloutNewStatefulSet(t *testing.T) {
+	plan := planStagedRollout(time.Now(), 3, time.Minute, nil, nil)
+	assert.Equal(t, int32(0), plan.partition)
+	assert.Nil(t, plan.status)
+	assert.Equal(t, stagedRolloutRequeue, plan.requeueAfter)
+}
+
+func TestPlanStagedRolloutWithoutNewRevision(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	sts := rolloutStatefulSet(0)
+	sts.Status.CurrentRevision = "db-new"
+
+	// Pods that are not ready without a rollout in progress do not pause anything
+	pods := map[int32]*corev1.Pod{0: rolloutPod(0, "db-new", now.Add(-time.Hour), nil)}
+	plan := planStagedRollout(now, 3, time.Minute, sts, pods)
+	assert.Equal(t, int32(3), plan.partition)
+	assert.Equal(t, stagedRolloutComplete, plan.status.State)
+	assert.Zero(t, plan.requeueAfter)
+}
+
+func TestPlanStagedRolloutStepsDownOneOrdinalAtATime(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	longAgo := now.Add(-time.Hour)
+	soak := 5 * time.Minute
+
+	// A new template starts with the highest ordinal
+	pods := map[int32]*corev1.Pod{
+		0: rolloutPod(0, "db-old", longAgo, &longAgo),
+		1: rolloutPod(1, "db-old", longAgo, &longAgo),
+		2: rolloutPod(2, "db-old", longAgo, &longAgo),
+	}
+	plan := planStagedRollout(now, 3, soak, rolloutStatefulSet(3), pods)
+	assert.Equal(t, int32(2), plan.partition)
+	assert.Equal(t, stagedRolloutProgressing, plan.status.State)
+	assert.Equal(t, stagedRolloutRequeue, plan.requeueAfter)
+
+	// The updated pod soaks before the next ordinal is updated
+	readySince := now.Add(-2 * time.Minute)
+	pods[2] = rolloutPod(2, "db-new", now.Add(-3*time.Minute), &readySince)
+	plan = planStagedRollout(now, 3, soak, rolloutStatefulSet(2), pods)
+	assert.Equal(t, int32(2), plan.partition)
+	assert.Equal(t, stagedRolloutSoaking, plan.status.State)
+	assert.Equal(t, 3*time.Minute, plan.requeueAfter)
+	assert.Equal(t, int32(1), plan.status.UpdatedReplicas)
+	require.Len(t, plan.status.Pods, 3)
+	assert.Equal(t, skiperatorv1alpha1.StatefulPodRevision{Ordinal: 1, Revision: "db-old", TargetRevision: "db-old", Ready: true}, plan.status.Pods[1])
+	assert.Equal(t, skiperatorv1alpha1.StatefulPodRevision{Ordinal: 2, Revision: "db-new", TargetRevision: "db-new", Ready: true}, plan.status.Pods[2])
+
+	plan = planStagedRollout(now.Add(3*time.Minute), 3, soak, rolloutStatefulSet(2), pods)
+	assert.Equal(t, int32(1), plan.partition)
+	assert.Equal(t, "db-new", plan.status.Pods[1].TargetRevision)
+
+	// Once all pods are updated the partition is raised again for the next template
+	pods[0] = rolloutPod(0, "db-new", longAgo, &longAgo)
+	pods[1] = rolloutPod(1, "db-new", longAgo, &longAgo)
+	pods[2] = rolloutPod(2, "db-new", longAgo, &longAgo)
+	plan = planStagedRollout(now, 3, soak, rolloutStatefulSet(0), pods)
+	assert.Equal(t, int32(3), plan.partition)
+	assert.Equal(t, stagedRolloutComplete, plan.status.State)
+	assert.Equal(t, int32(3), plan.status.UpdatedReplicas)
+	assert.Zero(t, plan.requeueAfter)
+}
+
+func TestPlanStagedRolloutWaitsForPods(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	longAgo := now.Add(-time.Hour)
+	pods := map[int32]*corev1.Pod{
+		0: rolloutPod(0, "db-old", longAgo, &longAgo),
+		1: rolloutPod(1, "db-old", longAgo, &longAgo),
+	}
+
+	// The StatefulSet controller is recreating the pod
+	plan := planStagedRollout(now, 3, 0, rolloutStatefulSet(2), pods)
+	assert.Equal(t, int32(2), plan.partition)
+	assert.Equal(t, stagedRolloutProgressing, plan.status.State)
+	assert.Equal(t, "", plan.status.Pods[2].Revision)
+
+	pods[2] = rolloutPod(2, "db-new", now.Add(-time.Minute), nil)
+	plan = planStagedRollout(now, 3, 0, rolloutStatefulSet(2), pods)
+	assert.Equal(t, int32(2), plan.partition)
+	assert.Equal(t, stagedRolloutProgressing, plan.status.State)
+
+	// The partition is kept until the StatefulSet controller has observed it
+	sts := rolloutStatefulSet(2)
+	sts.Generation = 3
+	plan = planStagedRollout(now, 3, 0, sts, pods)
+	assert.Equal(t, int32(2), plan.partition)
+	assert.Equal(t, stagedRolloutRequeue, plan.requeueAfter)
+}
+
+func TestPlanStagedRolloutPausesWhenPodFailsReadiness(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	longAgo := now.Add(-time.Hour)
+	pods := map[int32]*corev1.Pod{
+		0: rolloutPod(0, "db-old", longAgo, &longAgo),
+		1: rolloutPod(1, "db-old", longAgo, &longAgo),
+		2: rolloutPod(2, "db-new", now.Add(-time.Minute), nil),
+	}
+	pods[2].Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "db",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}}
+
+	plan := planStagedRollout(now, 3, 0, rolloutStatefulSet(2), pods)
+	assert.Equal(t, int32(2), plan.partition)
+	assert.Equal(t, stagedRolloutPaused, plan.status.State)
+	assert.Contains(t, plan.status.Message, "container db is in CrashLoopBackOff")
+
+	// A pod that never becomes ready also pauses the rollout
+	pods[2] = rolloutPod(2, "db-new", now.Add(-stagedRolloutReadyTimeout-time.Minute), nil)
+	plan = planStagedRollout(now, 3, 0, rolloutStatefulSet(2), pods)
+	assert.Equal(t, int32(2), plan.partition)
+	assert.Equal(t, stagedRolloutPaused, plan.status.State)
+}
+
+func TestPodOrdinal(t *testing.T) {
+	ordinal, ok := podOrdinal("db", "db-12")
+	assert.True(t, ok)
+	assert.Equal(t, int32(12), ordinal)
+
+	_, ok = podOrdinal("db", "db-predeploy-abcde")
+	assert.False(t, ok)
+	_, ok = podOrdinal("db", "cache-0")
+	assert.False(t, ok)
+}
+
+func TestValidateApplicationStatefulFieldsStagedRollout(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{Spec: skiperatorv1alpha1.ApplicationSpec{
+		Stateful: &skiperatorv1alpha1.StatefulSpec{
+			Enabled:              true,
+			VolumeClaimTemplates: []skiperatorv1alpha1.VolumeClaimTemplate{{Name: "data", MountPath: "/data"}},
+			Rollout:              skiperatorv1alpha1.StatefulRolloutStaged,
+		},
+	}}
+	assert.NoError(t, validateApplicationStatefulFields(application))
+
+	application.Spec.Stateful.Partition = new(int32(1))
+	assert.Error(t, validateApplicationStatefulFields(application))
+}
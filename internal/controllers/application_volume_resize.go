@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	volumeResizePollInterval = time.Second
+	volumeResizeTimeout      = 30 * time.Second
+)
+
+// volumeClaimResize is the expansion of the PVCs of a stateful Application planned by planVolumeClaimResize. It is
+// applied by resizeVolumeClaims once all resources of the Application have been generated and verified.
+type volumeClaimResize struct {
+	statefulSet *appsv1.StatefulSet
+	expand      map[*corev1.PersistentVolumeClaim]resource.Quantity
+}
+
+// planVolumeClaimResize returns the PVCs of a stateful Application to expand when the storage request of a volume
+// claim template has been increased, or nil if there are none. Nothing is changed in the cluster. Decreased requests
+// and StorageClasses without volume expansion are invalid.
+func (r *ApplicationReconciler) planVolumeClaimResize(ctx context.Context, application *skiperatorv1alpha1.Application) (*volumeClaimResize, error) {
+	if !application.IsStateful() {
+		return nil, nil
+	}
+
+	sts := &appsv1.StatefulSet{}
+	if err := r.GetClient().Get(ctx, client.ObjectKeyFromObject(application), sts); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get statefulset: %w", err)
+	}
+	if sts.DeletionTimestamp != nil {
+		// Deleted for a resize in an earlier reconcile, which has to wait for it to be gone
+		return &volumeClaimResize{statefulSet: sts}, nil
+	}
+
+	resizes, errs := volumeClaimResizes(application, sts)
+	if len(errs) > 0 {
+		return nil, errors.NewInvalid(application.GroupVersionKind().GroupKind(), application.Name, errs)
+	}
+	if len(resizes) == 0 {
+		return nil, nil
+	}
+
+	pvcs, err := r.listVolumeClaims(ctx, application)
+	if err != nil {
+		return nil, err
+	}
+
+	expandable := map[string]bool{}
+	plan := &volumeClaimResize{statefulSet: sts, expand: map[*corev1.PersistentVolumeClaim]resource.Quantity{}}
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		requested, ok := resizes[volumeClaimTemplateName(application, pvc.Name)]
+		if !ok || pvc.Spec.Resources.Requests.Storage().Cmp(requested) >= 0 {
+			continue
+		}
+
+		storageClassName := ""
+		if pvc.Spec.StorageClassName != nil {
+			storageClassName = *pvc.Spec.StorageClassName
+		}
+		if _, ok := expandable[storageClassName]; !ok {
+			if expandable[storageClassName], err = r.allowsVolumeExpansion(ctx, storageClassName); err != nil {
+				return nil, err
+			}
+		}
+		if !expandable[storageClassName] {
+			errs = append(errs, field.Forbidden(field.NewPath("spec", "stateful", "volumeClaimTemplates"),
+				fmt.Sprintf("storage class %q of %s does not allow volume expansion", storageClassName, pvc.Name)))
+			continue
+		}
+		plan.expand[pvc] = requested
+	}
+	if len(errs) > 0 {
+		return nil, errors.NewInvalid(application.GroupVersionKind().GroupKind(), application.Name, errs)
+	}
+	return plan, nil
+}
+
+// resizeVolumeClaims expands the PVCs planned by planVolumeClaimResize. Volume claim templates are immutable, so the
+// StatefulSet is then deleted with its pods orphaned, and this waits for it to be gone so it can be created with
+// the new templates in the same reconcile.
+func (r *ApplicationReconciler) resizeVolumeClaims(ctx context.Context, application *skiperatorv1alpha1.Application, plan *volumeClaimResize) error {
+	for pvc, requested := range plan.expand {
+		patch := client.MergeFrom(pvc.DeepCopy())
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = requested
+		if err := r.GetClient().Patch(ctx, pvc, patch); err != nil {
+			return fmt.Errorf("failed to expand persistent volume claim %s: %w", pvc.Name, err)
+		}
+		r.EmitNormalEvent(application, "VolumeResizing", fmt.Sprintf("Expanding %s to %s", pvc.Name, pvc.Spec.Resources.Requests.Storage()))
+	}
+
+	if plan.statefulSet.DeletionTimestamp == nil {
+		if err := r.GetClient().Delete(ctx, plan.statefulSet, client.PropagationPolicy(metav1.DeletePropagationOrphan)); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete statefulset for recreation: %w", err)
+		}
+		r.EmitNormalEvent(application, "StatefulSetRecreating", "Recreating StatefulSet with resized volume claim templates, pods are kept running")
+	}
+
+	err := wait.PollUntilContextTimeout(ctx, volumeResizePollInterval, volumeResizeTimeout, true, func(ctx context.Context) (bool, error) {
+		err := r.GetClient().Get(ctx, client.ObjectKeyFromObject(plan.statefulSet), &appsv1.StatefulSet{})
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for statefulset to be deleted before recreating it: %w", err)
+	}
+	return nil
+}
+
+// volumeClaimResizes returns the new storage request of the volume claim templates of the Application whose
+// request is larger than in the StatefulSet, by template name
+func volumeClaimResizes(application *skiperatorv1alpha1.Application, sts *appsv1.StatefulSet) (map[string]resource.Quantity, field.ErrorList) {
+	live := map[string]resource.Quantity{}
+	for _, template := range sts.Spec.VolumeClaimTemplates {
+		live[template.Name] = *template.Spec.Resources.Requests.Storage()
+	}
+
+	resizes := map[string]resource.Quantity{}
+	var errs field.ErrorList
+	for i, template := range application.Spec.Stateful.VolumeClaimTemplates {
+		current, ok := live[template.Name]
+		if !ok {
+			continue
+		}
+		requested := *template.Spec.Resources.Requests.Storage()
+		switch requested.Cmp(current) {
+		case 1:
+			resizes[template.Name] = requested
+		case -1:
+			errs = append(errs, field.Forbidden(
+				field.NewPath("spec", "stateful", "volumeClaimTemplates").Index(i).Child("spec", "resources", "requests", "storage"),
+				fmt.Sprintf("cannot be decreased from %s", current.String())))
+		}
+	}
+	return resizes, errs
+}
+
+// updateVolumeResizesStatus records the bound PVCs of the Application whose volume has not been expanded to the
+// requested storage yet. It returns true while there are any, as PVC changes do not trigger reconciles.
+func (r *ApplicationReconciler) updateVolumeResizesStatus(ctx context.Context, application *skiperatorv1alpha1.Application) (bool, error) {
+	if !application.IsStateful() {
+		application.Status.VolumeResizes = nil
+		return false, nil
+	}
+
+	pvcs, err := r.listVolumeClaims(ctx, application)
+	if err != nil {
+		return false, err
+	}
+	application.Status.VolumeResizes = volumeResizesState(pvcs)
+	return len(application.Status.VolumeResizes) > 0, nil
+}
+
+// volumeResizesState returns the progress of the bound PVCs whose capacity is less than the requested storage
+func volumeResizesState(pvcs []corev1.PersistentVolumeClaim) []skiperatorv1alpha1.VolumeClaimResizeStatus {
+	var resizes []skiperatorv1alpha1.VolumeClaimResizeStatus
+	for _, pvc := range pvcs {
+		if pvc.Status.Phase != corev1.ClaimBound {
+			continue
+		}
+		requested := *pvc.Spec.Resources.Requests.Storage()
+		capacity := *pvc.Status.Capacity.Storage()
+		if capacity.Cmp(requested) >= 0 {
+			continue
+		}
+
+		state := "Pending"
+		if resizeStatus, ok := pvc.Status.AllocatedResourceStatuses[corev1.ResourceStorage]; ok {
+			state = string(resizeStatus)
+		} else {
+			for _, condition := range pvc.Status.Conditions {
+				if condition.Status == corev1.ConditionTrue {
+					state = string(condition.Type)
+				}
+			}
+		}
+		resizes = append(resizes, skiperatorv1alpha1.VolumeClaimResizeStatus{
+			Name:      pvc.Name,
+			Requested: requested,
+			Capacity:  capacity,
+			State:     state,
+		})
+	}
+	return resizes
+}
+
+// listVolumeClaims returns the PVCs provisioned from the volume claim templates of the Application, including
+// those of replicas that have been scaled down
+func (r *ApplicationReconciler) listVolumeClaims(ctx context.Context, application *skiperatorv1alpha1.Application) ([]corev1.PersistentVolumeClaim, error) {
+	pvcs := corev1.PersistentVolumeClaimList{}
+	if err := r.GetClient().List(ctx, &pvcs, client.InNamespace(application.Namespace), client.MatchingLabels(util.GetPodAppSelector(application.Name))); err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+
+	var claims []corev1.PersistentVolumeClaim
+	for _, pvc := range pvcs.Items {
+		if volumeClaimTemplateName(application, pvc.Name) != "" {
+			claims = append(claims, pvc)
+		}
+	}
+	return claims, nil
+}
+
+// volumeClaimTemplateName returns the name of the volume claim template the PVC was provisioned from, or an
+// empty string if it was not provisioned from one
+func volumeClaimTemplateName(application *skiperatorv1alpha1.Application, claimName string) string {
+	for _, template := range application.Spec.Stateful.VolumeClaimTemplates {
+		ordinal, ok := strings.CutPrefix(claimName, template.Name+"-"+application.Name+"-")
+		if _, err := strconv.ParseUint(ordinal, 10, 32); ok && err == nil {
+			return template.Name
+		}
+	}
+	return ""
+}
+
+// allowsVolumeExpansion reports whether PVCs of the StorageClass can be expanded
+func (r *ApplicationReconciler) allowsVolumeExpansion(ctx context.Context, storageClassName string) (bool, error) {
+	if storageClassName == "" {
+		return false, nil
+	}
+	storageClass := &storagev1.StorageClass{}
+	if err := r.GetClient().Get(ctx, client.ObjectKey{Name: storageClassName}, storageClass); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get storage class %s: %w", storageClassName, err)
+	}
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	controllercommon "github.com/kartverket/skiperator/internal/controllers/common"
+	"github.com/kartverket/skiperator/pkg/resourceschemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func storageRequest(size string) corev1.PersistentVolumeClaimSpec {
+	return corev1.PersistentVolumeClaimSpec{
+		Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}},
+	}
+}
+
+func statefulApplication(size string) *skiperatorv1alpha1.Application {
+	return &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Stateful: &skiperatorv1alpha1.StatefulSpec{
+				Enabled:              true,
+				VolumeClaimTemplates: []skiperatorv1alpha1.VolumeClaimTemplate{{Name: "data", MountPath: "/data", Spec: storageRequest(size)}},
+			},
+		},
+	}
+}
+
+func volumeClaim(name string, size string, capacity string, storageClassName string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a", Labels: map[string]string{"app": "db"}},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClassName,
+			Resources:        corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Phase:    corev1.ClaimBound,
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)},
+		},
+	}
+}
+
+func TestVolumeClaimResizes(t *testing.T) {
+	sts := &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+		{ObjectMeta: metav1.ObjectMeta{Name: "data"}, Spec: storageRequest("1Gi")},
+	}}}
+
+	resizes, errs := volumeClaimResizes(statefulApplication("1Gi"), sts)
+	assert.Empty(t, resizes)
+	assert.Empty(t, errs)
+
+	resizes, errs = volumeClaimResizes(statefulApplication("2Gi"), sts)
+	assert.Empty(t, errs)
+	assert.Equal(t, map[string]resource.Quantity{"data": resource.MustParse("2Gi")}, resizes)
+
+	_, errs = volumeClaimResizes(statefulApplication("512Mi"), sts)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "spec.stateful.volumeClaimTemplates[0].spec.resources.requests.storage", errs[0].Field)
+}
+
+func TestVolumeClaimTemplateName(t *testing.T) {
+	application := statefulApplication("1Gi")
+	assert.Equal(t, "data", volumeClaimTemplateName(application, "data-db-0"))
+	assert.Equal(t, "data", volumeClaimTemplateName(application, "data-db-12"))
+	assert.Equal(t, "", volumeClaimTemplateName(application, "data-db-backup-0"))
+	assert.Equal(t, "", volumeClaimTemplateName(application, "logs-db-0"))
+}
+
+func TestVolumeResizesState(t *testing.T) {
+	resized := volumeClaim("data-db-0", "2Gi", "2Gi", "standard")
+	expanding := volumeClaim("data-db-1", "2Gi", "1Gi", "standard")
+	expanding.Status.AllocatedResourceStatuses = map[corev1.ResourceName]corev1.ClaimResourceStatus{
+		corev1.ResourceStorage: corev1.PersistentVolumeClaimNodeResizePending,
+	}
+	pending := volumeClaim("data-db-2", "2Gi", "1Gi", "standard")
+	unbound := volumeClaim("data-db-3", "2Gi", "0", "standard")
+	unbound.Status.Phase = corev1.ClaimPending
+
+	resizes := volumeResizesState([]corev1.PersistentVolumeClaim{*resized, *expanding, *pending, *unbound})
+	require.Len(t, resizes, 2)
+	assert.Equal(t, "data-db-1", resizes[0].Name)
+	assert.Equal(t, "NodeResizePending", resizes[0].State)
+	assert.Equal(t, "data-db-2", resizes[1].Name)
+	assert.Equal(t, "Pending", resizes[1].State)
+}
+
+func TestResizeVolumeClaims(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"},
+		Spec: appsv1.StatefulSetSpec{VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+			{ObjectMeta: metav1.ObjectMeta{Name: "data"}, Spec: storageRequest("1Gi")},
+		}},
+	}
+	expandable := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "expandable"}, AllowVolumeExpansion: new(true)}
+	fixed := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fixed"}}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		sts, expandable, fixed,
+		volumeClaim("data-db-0", "1Gi", "1Gi", "expandable"),
+		volumeClaim("data-db-1", "1Gi", "1Gi", "expandable"),
+	).Build()
+	r := &ApplicationReconciler{ReconcilerBase: controllercommon.NewReconcilerBase(c, nil, scheme, nil, record.NewFakeRecorder(10))}
+
+	plan, err := r.planVolumeClaimResize(context.Background(), statefulApplication("2Gi"))
+	require.NoError(t, err)
+	require.NotNil(t, plan)
+
+	// Planning does not change anything, so an error generating the resources leaves the StatefulSet in place
+	pvc := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "data-db-0"}, pvc))
+	assert.Equal(t, "1Gi", pvc.Spec.Resources.Requests.Storage().String())
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(sts), &appsv1.StatefulSet{}))
+
+	require.NoError(t, r.resizeVolumeClaims(context.Background(), statefulApplication("2Gi"), plan))
+
+	for _, name := range []string{"data-db-0", "data-db-1"} {
+		pvc := &corev1.PersistentVolumeClaim{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: name}, pvc))
+		assert.Equal(t, "2Gi", pvc.Spec.Resources.Requests.Storage().String())
+	}
+	err = c.Get(context.Background(), client.ObjectKeyFromObject(sts), &appsv1.StatefulSet{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// Without a StatefulSet there is nothing to resize, it is created with the new templates
+	plan, err = r.planVolumeClaimResize(context.Background(), statefulApplication("2Gi"))
+	require.NoError(t, err)
+	assert.Nil(t, plan)
+}
+
+func TestResizeVolumeClaimsRequiresVolumeExpansion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"},
+		Spec: appsv1.StatefulSetSpec{VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+			{ObjectMeta: metav1.ObjectMeta{Name: "data"}, Spec: storageRequest("1Gi")},
+		}},
+	}
+	fixed := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fixed"}}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sts, fixed, volumeClaim("data-db-0", "1Gi", "1Gi", "fixed")).Build()
+	r := &ApplicationReconciler{ReconcilerBase: controllercommon.NewReconcilerBase(c, nil, scheme, nil, record.NewFakeRecorder(10))}
+
+	plan, err := r.planVolumeClaimResize(context.Background(), statefulApplication("2Gi"))
+	assert.Nil(t, plan)
+	assert.True(t, errors.IsInvalid(err))
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "data-db-0"}, pvc))
+	assert.Equal(t, "1Gi", pvc.Spec.Resources.Requests.Storage().String())
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(sts), &appsv1.StatefulSet{}))
+}
@@ -3,6 +3,7 @@ package common
 import (
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
 	"github.com/kartverket/skiperator/pkg/util"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -64,3 +65,16 @@ var StatefulSetPredicate = predicate.Funcs{
 		return oldHash != newHash
 	},
 }
+
+// SKIPJobTriggerPredicate lets through updates that change the manual trigger annotation of a SKIPJob
+var SKIPJobTriggerPredicate = predicate.Funcs{
+	CreateFunc:  func(e event.CreateEvent) bool { return false },
+	DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+	GenericFunc: func(e event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return false
+		}
+		return e.ObjectOld.GetAnnotations()[skiperatorv1beta1.TriggerAnnotationKey] != e.ObjectNew.GetAnnotations()[skiperatorv1beta1.TriggerAnnotationKey]
+	},
+}
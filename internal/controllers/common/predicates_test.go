@@ -0,0 +1,22 @@
+package common
+
+import (
+	"testing"
+
+	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestSKIPJobTriggerPredicate(t *testing.T) {
+	oldJob := &skiperatorv1beta1.SKIPJob{ObjectMeta: v1.ObjectMeta{Name: "job"}}
+	triggered := oldJob.DeepCopy()
+	triggered.Annotations = map[string]string{skiperatorv1beta1.TriggerAnnotationKey: "1"}
+	relabeled := oldJob.DeepCopy()
+	relabeled.Annotations = map[string]string{"other": "annotation"}
+
+	assert.True(t, SKIPJobTriggerPredicate.Update(event.UpdateEvent{ObjectOld: oldJob, ObjectNew: triggered}))
+	assert.False(t, SKIPJobTriggerPredicate.Update(event.UpdateEvent{ObjectOld: triggered, ObjectNew: triggered}))
+	assert.False(t, SKIPJobTriggerPredicate.Update(event.UpdateEvent{ObjectOld: oldJob, ObjectNew: relabeled}))
+}
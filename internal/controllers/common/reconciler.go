@@ -2,10 +2,14 @@ package common
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"maps"
 
 	"github.com/kartverket/skiperator/api/common"
 	"github.com/kartverket/skiperator/api/common/podtypes"
+	"github.com/kartverket/skiperator/internal/config"
+	"github.com/kartverket/skiperator/pkg/imagepolicy"
 	"github.com/kartverket/skiperator/pkg/log"
 	"github.com/kartverket/skiperator/pkg/mesh"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/resourceutils"
@@ -16,6 +20,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
@@ -125,6 +130,87 @@ func (r *ReconcilerBase) MeshModeForNamespace(ctx context.Context, namespaceName
 	return mesh.ModeFromLabels(namespace.Labels), nil
 }
 
+// ValidateImagePolicy checks the images of skipObj against the cluster's image policy.
+// The namespace labels decide whether digest pinning is required. A nil policy allows
+// all images.
+func (r *ReconcilerBase) ValidateImagePolicy(ctx context.Context, skipObj common.SKIPObject, policy *config.ImagePolicy) (field.ErrorList, error) {
+	if policy == nil {
+		return nil, nil
+	}
+
+	namespace := corev1.Namespace{}
+	if err := r.GetClient().Get(ctx, types.NamespacedName{Name: skipObj.GetNamespace()}, &namespace); err != nil {
+		return nil, err
+	}
+
+	return imagepolicy.Validate(skipObj, policy, namespace.Labels)
+}
+
+// ResolveEnvFromKeys checks that every ConfigMap and Secret key projected by envFromKeys exists,
+// and returns a hash that changes when one of them changes, so Pods can be restarted. Only the values
+// of the referenced keys are hashed, so updates to other keys or to metadata do not restart the Pods.
+// The values only end up in the Pod template as part of a SHA-256 digest of all of them.
+// Missing ConfigMaps, Secrets and keys are returned as field errors.
+func (r *ReconcilerBase) ResolveEnvFromKeys(ctx context.Context, namespace string, envFromKeys []podtypes.EnvFromKey) (field.ErrorList, string, error) {
+	if len(envFromKeys) == 0 {
+		return nil, "", nil
+	}
+
+	var errs field.ErrorList
+	hash := sha256.New()
+	basePath := field.NewPath("spec", "envFromKeys")
+	for i, envFromKey := range envFromKeys {
+		path := basePath.Index(i)
+		key := types.NamespacedName{Namespace: namespace, Name: envFromKey.Secret}
+		var data map[string][]byte
+		var sourcePath *field.Path
+		var kind string
+		found := false
+
+		if len(envFromKey.ConfigMap) > 0 {
+			key.Name = envFromKey.ConfigMap
+			sourcePath, kind = path.Child("configMap"), "ConfigMap"
+			configMap := corev1.ConfigMap{}
+			err := r.GetClient().Get(ctx, key, &configMap)
+			if err != nil && !errors.IsNotFound(err) {
+				return nil, "", err
+			}
+			if err == nil {
+				found = true
+				data = make(map[string][]byte, len(configMap.Data)+len(configMap.BinaryData))
+				for k, v := range configMap.Data {
+					data[k] = []byte(v)
+				}
+				maps.Copy(data, configMap.BinaryData)
+			}
+		} else {
+			sourcePath, kind = path.Child("secret"), "Secret"
+			secret := corev1.Secret{}
+			err := r.GetClient().Get(ctx, key, &secret)
+			if err != nil && !errors.IsNotFound(err) {
+				return nil, "", err
+			}
+			if err == nil {
+				found = true
+				data = secret.Data
+			}
+		}
+
+		if !found {
+			errs = append(errs, field.NotFound(sourcePath, key.Name))
+			continue
+		}
+		value, ok := data[envFromKey.Key]
+		if !ok {
+			errs = append(errs, field.Invalid(path.Child("key"), envFromKey.Key, fmt.Sprintf("key not found in %s %q", kind, key.Name)))
+			continue
+		}
+		fmt.Fprintf(hash, "%s/%s/%s=%x\n", kind, key.Name, envFromKey.Key, sha256.Sum256(value))
+	}
+
+	return errs, fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
 // ValidateIstioEnabledForGatewayAPI requires the namespace to be in the mesh,
 // through a sidecar or through ambient mode. Istio only programs Gateway API
 // resources for namespaces it manages.
@@ -165,6 +251,7 @@ func (r *ReconcilerBase) SetProgressingState(ctx context.Context, skipObj common
 
 func (r *ReconcilerBase) UpdateStatus(ctx context.Context, skipObj common.SKIPObject) {
 	key := client.ObjectKeyFromObject(skipObj)
+	skipObj.GetStatus().SetKStatusConditions(skipObj.GetGeneration())
 	skipObj.GetStatus().SortConditions()
 	desiredStatus := skipObj.GetStatus().DeepCopy()
 
@@ -0,0 +1,110 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kartverket/skiperator/api/common/podtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newEnvFromKeysReconciler(objects ...client.Object) ReconcilerBase {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	return NewReconcilerBase(c, nil, scheme, nil, nil)
+}
+
+func TestResolveEnvFromKeys(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "team-a"},
+		Data:       map[string]string{"log-level": "debug"},
+		BinaryData: map[string][]byte{"cert": []byte("binary")},
+	}
+	r := newEnvFromKeysReconciler(secret, configMap)
+	envFromKeys := []podtypes.EnvFromKey{
+		{Secret: "db", Key: "password", As: "DB_PASSWORD"},
+		{ConfigMap: "settings", Key: "log-level", As: "LOG_LEVEL"},
+		{ConfigMap: "settings", Key: "cert", As: "CERT"},
+	}
+
+	errs, hash, err := r.ResolveEnvFromKeys(context.Background(), "team-a", envFromKeys)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.NotEmpty(t, hash)
+
+	secret.Data["password"] = []byte("correct horse battery staple")
+	require.NoError(t, r.GetClient().Update(context.Background(), secret))
+	_, changedHash, err := r.ResolveEnvFromKeys(context.Background(), "team-a", envFromKeys)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changedHash)
+
+	configMap.Data["log-level"] = "info"
+	require.NoError(t, r.GetClient().Update(context.Background(), configMap))
+	_, configChangedHash, err := r.ResolveEnvFromKeys(context.Background(), "team-a", envFromKeys)
+	require.NoError(t, err)
+	assert.NotEqual(t, changedHash, configChangedHash)
+}
+
+func TestResolveEnvFromKeys_HashesOnlyReferencedSecretValues(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"},
+		Data:       map[string][]byte{"password": []byte("hunter2"), "username": []byte("app")},
+	}
+	r := newEnvFromKeysReconciler(secret)
+	envFromKeys := []podtypes.EnvFromKey{{Secret: "db", Key: "password", As: "DB_PASSWORD"}}
+
+	_, hash, err := r.ResolveEnvFromKeys(context.Background(), "team-a", envFromKeys)
+	require.NoError(t, err)
+
+	// Metadata-only updates and updates to other keys bump the resourceVersion, but do not restart the Pods
+	secret.Labels = map[string]string{"rotated-by": "vault"}
+	secret.Data["username"] = []byte("other")
+	require.NoError(t, r.GetClient().Update(context.Background(), secret))
+	_, updatedHash, err := r.ResolveEnvFromKeys(context.Background(), "team-a", envFromKeys)
+	require.NoError(t, err)
+	assert.Equal(t, hash, updatedHash)
+	assert.NotContains(t, updatedHash, "hunter2")
+}
+
+func TestResolveEnvFromKeys_Missing(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	r := newEnvFromKeysReconciler(secret)
+
+	errs, _, err := r.ResolveEnvFromKeys(context.Background(), "team-a", []podtypes.EnvFromKey{
+		{Secret: "db", Key: "username", As: "DB_USERNAME"},
+		{ConfigMap: "settings", Key: "log-level", As: "LOG_LEVEL"},
+		{Secret: "db", Key: "password", As: "DB_PASSWORD"},
+	})
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, field.ErrorTypeInvalid, errs[0].Type)
+	assert.Equal(t, "spec.envFromKeys[0].key", errs[0].Field)
+	assert.Contains(t, errs[0].Detail, `Secret "db"`)
+	assert.Equal(t, field.ErrorTypeNotFound, errs[1].Type)
+	assert.Equal(t, "spec.envFromKeys[1].configMap", errs[1].Field)
+}
+
+func TestResolveEnvFromKeys_Empty(t *testing.T) {
+	r := newEnvFromKeysReconciler()
+
+	errs, hash, err := r.ResolveEnvFromKeys(context.Background(), "team-a", nil)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.Empty(t, hash)
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
 	"slices"
 	"strings"
@@ -10,12 +11,14 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/kartverket/skiperator/api/common"
 	"github.com/kartverket/skiperator/api/common/podtypes"
+	"github.com/kartverket/skiperator/internal/config"
 	"github.com/kartverket/skiperator/pkg/mesh"
 	"github.com/kartverket/skiperator/pkg/metrics/usage"
 	"github.com/r3labs/diff/v3"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -135,7 +138,7 @@ func SetExternalRulesCondition(obj common.SKIPObject, status metav1.ConditionSta
 }
 
 func SetReadyInvalidConfig(obj common.SKIPObject, message string) {
-	obj.GetStatus().SetReadyCondition(metav1.ConditionFalse, obj.GetGeneration(), "InvalidConfig", message)
+	obj.GetStatus().SetReadyCondition(metav1.ConditionFalse, obj.GetGeneration(), common.InvalidConfigReason, message)
 }
 
 func SetReadyReconciled(obj common.SKIPObject, message string) {
@@ -221,3 +224,126 @@ func ValidateImageString(image string) error {
 	}
 	return nil
 }
+
+// ValidatePodSettings checks the node placement in podSettings against the
+// cluster's scheduling policy, so teams cannot land on node pools reserved for
+// others. A nil policy allows everything.
+func ValidatePodSettings(podSettings *podtypes.PodSettings, policy *config.SchedulingPolicy) field.ErrorList {
+	if podSettings == nil || policy == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec").Child("podSettings")
+	var errs field.ErrorList
+	checkNodeLabel := func(fldPath *field.Path, key string) {
+		if !slices.Contains(policy.AllowedNodeLabels, key) {
+			errs = append(errs, field.Forbidden(fldPath, fmt.Sprintf("node label %q is not allowed by the cluster scheduling policy", key)))
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(podSettings.NodeSelector)) {
+		checkNodeLabel(path.Child("nodeSelector").Key(key), key)
+	}
+
+	for i, toleration := range podSettings.Tolerations {
+		fldPath := path.Child("tolerations").Index(i).Child("key")
+		if toleration.Key == "" {
+			errs = append(errs, field.Forbidden(fldPath, "tolerations must name the taint key they tolerate"))
+		} else if !slices.Contains(policy.AllowedTolerationKeys, toleration.Key) {
+			errs = append(errs, field.Forbidden(fldPath, fmt.Sprintf("taint %q is not allowed by the cluster scheduling policy", toleration.Key)))
+		}
+	}
+
+	if affinity := podSettings.Affinity; affinity != nil {
+		affinityPath := path.Child("affinity")
+		if nodeAffinity := affinity.NodeAffinity; nodeAffinity != nil {
+			nodeAffinityPath := affinityPath.Child("nodeAffinity")
+			if required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+				for i, term := range required.NodeSelectorTerms {
+					termPath := nodeAffinityPath.Child("requiredDuringSchedulingIgnoredDuringExecution").Child("nodeSelectorTerms").Index(i)
+					validateNodeSelectorTerm(termPath, term, checkNodeLabel)
+				}
+			}
+			for i, preferred := range nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+				termPath := nodeAffinityPath.Child("preferredDuringSchedulingIgnoredDuringExecution").Index(i).Child("preference")
+				validateNodeSelectorTerm(termPath, preferred.Preference, checkNodeLabel)
+			}
+		}
+		if affinity.PodAffinity != nil {
+			validatePodAffinityTopologyKeys(affinityPath.Child("podAffinity"), affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution, affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution, checkNodeLabel)
+		}
+		if affinity.PodAntiAffinity != nil {
+			validatePodAffinityTopologyKeys(affinityPath.Child("podAntiAffinity"), affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, checkNodeLabel)
+		}
+	}
+
+	for i, constraint := range podSettings.TopologySpreadConstraints {
+		checkNodeLabel(path.Child("topologySpreadConstraints").Index(i).Child("topologyKey"), constraint.TopologyKey)
+	}
+
+	return errs
+}
+
+func validateNodeSelectorTerm(fldPath *field.Path, term corev1.NodeSelectorTerm, checkNodeLabel func(*field.Path, string)) {
+	for i, requirement := range term.MatchExpressions {
+		checkNodeLabel(fldPath.Child("matchExpressions").Index(i).Child("key"), requirement.Key)
+	}
+	for i, requirement := range term.MatchFields {
+		checkNodeLabel(fldPath.Child("matchFields").Index(i).Child("key"), requirement.Key)
+	}
+}
+
+func validatePodAffinityTopologyKeys(fldPath *field.Path, required []corev1.PodAffinityTerm, preferred []corev1.WeightedPodAffinityTerm, checkNodeLabel func(*field.Path, string)) {
+	for i, term := range required {
+		checkNodeLabel(fldPath.Child("requiredDuringSchedulingIgnoredDuringExecution").Index(i).Child("topologyKey"), term.TopologyKey)
+	}
+	for i, term := range preferred {
+		checkNodeLabel(fldPath.Child("preferredDuringSchedulingIgnoredDuringExecution").Index(i).Child("podAffinityTerm").Child("topologyKey"), term.PodAffinityTerm.TopologyKey)
+	}
+}
+
+// ValidatePodSecurityContext checks the IDs requested in podSettings.securityContext
+// against the cluster's security context policy.
+func ValidatePodSecurityContext(podSettings *podtypes.PodSettings, policy *config.SecurityContextPolicy) field.ErrorList {
+	if podSettings == nil || podSettings.SecurityContext == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec").Child("podSettings").Child("securityContext")
+	sc := podSettings.SecurityContext
+	var errs field.ErrorList
+	errs = append(errs, validateSecurityContextID(path.Child("runAsUser"), sc.RunAsUser, policy)...)
+	errs = append(errs, validateSecurityContextID(path.Child("runAsGroup"), sc.RunAsGroup, policy)...)
+	errs = append(errs, validateSecurityContextID(path.Child("fsGroup"), sc.FSGroup, policy)...)
+	return errs
+}
+
+// ValidateContainerSecurityContext checks a container's security context overrides against
+// the cluster's security context policy. Without a policy no capabilities may be added.
+func ValidateContainerSecurityContext(path *field.Path, sc *podtypes.ContainerSecurityContext, policy *config.SecurityContextPolicy) field.ErrorList {
+	if sc == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	errs = append(errs, validateSecurityContextID(path.Child("runAsUser"), sc.RunAsUser, policy)...)
+	errs = append(errs, validateSecurityContextID(path.Child("runAsGroup"), sc.RunAsGroup, policy)...)
+	for i, capability := range sc.AddCapabilities {
+		if policy == nil || !slices.Contains(policy.AllowedCapabilities, string(capability)) {
+			errs = append(errs, field.Forbidden(path.Child("addCapabilities").Index(i), fmt.Sprintf("capability %q is not allowed by the cluster security context policy", capability)))
+		}
+	}
+	return errs
+}
+
+func validateSecurityContextID(fldPath *field.Path, id *int64, policy *config.SecurityContextPolicy) field.ErrorList {
+	if id == nil || policy == nil || len(policy.AllowedIDRanges) == 0 {
+		return nil
+	}
+	for _, r := range policy.AllowedIDRanges {
+		if *id >= r.Min && *id <= r.Max {
+			return nil
+		}
+	}
+	return field.ErrorList{field.Forbidden(fldPath, fmt.Sprintf("ID %d is not allowed by the cluster security context policy", *id))}
+}
@@ -8,10 +8,13 @@ import (
 	istiov1alpha1 "github.com/kartverket/skiperator/api/common/istiotypes"
 	"github.com/kartverket/skiperator/api/common/podtypes"
 	"github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/internal/config"
 	"github.com/kartverket/skiperator/pkg/testutil"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 func TestShouldReconcile(t *testing.T) {
@@ -264,3 +267,95 @@ func TestValidateContainerImageString(t *testing.T) {
 		}))
 	})
 }
+
+func TestValidatePodSettings(t *testing.T) {
+	policy := &config.SchedulingPolicy{
+		AllowedNodeLabels:     []string{"kubernetes.io/hostname", "topology.kubernetes.io/zone", "skip.kartverket.no/pool"},
+		AllowedTolerationKeys: []string{"skip.kartverket.no/high-memory"},
+	}
+
+	t.Run("nil_policy_allows_everything", func(t *testing.T) {
+		assert.Empty(t, ValidatePodSettings(&podtypes.PodSettings{
+			NodeSelector: map[string]string{"node-role.kubernetes.io/control-plane": ""},
+			Tolerations:  []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+		}, nil))
+	})
+
+	t.Run("allowed_placement", func(t *testing.T) {
+		assert.Empty(t, ValidatePodSettings(&podtypes.PodSettings{
+			NodeSelector: map[string]string{"skip.kartverket.no/pool": "high-memory"},
+			Tolerations:  []corev1.Toleration{{Key: "skip.kartverket.no/high-memory", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+			Affinity: &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+							MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "topology.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}},
+						}},
+					},
+				},
+			},
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{TopologyKey: "topology.kubernetes.io/zone"}},
+		}, policy))
+	})
+
+	t.Run("disallowed_placement", func(t *testing.T) {
+		errs := ValidatePodSettings(&podtypes.PodSettings{
+			NodeSelector: map[string]string{"node-role.kubernetes.io/control-plane": ""},
+			Tolerations: []corev1.Toleration{
+				{Operator: corev1.TolerationOpExists},
+				{Key: "node-role.kubernetes.io/control-plane", Operator: corev1.TolerationOpExists},
+			},
+			Affinity: &corev1.Affinity{
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{TopologyKey: "example.com/rack"}},
+				},
+			},
+		}, policy)
+
+		fields := make([]string, 0, len(errs))
+		for _, err := range errs {
+			fields = append(fields, err.Field)
+		}
+		assert.Equal(t, []string{
+			"spec.podSettings.nodeSelector[node-role.kubernetes.io/control-plane]",
+			"spec.podSettings.tolerations[0].key",
+			"spec.podSettings.tolerations[1].key",
+			"spec.podSettings.affinity.podAntiAffinity.requiredDuringSchedulingIgnoredDuringExecution[0].topologyKey",
+		}, fields)
+	})
+}
+
+func TestValidateSecurityContexts(t *testing.T) {
+	path := field.NewPath("spec").Child("securityContext")
+	policy := &config.SecurityContextPolicy{
+		AllowedCapabilities: []string{"NET_BIND_SERVICE"},
+		AllowedIDRanges:     []config.IDRange{{Min: 1000, Max: 1999}},
+	}
+
+	t.Run("nil_policy_allows_ids_but_no_capabilities", func(t *testing.T) {
+		assert.Empty(t, ValidatePodSecurityContext(&podtypes.PodSettings{SecurityContext: &podtypes.PodSecurityContext{RunAsUser: new(int64(5000))}}, nil))
+		errs := ValidateContainerSecurityContext(path, &podtypes.ContainerSecurityContext{
+			RunAsUser:       new(int64(5000)),
+			AddCapabilities: []corev1.Capability{"NET_BIND_SERVICE"},
+		}, nil)
+		assert.Len(t, errs, 1)
+		assert.Equal(t, "spec.securityContext.addCapabilities[0]", errs[0].Field)
+	})
+
+	t.Run("allowed_overrides", func(t *testing.T) {
+		assert.Empty(t, ValidatePodSecurityContext(&podtypes.PodSettings{SecurityContext: &podtypes.PodSecurityContext{FSGroup: new(int64(1500))}}, policy))
+		assert.Empty(t, ValidateContainerSecurityContext(path, &podtypes.ContainerSecurityContext{
+			RunAsUser:       new(int64(1000)),
+			RunAsGroup:      new(int64(1999)),
+			AddCapabilities: []corev1.Capability{"NET_BIND_SERVICE"},
+		}, policy))
+	})
+
+	t.Run("disallowed_overrides", func(t *testing.T) {
+		errs := ValidatePodSecurityContext(&podtypes.PodSettings{SecurityContext: &podtypes.PodSecurityContext{RunAsUser: new(int64(2000)), FSGroup: new(int64(999))}}, policy)
+		assert.Equal(t, []string{"spec.podSettings.securityContext.runAsUser", "spec.podSettings.securityContext.fsGroup"}, []string{errs[0].Field, errs[1].Field})
+
+		errs = ValidateContainerSecurityContext(path, &podtypes.ContainerSecurityContext{AddCapabilities: []corev1.Capability{"SYS_ADMIN"}}, policy)
+		assert.Len(t, errs, 1)
+	})
+}
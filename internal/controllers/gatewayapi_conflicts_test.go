@@ -141,3 +141,22 @@ func TestRoutingCertificateWatchUsesRoutingLabels(t *testing.T) {
 	assert.Equal(t, "team-a", requests[0].Namespace)
 	assert.Equal(t, "api", requests[0].Name)
 }
+
+func TestValidateTLSPassthroughIngressesRequiresTLSRoute(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			RoutingProvider: skiperatorv1alpha1.RoutingProviderStandard,
+			TCPIngresses:    []skiperatorv1alpha1.TCPIngress{{Hostname: "db.example.com", Port: 5432, Protocol: "TLS"}},
+		},
+	}
+
+	assert.NoError(t, validateTLSPassthroughIngresses(application, true))
+	err := validateTLSPassthroughIngresses(application, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TLSRoute CRD")
+
+	// Legacy routing serves TLS passthrough through Istio Gateways instead
+	application.Spec.RoutingProvider = skiperatorv1alpha1.RoutingProviderLegacy
+	assert.NoError(t, validateTLSPassthroughIngresses(application, false))
+}
@@ -23,13 +23,13 @@ type gatewayAPIRoutable interface {
 func checkGatewayAPIPrerequisites(ctx context.Context, r *controllercommon.ReconcilerBase, obj gatewayAPIRoutable, meshMode mesh.Mode, logger log.Logger) bool {
 	if err := r.ValidateIstioEnabledForGatewayAPI(obj.UsesStandardRouting(), meshMode, obj.GetNamespace()); err != nil {
 		logger.Error(err, "namespace is not part of the Istio mesh")
-		r.SetErrorState(ctx, obj, err, "namespace is not part of the Istio mesh", "NamespaceNotInMesh")
+		r.SetErrorState(ctx, obj, err, "namespace is not part of the Istio mesh", commontypes.NamespaceNotInMeshReason)
 		return true
 	}
 
 	if err := gwapi.ValidateConflicts(ctx, r.GetClient(), obj); err != nil {
 		logger.Error(err, "gateway api conflict")
-		r.SetErrorState(ctx, obj, err, "gateway api conflict", "GatewayAPIConflict")
+		r.SetErrorState(ctx, obj, err, "gateway api conflict", commontypes.GatewayAPIConflictReason)
 		return true
 	}
 
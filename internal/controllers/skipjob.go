@@ -4,6 +4,8 @@ import (
 	"context"
 	goerrors "errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/kartverket/skiperator/internal/config"
 	"github.com/kartverket/skiperator/pkg/resourceprocessor"
@@ -14,8 +16,10 @@ import (
 	commontypes "github.com/kartverket/skiperator/api/common"
 	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
 	"github.com/kartverket/skiperator/internal/controllers/common"
+	"github.com/kartverket/skiperator/pkg/imagepolicy"
 	"github.com/kartverket/skiperator/pkg/log"
 	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/externalsecret"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/gcp/auth"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/istio/serviceentry"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/istio/telemetry"
@@ -32,6 +36,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -51,6 +57,7 @@ const (
 // +kubebuilder:rbac:groups=skiperator.kartverket.no,resources=skipjobs;skipjobs/status,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=telemetry.istio.io,resources=telemetries,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=external-secrets.io,resources=externalsecrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=pods;pods/ephemeralcontainers,verbs=get;list;watch;create;update;patch;delete
 
 // leave an empty line over this comment
@@ -63,7 +70,8 @@ type SKIPJobReconciler struct {
 func (r *SKIPJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		// GenerationChangedPredicate is now only applied to the SkipJob itself to allow status changes on Jobs/CronJobs to affect reconcile loops
-		For(&skiperatorv1beta1.SKIPJob{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// The trigger annotation does not change the generation, so changes to it are let through separately
+		For(&skiperatorv1beta1.SKIPJob{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, common.SKIPJobTriggerPredicate))).
 		Owns(&batchv1.CronJob{}).
 		Owns(&batchv1.Job{}).
 		// This is added as the Jobs created by CronJobs are not owned by the SKIPJob directly, but rather through the CronJob
@@ -87,6 +95,8 @@ func (r *SKIPJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 			return nil
 		})).
+		// Status changes of a SKIPJob start or fail the SKIPJobs depending on it
+		Watches(&skiperatorv1beta1.SKIPJob{}, handler.EnqueueRequestsFromMapFunc(r.getDependentSKIPJobs)).
 		Owns(&networkingv1.NetworkPolicy{}).
 		Owns(&istionetworkingv1.ServiceEntry{}).
 		Owns(&telemetryv1.Telemetry{}).
@@ -161,7 +171,67 @@ func (r *SKIPJobReconciler) Reconcile(ctx context.Context, req reconcile.Request
 
 	if err := common.ValidateContainerImageString(skipJob); err != nil {
 		rLog.Error(err, "invalid container image reference")
-		r.SetErrorState(ctx, skipJob, err, "invalid container image reference", "InvalidSKIPJob")
+		r.SetErrorState(ctx, skipJob, err, "invalid container image reference", commontypes.InvalidSKIPJobReason)
+		return common.DoNotRequeue()
+	}
+
+	if err := validateSKIPJobExtraContainers(skipJob); err != nil {
+		rLog.Error(err, "invalid extra containers")
+		r.SetErrorState(ctx, skipJob, err, "invalid extra containers", commontypes.InvalidSKIPJobReason)
+		return common.DoNotRequeue()
+	}
+
+	imagePolicyErrs, err := r.ValidateImagePolicy(ctx, skipJob, r.SkiperatorConfig.ImagePolicy)
+	if err != nil {
+		rLog.Error(err, "failed to evaluate image policy")
+		r.SetErrorState(ctx, skipJob, err, "failed to evaluate image policy", "ImagePolicyFailure")
+		return common.RequeueWithError(err)
+	}
+	if len(imagePolicyErrs) > 0 {
+		err := errors.NewInvalid(skipJob.GroupVersionKind().GroupKind(), skipJob.Name, imagePolicyErrs)
+		rLog.Error(err, "container images violate the image policy")
+		r.SetErrorState(ctx, skipJob, err, "container images violate the image policy", commontypes.ImagePolicyViolationReason)
+		return common.DoNotRequeue()
+	}
+
+	if errs := common.ValidatePodSettings(skipJob.Spec.PodSettings, r.SkiperatorConfig.SchedulingPolicy); len(errs) > 0 {
+		err := errors.NewInvalid(skipJob.GroupVersionKind().GroupKind(), skipJob.Name, errs)
+		rLog.Error(err, "pod settings violate the scheduling policy")
+		r.SetErrorState(ctx, skipJob, err, "pod settings violate the scheduling policy", commontypes.InvalidSKIPJobReason)
+		return common.DoNotRequeue()
+	}
+
+	if err := validateSKIPJobSecurityContexts(skipJob, r.SkiperatorConfig.SecurityContextPolicy); err != nil {
+		rLog.Error(err, "security context overrides violate the security context policy")
+		r.SetErrorState(ctx, skipJob, err, "security context overrides violate the security context policy", commontypes.InvalidSKIPJobReason)
+		return common.DoNotRequeue()
+	}
+
+	envFromKeysErrs, _, err := r.ResolveEnvFromKeys(ctx, skipJob.Namespace, skipJob.Spec.EnvFromKeys)
+	if err != nil {
+		rLog.Error(err, "failed to resolve envFromKeys")
+		r.SetErrorState(ctx, skipJob, err, "failed to resolve envFromKeys", "EnvFromKeysFailure")
+		return common.RequeueWithError(err)
+	}
+	if len(envFromKeysErrs) > 0 {
+		err := errors.NewInvalid(skipJob.GroupVersionKind().GroupKind(), skipJob.Name, envFromKeysErrs)
+		rLog.Error(err, "envFromKeys reference missing ConfigMaps, Secrets or keys")
+		r.SetErrorState(ctx, skipJob, err, "envFromKeys reference missing ConfigMaps, Secrets or keys", "EnvFromKeyNotFound")
+		// The ConfigMap or Secret may be created later
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	waitingForDependencies, dependencyErrs, err := r.resolveDependencies(ctx, skipJob)
+	if err != nil {
+		rLog.Error(err, "failed to resolve SKIPJob dependencies")
+		r.SetErrorState(ctx, skipJob, err, "failed to resolve SKIPJob dependencies", "DependencyFailure")
+		return common.RequeueWithError(err)
+	}
+	if len(dependencyErrs) > 0 {
+		err := errors.NewInvalid(skipJob.GroupVersionKind().GroupKind(), skipJob.Name, dependencyErrs)
+		rLog.Error(err, "SKIPJob dependencies have failed or form a cycle")
+		r.SetErrorState(ctx, skipJob, err, "SKIPJob dependencies have failed or form a cycle", commontypes.DependencyFailedReason)
+		// Changes to the dependencies requeue this SKIPJob
 		return common.DoNotRequeue()
 	}
 
@@ -181,12 +251,15 @@ func (r *SKIPJobReconciler) Reconcile(ctx context.Context, req reconcile.Request
 	}
 
 	reconciliationJob := reconciliation.NewJobReconciliation(ctx, skipJob, rLog, meshMode, r.GetRestConfig(), r.SkiperatorConfig)
+	reconciliationJob.SetWaitingForDependencies(len(waitingForDependencies) > 0)
 
 	resourceGeneration := []reconciliationFunc{
+		imagepolicy.Verify,
 		serviceaccount.Generate,
 		networkpolicy.Generate,
 		serviceentry.Generate,
 		auth.Generate,
+		externalsecret.Generate,
 		job.Generate,
 		prometheus.Generate,
 		telemetry.Generate,
@@ -224,6 +297,28 @@ func (r *SKIPJobReconciler) Reconcile(ctx context.Context, req reconcile.Request
 		r.SetErrorState(ctx, skipJob, fmt.Errorf("found %d errors", len(errs)), "failed to process skipjob resources, see subresource status", "ProcessorFailure")
 		return common.RequeueWithError(err)
 	}
+	// A Job with changed immutable fields has been deleted, and is created again once the deletion has finished
+	if reconciliationJob.RecreatePending() {
+		r.SetProgressingState(ctx, skipJob, "Waiting for the Job to be deleted before recreating it")
+		return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	unsyncedSecrets, err := externalsecret.Unsynced(ctx, r.GetClient(), reconciliationJob.GetResources())
+	if err != nil {
+		rLog.Error(err, "failed to check external secrets")
+		r.SetErrorState(ctx, skipJob, err, "failed to check external secrets", "ExternalSecretFailure")
+		return common.RequeueWithError(err)
+	}
+	if len(unsyncedSecrets) > 0 {
+		r.SetProgressingState(ctx, skipJob, fmt.Sprintf("Waiting for external secrets to sync: %s", strings.Join(unsyncedSecrets, ", ")))
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	if err = r.triggerManualRun(ctx, skipJob); err != nil {
+		rLog.Error(err, "failed to start manually triggered job")
+		r.SetErrorState(ctx, skipJob, err, "failed to start manually triggered job", "ManualTriggerFailure")
+		return common.RequeueWithError(err)
+	}
 
 	//TODO consider if we need better handling of status updates in context of summary, conditions and subresources
 	if err = r.updateConditions(ctx, skipJob); err != nil {
@@ -232,13 +327,57 @@ func (r *SKIPJobReconciler) Reconcile(ctx context.Context, req reconcile.Request
 		return common.RequeueWithError(err)
 	}
 
+	if len(waitingForDependencies) > 0 {
+		skipJob.GetStatus().SetReadyCondition(v1.ConditionUnknown, skipJob.GetGeneration(), "WaitingForDependencies",
+			fmt.Sprintf("Waiting for SKIPJobs to finish: %s", strings.Join(waitingForDependencies, ", ")))
+	}
+
 	r.EmitNormalEvent(skipJob, "ReconcileEndSuccess", "SKIPJob has been reconciled")
 	skipJob.GetStatus().SetSummarySynced()
-	r.UpdateStatus(ctx, skipJob)
+	r.updateSKIPJobStatus(ctx, skipJob)
 
 	return common.RequeueWithError(err)
 }
 
+// validateSKIPJobSecurityContexts checks the pod-wide and per-container security context
+// overrides against the cluster's security context policy.
+func validateSKIPJobSecurityContexts(skipJob *skiperatorv1beta1.SKIPJob, policy *config.SecurityContextPolicy) error {
+	errs := common.ValidatePodSecurityContext(skipJob.Spec.PodSettings, policy)
+	errs = append(errs, common.ValidateContainerSecurityContext(field.NewPath("spec").Child("securityContext"), skipJob.Spec.SecurityContext, policy)...)
+	for i, c := range skipJob.Spec.ExtraContainers {
+		errs = append(errs, common.ValidateContainerSecurityContext(field.NewPath("spec").Child("extraContainers").Index(i).Child("securityContext"), c.SecurityContext, policy)...)
+	}
+
+	if len(errs) > 0 {
+		return errors.NewInvalid(skipJob.GroupVersionKind().GroupKind(), skipJob.Name, errs)
+	}
+	return nil
+}
+
+// validateSKIPJobExtraContainers covers the extra-container rules that CRD CEL validation cannot express, like
+// validateExtraContainers does for Applications
+func validateSKIPJobExtraContainers(skipJob *skiperatorv1beta1.SKIPJob) error {
+	basePath := field.NewPath("spec").Child("extraContainers")
+	var errs field.ErrorList
+
+	for i, c := range skipJob.Spec.ExtraContainers {
+		path := basePath.Index(i)
+
+		if c.Name == skipJob.KindPostFixedName() {
+			errs = append(errs, field.Invalid(path.Child("name"), c.Name, "container name must not equal the name of the job container"))
+		}
+
+		if err := common.ValidateImageString(c.Image); err != nil {
+			errs = append(errs, field.Invalid(path.Child("image"), c.Image, err.Error()))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.NewInvalid(skipJob.GroupVersionKind().GroupKind(), skipJob.Name, errs)
+	}
+	return nil
+}
+
 func (r *SKIPJobReconciler) getSKIPJob(ctx context.Context, req reconcile.Request) (*skiperatorv1beta1.SKIPJob, error) {
 	skipJob := &skiperatorv1beta1.SKIPJob{}
 	if err := r.GetClient().Get(ctx, req.NamespacedName, skipJob); err != nil {
@@ -251,6 +390,27 @@ func (r *SKIPJobReconciler) getSKIPJob(ctx context.Context, req reconcile.Reques
 	return skipJob, nil
 }
 
+// updateSKIPJobStatus writes the full SKIPJob status, including the SKIPJob specific fields that UpdateStatus leaves out
+func (r *SKIPJobReconciler) updateSKIPJobStatus(ctx context.Context, skipJob *skiperatorv1beta1.SKIPJob) {
+	key := client.ObjectKeyFromObject(skipJob)
+	skipJob.GetStatus().SetKStatusConditions(skipJob.GetGeneration())
+	skipJob.GetStatus().SortConditions()
+	desiredStatus := skipJob.Status.DeepCopy()
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latestSkipJob := &skiperatorv1beta1.SKIPJob{}
+		if err := r.GetClient().Get(ctx, key, latestSkipJob); err != nil {
+			return err
+		}
+		latestSkipJob.Status = *desiredStatus
+		return r.GetClient().Status().Update(ctx, latestSkipJob)
+	})
+
+	if err != nil {
+		r.Logger.Error(err, "failed to update status", "name", key.Name, "namespace", key.Namespace, "kind", "SKIPJob")
+	}
+}
+
 func (r *SKIPJobReconciler) teamNameForNamespace(ctx context.Context, skipJob *skiperatorv1beta1.SKIPJob) (string, error) {
 	ns := &corev1.Namespace{}
 	if err := r.GetClient().Get(ctx, types.NamespacedName{Name: skipJob.Namespace}, ns); err != nil {
@@ -313,6 +473,36 @@ func (r *SKIPJobReconciler) getJobsToReconcile(ctx context.Context, object clien
 	return reconcileRequests
 }
 
+// triggerManualRun starts a Job from the CronJob of a scheduled SKIPJob when the trigger annotation has a value
+// that is not recorded in the status yet. The trigger annotation is ignored for one-off jobs.
+func (r *SKIPJobReconciler) triggerManualRun(ctx context.Context, skipJob *skiperatorv1beta1.SKIPJob) error {
+	trigger := skipJob.Annotations[skiperatorv1beta1.TriggerAnnotationKey]
+	if trigger == "" || skipJob.Spec.Cron == nil {
+		return nil
+	}
+	if skipJob.Status.ManualTrigger != nil && skipJob.Status.ManualTrigger.Trigger == trigger {
+		return nil
+	}
+
+	cronJob := &batchv1.CronJob{}
+	if err := r.GetClient().Get(ctx, client.ObjectKeyFromObject(skipJob), cronJob); err != nil {
+		return fmt.Errorf("failed to get cronjob: %w", err)
+	}
+
+	manualJob := job.FromCronJob(cronJob, trigger)
+	if err := r.GetClient().Create(ctx, manualJob); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	r.EmitNormalEvent(skipJob, "ManualTrigger", fmt.Sprintf("Started job %s for trigger %s", manualJob.Name, trigger))
+	skipJob.Status.ManualTrigger = &skiperatorv1beta1.ManualTriggerStatus{
+		Trigger:     trigger,
+		JobName:     manualJob.Name,
+		TriggeredAt: v1.Now(),
+	}
+	return nil
+}
+
 func (r *SKIPJobReconciler) getConditionRunning(skipJob *skiperatorv1beta1.SKIPJob, status v1.ConditionStatus) v1.Condition {
 	return v1.Condition{
 		Type:               ConditionRunning,
@@ -345,7 +535,7 @@ func (r *SKIPJobReconciler) getConditionFailed(skipJob *skiperatorv1beta1.SKIPJo
 		Status:             status,
 		ObservedGeneration: skipJob.Generation,
 		LastTransitionTime: v1.Now(),
-		Reason:             "JobFailed",
+		Reason:             commontypes.JobFailedReason,
 		Message:            conditionMessage,
 	}
 }
@@ -379,17 +569,31 @@ func (r *SKIPJobReconciler) updateConditions(ctx context.Context, skipJob *skipe
 		}
 	}
 
+	// Per-index progress of indexed jobs, failed indexes are only tracked by Kubernetes with backoffLimitPerIndex
+	skipJob.Status.CompletedIndexes, skipJob.Status.FailedIndexes = "", ""
+	if lastJob.Spec.CompletionMode != nil && *lastJob.Spec.CompletionMode == batchv1.IndexedCompletion {
+		skipJob.Status.CompletedIndexes = lastJob.Status.CompletedIndexes
+		if lastJob.Status.FailedIndexes != nil {
+			skipJob.Status.FailedIndexes = *lastJob.Status.FailedIndexes
+		}
+	}
+
 	if len(jobList.Items) == 0 {
 		skipJob.Status.Conditions = []v1.Condition{
-			r.getConditionReady(skipJob, v1.ConditionUnknown, "JobPending", "Job has not started yet"),
+			r.getConditionReady(skipJob, v1.ConditionUnknown, commontypes.JobPendingReason, "Job has not started yet"),
 			r.getConditionFailed(skipJob, v1.ConditionFalse, nil),
 			r.getConditionRunning(skipJob, v1.ConditionFalse),
 			r.getConditionFinished(skipJob, v1.ConditionFalse),
 		}
-	} else if isFailed, failedJobMessage := isFailedJob(lastJob); isFailed {
+	} else if isFailed, failedJobReason, failedJobMessage := isFailedJob(lastJob); isFailed {
+		// The reason tells why the Job failed, e.g. PodFailurePolicy, and the message which rule was matched
+		failedCondition := r.getConditionFailed(skipJob, v1.ConditionTrue, &failedJobMessage)
+		if failedJobReason != "" {
+			failedCondition.Reason = failedJobReason
+		}
 		skipJob.Status.Conditions = []v1.Condition{
-			r.getConditionReady(skipJob, v1.ConditionFalse, "JobFailed", failedJobMessage),
-			r.getConditionFailed(skipJob, v1.ConditionTrue, &failedJobMessage),
+			r.getConditionReady(skipJob, v1.ConditionFalse, commontypes.JobFailedReason, failedJobMessage),
+			failedCondition,
 			r.getConditionRunning(skipJob, v1.ConditionFalse),
 			r.getConditionFinished(skipJob, v1.ConditionFalse),
 		}
@@ -400,6 +604,13 @@ func (r *SKIPJobReconciler) updateConditions(ctx context.Context, skipJob *skipe
 			r.getConditionRunning(skipJob, v1.ConditionFalse),
 			r.getConditionFinished(skipJob, v1.ConditionTrue),
 		}
+	} else if lastJob.Spec.Suspend != nil && *lastJob.Spec.Suspend {
+		skipJob.Status.Conditions = []v1.Condition{
+			r.getConditionReady(skipJob, v1.ConditionUnknown, commontypes.JobSuspendedReason, "Job is suspended"),
+			r.getConditionFailed(skipJob, v1.ConditionFalse, nil),
+			r.getConditionRunning(skipJob, v1.ConditionFalse),
+			r.getConditionFinished(skipJob, v1.ConditionFalse),
+		}
 	} else {
 		skipJob.Status.Conditions = []v1.Condition{
 			r.getConditionReady(skipJob, v1.ConditionUnknown, "JobRunning", "Job is running"),
@@ -413,11 +624,11 @@ func (r *SKIPJobReconciler) updateConditions(ctx context.Context, skipJob *skipe
 	accessPolicy := skipJob.Spec.AccessPolicy
 
 	if accessPolicy != nil && !common.IsInternalRulesValid(accessPolicy) {
-		skipJob.GetStatus().SetReadyCondition(v1.ConditionFalse, skipJob.GetGeneration(), "InvalidConfig", "Internal rules are invalid")
+		skipJob.GetStatus().SetReadyCondition(v1.ConditionFalse, skipJob.GetGeneration(), commontypes.InvalidConfigReason, "Internal rules are invalid")
 		skipJob.Status.Conditions = append(skipJob.Status.Conditions, common.GetInternalRulesCondition(skipJob, v1.ConditionFalse))
 		skipJob.Status.AccessPolicies = skiperatorv1beta1.INVALIDCONFIG
 	} else if accessPolicy != nil && !common.IsExternalRulesValid(accessPolicy) {
-		skipJob.GetStatus().SetReadyCondition(v1.ConditionFalse, skipJob.GetGeneration(), "InvalidConfig", "External rules are invalid")
+		skipJob.GetStatus().SetReadyCondition(v1.ConditionFalse, skipJob.GetGeneration(), commontypes.InvalidConfigReason, "External rules are invalid")
 		skipJob.Status.Conditions = append(skipJob.Status.Conditions, common.GetExternalRulesCondition(skipJob, v1.ConditionFalse))
 		skipJob.Status.AccessPolicies = skiperatorv1beta1.INVALIDCONFIG
 	} else {
@@ -430,11 +641,11 @@ func (r *SKIPJobReconciler) updateConditions(ctx context.Context, skipJob *skipe
 }
 
 // think it can be done easier
-func isFailedJob(job *batchv1.Job) (bool, string) {
+func isFailedJob(job *batchv1.Job) (bool, string, string) {
 	for _, condition := range job.Status.Conditions {
 		if condition.Type == ConditionFailed && condition.Status == corev1.ConditionTrue {
-			return true, condition.Message
+			return true, condition.Reason, condition.Message
 		}
 	}
-	return false, ""
+	return false, "", ""
 }
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// resolveDependencies returns the dependencies of the SKIPJob that have not finished yet. Failed dependencies and
+// dependency cycles are returned as field errors. Once the Job has started, the dependencies are not checked again.
+func (r *SKIPJobReconciler) resolveDependencies(ctx context.Context, skipJob *skiperatorv1beta1.SKIPJob) ([]string, field.ErrorList, error) {
+	if len(skipJob.Spec.DependsOn) == 0 {
+		return nil, nil, nil
+	}
+
+	job := batchv1.Job{}
+	err := r.GetClient().Get(ctx, client.ObjectKeyFromObject(skipJob), &job)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	if err == nil && (job.Status.StartTime != nil || job.Status.CompletionTime != nil) {
+		return nil, nil, nil
+	}
+
+	skipJobs := skiperatorv1beta1.SKIPJobList{}
+	if err := r.GetClient().List(ctx, &skipJobs, client.InNamespace(skipJob.Namespace)); err != nil {
+		return nil, nil, err
+	}
+	byName := make(map[string]*skiperatorv1beta1.SKIPJob, len(skipJobs.Items))
+	dependencyGraph := make(map[string][]string, len(skipJobs.Items))
+	for i := range skipJobs.Items {
+		byName[skipJobs.Items[i].Name] = &skipJobs.Items[i]
+		dependencyGraph[skipJobs.Items[i].Name] = skipJobs.Items[i].Spec.DependsOn
+	}
+	dependencyGraph[skipJob.Name] = skipJob.Spec.DependsOn
+
+	path := field.NewPath("spec", "dependsOn")
+	if cycle := findDependencyCycle(skipJob.Name, dependencyGraph); cycle != nil {
+		return nil, field.ErrorList{field.Invalid(path, strings.Join(cycle, " -> "), "dependencies form a cycle")}, nil
+	}
+
+	var waitingFor []string
+	var errs field.ErrorList
+	for i, name := range skipJob.Spec.DependsOn {
+		dependency, exists := byName[name]
+		switch {
+		case !exists:
+			waitingFor = append(waitingFor, name)
+		case meta.IsStatusConditionTrue(dependency.Status.Conditions, ConditionFailed):
+			errs = append(errs, field.Invalid(path.Index(i), name, "SKIPJob has failed"))
+		case !meta.IsStatusConditionTrue(dependency.Status.Conditions, ConditionFinished):
+			waitingFor = append(waitingFor, name)
+		}
+	}
+
+	return waitingFor, errs, nil
+}
+
+// findDependencyCycle returns the path of a dependency cycle that the SKIPJob is part of, e.g. [a b a],
+// or nil if there is none
+func findDependencyCycle(name string, dependencyGraph map[string][]string) []string {
+	visited := make(map[string]bool)
+	var visit func(path []string) []string
+	visit = func(path []string) []string {
+		for _, dependency := range dependencyGraph[path[len(path)-1]] {
+			if dependency == name {
+				return append(slices.Clone(path), dependency)
+			}
+			if visited[dependency] {
+				continue
+			}
+			visited[dependency] = true
+			if cycle := visit(append(path, dependency)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return visit([]string{name})
+}
+
+// getDependentSKIPJobs enqueues the SKIPJobs in the namespace that depend on the changed SKIPJob, so they are
+// started when it finishes, or report that it has failed
+func (r *SKIPJobReconciler) getDependentSKIPJobs(ctx context.Context, object client.Object) []reconcile.Request {
+	skipJobs := skiperatorv1beta1.SKIPJobList{}
+	if err := r.GetClient().List(ctx, &skipJobs, client.InNamespace(object.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, skipJob := range skipJobs.Items {
+		if slices.Contains(skipJob.Spec.DependsOn, object.GetName()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: skipJob.Namespace, Name: skipJob.Name},
+			})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kartverket/skiperator/api/common/podtypes"
+	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
+	"github.com/kartverket/skiperator/internal/config"
+	controllercommon "github.com/kartverket/skiperator/internal/controllers/common"
+	"github.com/kartverket/skiperator/pkg/resourceschemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIsFailedJob(t *testing.T) {
+	job := &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+		{Type: batchv1.JobFailureTarget, Status: corev1.ConditionTrue, Reason: "PodFailurePolicy"},
+		{
+			Type:    batchv1.JobFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "PodFailurePolicy",
+			Message: "Container main for pod test/job-abc failed with exit code 42 matching FailJob rule at index 0",
+		},
+	}}}
+
+	isFailed, reason, message := isFailedJob(job)
+
+	assert.True(t, isFailed)
+	assert.Equal(t, "PodFailurePolicy", reason)
+	assert.Contains(t, message, "FailJob rule at index 0")
+
+	isFailed, _, _ = isFailedJob(&batchv1.Job{})
+	assert.False(t, isFailed)
+}
+
+func TestValidateSKIPJobExtraContainers(t *testing.T) {
+	skipJob := &skiperatorv1beta1.SKIPJob{
+		TypeMeta:   metav1.TypeMeta{Kind: "SKIPJob"},
+		ObjectMeta: metav1.ObjectMeta{Name: "seed", Namespace: "test"},
+		Spec: skiperatorv1beta1.SKIPJobSpec{ExtraContainers: []podtypes.ContainerSpec{
+			{Name: "postgres", Image: "postgres:17"},
+		}},
+	}
+	assert.NoError(t, validateSKIPJobExtraContainers(skipJob))
+
+	skipJob.Spec.ExtraContainers = append(skipJob.Spec.ExtraContainers,
+		podtypes.ContainerSpec{Name: "seed-skipjob", Image: "fixtures:1.0"},
+		podtypes.ContainerSpec{Name: "proxy", Image: "not a valid image"},
+	)
+	err := validateSKIPJobExtraContainers(skipJob)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.extraContainers[1].name")
+	assert.Contains(t, err.Error(), "spec.extraContainers[2].image")
+}
+
+func TestValidateSKIPJobSecurityContexts(t *testing.T) {
+	policy := &config.SecurityContextPolicy{AllowedIDRanges: []config.IDRange{{Min: 1000, Max: 1999}}}
+	skipJob := &skiperatorv1beta1.SKIPJob{
+		TypeMeta:   metav1.TypeMeta{Kind: "SKIPJob"},
+		ObjectMeta: metav1.ObjectMeta{Name: "seed", Namespace: "test"},
+		Spec: skiperatorv1beta1.SKIPJobSpec{
+			SecurityContext: &podtypes.ContainerSecurityContext{RunAsUser: new(int64(1500))},
+		},
+	}
+	assert.NoError(t, validateSKIPJobSecurityContexts(skipJob, policy))
+
+	skipJob.Spec.SecurityContext.AddCapabilities = []corev1.Capability{"SYS_ADMIN"}
+	skipJob.Spec.SecurityContext.RunAsUser = new(int64(0))
+	err := validateSKIPJobSecurityContexts(skipJob, policy)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.securityContext.runAsUser")
+	assert.Contains(t, err.Error(), "spec.securityContext.addCapabilities[0]")
+}
+
+func TestFindDependencyCycle(t *testing.T) {
+	graph := map[string][]string{
+		"extract":   nil,
+		"transform": {"extract"},
+		"load":      {"transform", "extract"},
+		"a":         {"b"},
+		"b":         {"c"},
+		"c":         {"a"},
+		"self":      {"self"},
+	}
+
+	assert.Nil(t, findDependencyCycle("load", graph))
+	assert.Equal(t, []string{"a", "b", "c", "a"}, findDependencyCycle("a", graph))
+	assert.Equal(t, []string{"self", "self"}, findDependencyCycle("self", graph))
+}
+
+func TestResolveDependencies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	newSKIPJob := func(name string, conditionType string, dependsOn ...string) *skiperatorv1beta1.SKIPJob {
+		skipJob := &skiperatorv1beta1.SKIPJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a"},
+			Spec:       skiperatorv1beta1.SKIPJobSpec{DependsOn: dependsOn},
+		}
+		if conditionType != "" {
+			skipJob.Status.Conditions = []metav1.Condition{{Type: conditionType, Status: metav1.ConditionTrue}}
+		}
+		return skipJob
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newSKIPJob("extract", ConditionFinished),
+		newSKIPJob("transform", ConditionRunning, "extract"),
+		newSKIPJob("broken", ConditionFailed),
+	).Build()
+	reconciler := &SKIPJobReconciler{ReconcilerBase: controllercommon.NewReconcilerBase(client, nil, scheme, nil, nil)}
+
+	waitingFor, errs, err := reconciler.resolveDependencies(context.Background(), newSKIPJob("load", "", "extract", "transform", "not-created"))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.Equal(t, []string{"transform", "not-created"}, waitingFor)
+
+	_, errs, err = reconciler.resolveDependencies(context.Background(), newSKIPJob("load", "", "extract", "broken"))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "spec.dependsOn[1]", errs[0].Field)
+}
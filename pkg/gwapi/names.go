@@ -38,6 +38,12 @@ func ListenerSetName(prefix string, hostname string) string {
 	return fmt.Sprintf("%s-listener-%x", prefix, util.GenerateHashFromName(hostname))
 }
 
+// TLSPassthroughName returns the base name of the ListenerSet and TLSRoute
+// exposing one TLS passthrough port.
+func TLSPassthroughName(prefix string, port int32) string {
+	return fmt.Sprintf("%s-tls-%d", prefix, port)
+}
+
 // SharedListenerSetName returns generated shared ListenerSet name for hostname.
 func SharedListenerSetName(hostname string) string {
 	return ListenerSetName("shared", hostname)
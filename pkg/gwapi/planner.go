@@ -48,11 +48,23 @@ func (p applicationPlanner) readinessPlan() (readinessPlan, error) {
 		namespace:       p.Namespace,
 		routeBaseName:   p.Name,
 		redirectToHTTPS: p.Spec.RedirectToHTTPS != nil && *p.Spec.RedirectToHTTPS,
+		grpc:            p.UsesGRPC(),
+		tlsPassthroughs: applicationTLSPassthroughs(p.Application),
 		hosts:           hosts,
 		certificateName: p.GetCertificateName,
 	})
 }
 
+// applicationTLSPassthroughs lists the TCP ingresses standard routing exposes,
+// which are always TLS passthrough.
+func applicationTLSPassthroughs(application *skiperatorv1alpha1.Application) []tlsPassthrough {
+	passthroughs := make([]tlsPassthrough, 0, len(application.Spec.TCPIngresses))
+	for _, ingress := range application.Spec.TCPIngresses {
+		passthroughs = append(passthroughs, tlsPassthrough{hostname: ingress.Hostname, port: ingress.Port})
+	}
+	return passthroughs
+}
+
 func (p applicationPlanner) validateConflicts(ctx context.Context, c client.Client) error {
 	return validateApplicationConflicts(ctx, c, p.Application)
 }
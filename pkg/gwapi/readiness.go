@@ -32,16 +32,28 @@ type standardHost struct {
 	ListenerSetName string
 }
 
+// routeCheck names one route to probe. Kind is the Gateway API route kind,
+// HTTPRoute when empty.
 type routeCheck struct {
+	Kind      string
 	Namespace string
 	Name      string
 }
 
+// tlsPassthrough is one TLS passthrough port of a routable. It has its own
+// ListenerSet and TLSRoute, and no certificate.
+type tlsPassthrough struct {
+	hostname string
+	port     int32
+}
+
 // planInput is the per-kind data a planner supplies to build a readinessPlan.
 type planInput struct {
 	namespace       string
 	routeBaseName   string
 	redirectToHTTPS bool
+	grpc            bool
+	tlsPassthroughs []tlsPassthrough
 	hosts           common.HostCollection
 	certificateName func(*common.Host) (string, error)
 	sharedRouting   bool
@@ -51,8 +63,9 @@ type planInput struct {
 // It is produced purely (no cluster I/O) by buildReadinessPlan and consumed by
 // observeReadiness, which performs the actual reads.
 type readinessPlan struct {
-	hosts  []standardHost
-	routes []routeCheck
+	hosts        []standardHost
+	listenerSets []routeCheck
+	routes       []routeCheck
 }
 
 // legacyRoutingExists reports whether legacy Istio routing resources are
@@ -108,16 +121,24 @@ func legacyResourceExists(ctx context.Context, c client.Client, key types.Namesp
 // "VirtualService and Gateway exist" with the set of certificates, ListenerSets,
 // and HTTPRoutes that must be ready before legacy routing can be pruned.
 //
-// An empty hosts collection yields an empty plan; observeStandardRouting reads
-// that as "no Gateway API hosts" and reports ready.
+// An empty hosts collection without TLS passthrough ports yields an empty plan;
+// observeStandardRouting reads that as "no Gateway API hosts" and reports ready.
 func buildReadinessPlan(in planInput) (readinessPlan, error) {
+	plan := readinessPlan{}
+	for _, passthrough := range in.tlsPassthroughs {
+		name := TLSPassthroughName(in.routeBaseName, passthrough.port)
+		plan.listenerSets = append(plan.listenerSets, routeCheck{Namespace: in.namespace, Name: ListenerSetName(name, passthrough.hostname)})
+		plan.routes = append(plan.routes, routeCheck{Kind: "TLSRoute", Namespace: in.namespace, Name: name})
+	}
 	if in.hosts.Count() == 0 {
-		return readinessPlan{}, nil
+		return plan, nil
 	}
 
-	plan := readinessPlan{
-		routes: []routeCheck{{Namespace: in.namespace, Name: in.routeBaseName}},
+	backendRoute := routeCheck{Namespace: in.namespace, Name: in.routeBaseName}
+	if in.grpc {
+		backendRoute.Kind = "GRPCRoute"
 	}
+	plan.routes = append(plan.routes, backendRoute)
 	if in.redirectToHTTPS {
 		redirectRoute := routeCheck{Namespace: in.namespace, Name: RedirectRouteName(in.routeBaseName)}
 		if in.sharedRouting {
@@ -159,7 +180,7 @@ func observeStandardRouting(ctx context.Context, c client.Client, planner routab
 	if err != nil {
 		return Readiness{Message: err.Error()}
 	}
-	if len(plan.hosts) == 0 {
+	if len(plan.hosts) == 0 && len(plan.listenerSets) == 0 {
 		return Readiness{Ready: true, Message: "object has no Gateway API hosts"}
 	}
 	return observeReadiness(ctx, c, plan)
@@ -188,8 +209,13 @@ func observeReadiness(ctx context.Context, c client.Client, plan readinessPlan)
 			return ready
 		}
 	}
+	for _, listenerSet := range plan.listenerSets {
+		if ready := listenerSetReady(ctx, c, listenerSet.Namespace, listenerSet.Name); !ready.Ready {
+			return ready
+		}
+	}
 	for _, route := range plan.routes {
-		if ready := httpRouteReady(ctx, c, route.Namespace, route.Name); !ready.Ready {
+		if ready := routeReady(ctx, c, route); !ready.Ready {
 			return ready
 		}
 	}
@@ -288,23 +314,44 @@ func parentGatewayReady(ctx context.Context, c client.Client, listenerSet *gatew
 	return Readiness{Ready: true}
 }
 
-func httpRouteReady(ctx context.Context, c client.Client, namespace string, name string) Readiness {
-	route := &gatewayapiv1.HTTPRoute{}
-	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, route); err != nil {
+// routeReady fetches the route of the checked kind and probes its status.
+func routeReady(ctx context.Context, c client.Client, check routeCheck) Readiness {
+	kind := check.Kind
+	var route client.Object
+	var status func() gatewayapiv1.RouteStatus
+	switch kind {
+	case "GRPCRoute":
+		grpcRoute := &gatewayapiv1.GRPCRoute{}
+		route, status = grpcRoute, func() gatewayapiv1.RouteStatus { return grpcRoute.Status.RouteStatus }
+	case "TLSRoute":
+		tlsRoute := &gatewayapiv1.TLSRoute{}
+		route, status = tlsRoute, func() gatewayapiv1.RouteStatus { return tlsRoute.Status.RouteStatus }
+	default:
+		kind = "HTTPRoute"
+		httpRoute := &gatewayapiv1.HTTPRoute{}
+		route, status = httpRoute, func() gatewayapiv1.RouteStatus { return httpRoute.Status.RouteStatus }
+	}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: check.Namespace, Name: check.Name}, route); err != nil {
 		if apierrors.IsNotFound(err) {
-			return Readiness{Message: fmt.Sprintf("waiting for HTTPRoute %s/%s", namespace, name)}
+			return Readiness{Message: fmt.Sprintf("waiting for %s %s/%s", kind, check.Namespace, check.Name)}
 		}
 		return Readiness{Message: err.Error()}
 	}
-	if len(route.Status.Parents) == 0 {
-		return Readiness{Message: fmt.Sprintf("waiting for HTTPRoute %s/%s parent status", namespace, name)}
+	return routeStatusReady(kind, check.Namespace, check.Name, status())
+}
+
+// routeStatusReady checks that every parent accepted the route and resolved its
+// backend references. The status shape is shared by all Gateway API route kinds.
+func routeStatusReady(kind string, namespace string, name string, status gatewayapiv1.RouteStatus) Readiness {
+	if len(status.Parents) == 0 {
+		return Readiness{Message: fmt.Sprintf("waiting for %s %s/%s parent status", kind, namespace, name)}
 	}
-	for _, parent := range route.Status.Parents {
+	for _, parent := range status.Parents {
 		if !meta.IsStatusConditionTrue(parent.Conditions, string(gatewayapiv1.RouteConditionAccepted)) {
-			return Readiness{Message: fmt.Sprintf("waiting for HTTPRoute %s/%s Accepted=True", namespace, name)}
+			return Readiness{Message: fmt.Sprintf("waiting for %s %s/%s Accepted=True", kind, namespace, name)}
 		}
 		if !meta.IsStatusConditionTrue(parent.Conditions, string(gatewayapiv1.RouteConditionResolvedRefs)) {
-			return Readiness{Message: fmt.Sprintf("waiting for HTTPRoute %s/%s ResolvedRefs=True", namespace, name)}
+			return Readiness{Message: fmt.Sprintf("waiting for %s %s/%s ResolvedRefs=True", kind, namespace, name)}
 		}
 	}
 	return Readiness{Ready: true}
@@ -165,6 +165,67 @@ func TestApplicationStandardRoutingPrunesLegacyWhenReady(t *testing.T) {
 	assert.True(t, state.Readiness.Ready)
 }
 
+func TestApplicationStandardRoutingGRPCWaitsForGRPCRoute(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	application := gatewayAPIApplication()
+	application.Spec.AppProtocol = "grpc"
+	certificateName, err := application.GetCertificateName(mustHost(t, "app.example.com"))
+	require.NoError(t, err)
+	objects := []client.Object{
+		application,
+		readyGateway(IstioGatewayNamespace, ExternalGatewayName),
+		readyCertificate("team-a", certificateName),
+		tlsSecret("team-a", certificateName),
+		readyListenerSet("team-a", ListenerSetName("app", "app.example.com")),
+		readyHTTPRoute("team-a", "app"),
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+
+	state, err := EvaluateRoutingState(context.Background(), c, application, application.GetStatus())
+	require.NoError(t, err)
+	assert.False(t, state.Readiness.Ready)
+	assert.Contains(t, state.Readiness.Message, "waiting for GRPCRoute team-a/app")
+
+	c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, readyGRPCRoute("team-a", "app"))...).Build()
+
+	state, err = EvaluateRoutingState(context.Background(), c, application, application.GetStatus())
+	require.NoError(t, err)
+	assert.True(t, state.Readiness.Ready)
+}
+
+func TestApplicationStandardRoutingTLSPassthroughWaitsForTLSRoute(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	application := gatewayAPIApplication()
+	application.Spec.Ingresses = nil
+	application.Spec.TCPIngresses = []skiperatorv1alpha1.TCPIngress{{Hostname: "db.example.com", Port: 5432}}
+	objects := []client.Object{
+		application,
+		&istionetworkingv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: application.GetTCPGatewayName(application.Spec.TCPIngresses[0]), Namespace: "team-a"}},
+		readyGateway(IstioGatewayNamespace, ExternalGatewayName),
+		readyListenerSet("team-a", ListenerSetName("app-tls-5432", "db.example.com")),
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+
+	state, err := EvaluateRoutingState(context.Background(), c, application, application.GetStatus())
+	require.NoError(t, err)
+	assert.True(t, state.GenerateLegacyRouting)
+	assert.False(t, state.Readiness.Ready)
+	assert.Contains(t, state.Readiness.Message, "waiting for TLSRoute team-a/app-tls-5432")
+
+	tlsRoute := &gatewayapiv1.TLSRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-tls-5432", Namespace: "team-a"},
+		Status:     gatewayapiv1.TLSRouteStatus{RouteStatus: readyHTTPRoute("team-a", "app-tls-5432").Status.RouteStatus},
+	}
+	c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, tlsRoute)...).Build()
+
+	state, err = EvaluateRoutingState(context.Background(), c, application, application.GetStatus())
+	require.NoError(t, err)
+	assert.False(t, state.GenerateLegacyRouting)
+	assert.True(t, state.Readiness.Ready)
+}
+
 func TestApplicationStandardRoutingGreenfieldSkipsLegacy(t *testing.T) {
 	scheme := runtime.NewScheme()
 	resourceschemas.AddSchemas(scheme)
@@ -624,3 +685,12 @@ func readyHTTPRoute(namespace string, name string) *gatewayapiv1.HTTPRoute {
 		},
 	}
 }
+
+func readyGRPCRoute(namespace string, name string) *gatewayapiv1.GRPCRoute {
+	return &gatewayapiv1.GRPCRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Status: gatewayapiv1.GRPCRouteStatus{
+			RouteStatus: readyHTTPRoute(namespace, name).Status.RouteStatus,
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package imagepolicy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/kartverket/skiperator/api/common/podtypes"
+	"github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/api/v1beta1"
+	"github.com/kartverket/skiperator/internal/config"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/pod"
+	"github.com/kartverket/skiperator/pkg/util"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const latestTag = "latest"
+
+type containerImage struct {
+	path  *field.Path
+	image string
+}
+
+// containerImages returns every image run by the object, with the field it comes from.
+func containerImages(obj v1alpha1.SKIPObject) []containerImage {
+	spec := field.NewPath("spec")
+	commonSpec := obj.GetCommonSpec()
+	result := []containerImage{{path: spec.Child("image"), image: commonSpec.Image}}
+
+	var extraContainers []podtypes.ContainerSpec
+	switch o := obj.(type) {
+	case *v1alpha1.Application:
+		extraContainers = o.Spec.ExtraContainers
+		if o.Spec.PreDeploy != nil && o.Spec.PreDeploy.Image != "" {
+			result = append(result, containerImage{path: spec.Child("preDeploy", "image"), image: o.Spec.PreDeploy.Image})
+		}
+	case *v1beta1.SKIPJob:
+		extraContainers = o.Spec.ExtraContainers
+	}
+	for i, container := range extraContainers {
+		result = append(result, containerImage{path: spec.Child("extraContainers").Index(i).Child("image"), image: container.Image})
+	}
+
+	if util.IsCloudSqlProxyEnabled(commonSpec.GCP) {
+		result = append(result, containerImage{
+			path:  spec.Child("gcp", "cloudSqlProxy", "version"),
+			image: pod.CloudSQLProxyImage(commonSpec.GCP.CloudSQLProxy),
+		})
+	}
+
+	return result
+}
+
+// Validate checks the images of obj against the cluster's image policy. Digest pinning is
+// only required in namespaces whose labels match policy.RequireDigestNamespace.
+func Validate(obj v1alpha1.SKIPObject, policy *config.ImagePolicy, namespaceLabels map[string]string) (field.ErrorList, error) {
+	if policy == nil {
+		return nil, nil
+	}
+
+	requireDigest := false
+	if policy.RequireDigestNamespace != "" {
+		selector, err := labels.Parse(policy.RequireDigestNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("invalid requireDigestNamespace selector: %w", err)
+		}
+		requireDigest = selector.Matches(labels.Set(namespaceLabels))
+	}
+
+	allowedRegistries := make([]repositoryPrefix, 0, len(policy.AllowedRegistries))
+	for _, registry := range policy.AllowedRegistries {
+		prefix, err := parseRepositoryPrefix(registry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowedRegistries: %w", err)
+		}
+		allowedRegistries = append(allowedRegistries, prefix)
+	}
+
+	var errs field.ErrorList
+	for _, c := range containerImages(obj) {
+		ref, err := name.ParseReference(c.image)
+		if err != nil {
+			errs = append(errs, field.Invalid(c.path, c.image, err.Error()))
+			continue
+		}
+
+		if len(allowedRegistries) > 0 && !slices.ContainsFunc(allowedRegistries, func(prefix repositoryPrefix) bool {
+			return prefix.matches(ref.Context())
+		}) {
+			errs = append(errs, field.Forbidden(c.path, fmt.Sprintf("image %q is not from an allowed registry (%s)", c.image, strings.Join(policy.AllowedRegistries, ", "))))
+		}
+
+		tag, isTag := ref.(name.Tag)
+		if !isTag {
+			continue
+		}
+		// Images without a tag are parsed as latest
+		if policy.ForbidLatestTag && tag.TagStr() == latestTag {
+			errs = append(errs, field.Forbidden(c.path, fmt.Sprintf("image %q must use a tag other than latest", c.image)))
+		}
+		if requireDigest {
+			errs = append(errs, field.Forbidden(c.path, fmt.Sprintf("image %q must be pinned by digest in this namespace", c.image)))
+		}
+	}
+
+	return errs, nil
+}
@@ -0,0 +1,145 @@
+package imagepolicy
+
+import (
+	"testing"
+
+	"github.com/kartverket/skiperator/api/common/podtypes"
+	"github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/api/v1beta1"
+	"github.com/kartverket/skiperator/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const fakeDigest = "0000000000000000000000000000000000000000000000000000000000000000"
+
+func application(image string, extraImages ...string) *v1alpha1.Application {
+	app := &v1alpha1.Application{
+		Spec: v1alpha1.ApplicationSpec{
+			Image:         image,
+			IstioSettings: &v1alpha1.IstioSettingsApplication{},
+		},
+	}
+	for _, extraImage := range extraImages {
+		app.Spec.ExtraContainers = append(app.Spec.ExtraContainers, podtypes.ContainerSpec{Name: "extra", Image: extraImage})
+	}
+	return app
+}
+
+func errorFields(t *testing.T, obj v1alpha1.SKIPObject, policy *config.ImagePolicy, namespaceLabels map[string]string) []string {
+	errs, err := Validate(obj, policy, namespaceLabels)
+	assert.NoError(t, err)
+	fields := make([]string, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+func TestValidate_NilPolicy(t *testing.T) {
+	errs, err := Validate(application("nginx"), nil, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestValidate_AllowedRegistries(t *testing.T) {
+	policy := &config.ImagePolicy{AllowedRegistries: []string{"ghcr.io/kartverket/", "gcr.io/cloud-sql-connectors/"}}
+
+	assert.Empty(t, errorFields(t, application("ghcr.io/kartverket/app:v1"), policy, nil))
+	assert.Equal(t,
+		[]string{"spec.image", "spec.extraContainers[1].image"},
+		errorFields(t, application("docker.io/library/nginx:1.27", "ghcr.io/kartverket/proxy:v1", "quay.io/other/sidecar:v1"), policy, nil),
+	)
+}
+
+func TestValidate_AllowedRegistriesMatchPathSegments(t *testing.T) {
+	policy := &config.ImagePolicy{AllowedRegistries: []string{"ghcr.io/kartverket"}}
+
+	assert.Empty(t, errorFields(t, application("ghcr.io/kartverket/app:v1"), policy, nil))
+	assert.Empty(t, errorFields(t, application("ghcr.io/kartverket/team/app:v1"), policy, nil))
+	assert.Equal(t, []string{"spec.image"}, errorFields(t, application("ghcr.io/kartverket-evil/app:v1"), policy, nil))
+	assert.Equal(t, []string{"spec.image"}, errorFields(t, application("ghcr.io.evil.com/kartverket/app:v1"), policy, nil))
+
+	// A registry allows all of its repositories
+	policy = &config.ImagePolicy{AllowedRegistries: []string{"ghcr.io"}}
+	assert.Empty(t, errorFields(t, application("ghcr.io/kartverket/app:v1"), policy, nil))
+	assert.Equal(t, []string{"spec.image"}, errorFields(t, application("quay.io/kartverket/app:v1"), policy, nil))
+}
+
+func TestValidate_AllowedRegistriesDockerHub(t *testing.T) {
+	// Docker Hub images are named index.docker.io/..., and images without a registry come from Docker Hub
+	policy := &config.ImagePolicy{AllowedRegistries: []string{"docker.io/library/", "docker.io/bitnami"}}
+
+	assert.Empty(t, errorFields(t, application("docker.io/library/nginx:1.27"), policy, nil))
+	assert.Empty(t, errorFields(t, application("nginx:1.27"), policy, nil))
+	assert.Empty(t, errorFields(t, application("bitnami/redis:7"), policy, nil))
+	assert.Equal(t, []string{"spec.image"}, errorFields(t, application("docker.io/other/redis:7"), policy, nil))
+
+	policy = &config.ImagePolicy{AllowedRegistries: []string{"docker.io"}}
+	assert.Empty(t, errorFields(t, application("nginx:1.27"), policy, nil))
+	assert.Equal(t, []string{"spec.image"}, errorFields(t, application("ghcr.io/kartverket/app:v1"), policy, nil))
+}
+
+func TestValidate_InvalidAllowedRegistry(t *testing.T) {
+	_, err := Validate(application("ghcr.io/kartverket/app:v1"), &config.ImagePolicy{AllowedRegistries: []string{"ghcr.io/Kartverket"}}, nil)
+	assert.Error(t, err)
+}
+
+func TestValidate_CloudSQLProxyImage(t *testing.T) {
+	app := application("ghcr.io/kartverket/app:v1")
+	app.Spec.GCP = &podtypes.GCP{CloudSQLProxy: &podtypes.CloudSQLProxySettings{ConnectionName: "project:region:db"}}
+
+	assert.Equal(t,
+		[]string{"spec.gcp.cloudSqlProxy.version"},
+		errorFields(t, app, &config.ImagePolicy{AllowedRegistries: []string{"ghcr.io/kartverket/"}}, nil),
+	)
+	assert.Empty(t, errorFields(t, app, &config.ImagePolicy{AllowedRegistries: []string{"ghcr.io/kartverket/", "gcr.io/cloud-sql-connectors/"}}, nil))
+}
+
+func TestValidate_ForbidLatestTag(t *testing.T) {
+	policy := &config.ImagePolicy{ForbidLatestTag: true}
+
+	assert.Equal(t, []string{"spec.image"}, errorFields(t, application("ghcr.io/kartverket/app:latest"), policy, nil))
+	assert.Equal(t, []string{"spec.image"}, errorFields(t, application("ghcr.io/kartverket/app"), policy, nil))
+	assert.Empty(t, errorFields(t, application("ghcr.io/kartverket/app:v1"), policy, nil))
+	assert.Empty(t, errorFields(t, application("ghcr.io/kartverket/app@sha256:"+fakeDigest), policy, nil))
+}
+
+func TestValidate_RequireDigestNamespace(t *testing.T) {
+	policy := &config.ImagePolicy{RequireDigestNamespace: "skip.kartverket.no/environment=production"}
+	production := map[string]string{"skip.kartverket.no/environment": "production"}
+
+	assert.Empty(t, errorFields(t, application("ghcr.io/kartverket/app:v1"), policy, nil))
+	assert.Equal(t, []string{"spec.image"}, errorFields(t, application("ghcr.io/kartverket/app:v1"), policy, production))
+	assert.Empty(t, errorFields(t, application("ghcr.io/kartverket/app@sha256:"+fakeDigest), policy, production))
+}
+
+func TestValidate_PreDeployImage(t *testing.T) {
+	app := application("ghcr.io/kartverket/app:v1")
+	app.Spec.PreDeploy = &v1alpha1.PreDeploySpec{Image: "docker.io/library/flyway:10", Command: []string{"flyway", "migrate"}}
+
+	assert.Equal(t,
+		[]string{"spec.preDeploy.image"},
+		errorFields(t, app, &config.ImagePolicy{AllowedRegistries: []string{"ghcr.io/kartverket/"}}, nil),
+	)
+}
+
+func TestValidate_SKIPJob(t *testing.T) {
+	job := &v1beta1.SKIPJob{Spec: v1beta1.SKIPJobSpec{Image: "docker.io/library/busybox:latest"}}
+
+	assert.Equal(t,
+		[]string{"spec.image", "spec.image"},
+		errorFields(t, job, &config.ImagePolicy{AllowedRegistries: []string{"ghcr.io/"}, ForbidLatestTag: true}, nil),
+	)
+
+	job.Spec.Image = "ghcr.io/kartverket/job:v1"
+	job.Spec.ExtraContainers = []podtypes.ContainerSpec{{Name: "proxy", Image: "ghcr.io/kartverket/proxy:v1"}, {Name: "db", Image: "postgres:17"}}
+	assert.Equal(t,
+		[]string{"spec.extraContainers[1].image"},
+		errorFields(t, job, &config.ImagePolicy{AllowedRegistries: []string{"ghcr.io/"}}, nil),
+	)
+}
+
+func TestValidate_InvalidSelector(t *testing.T) {
+	_, err := Validate(application("ghcr.io/kartverket/app:v1"), &config.ImagePolicy{RequireDigestNamespace: "!!"}, nil)
+	assert.Error(t, err)
+}
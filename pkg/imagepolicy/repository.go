@@ -0,0 +1,52 @@
+package imagepolicy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// parentProbe is appended to a repository path to parse it as the parent of a repository
+const parentProbe = "probe"
+
+// repositoryPrefix matches the repositories of a registry, or of a path within a registry. Entries such as
+// ghcr.io/kartverket/ match ghcr.io/kartverket and the repositories below it, but not ghcr.io/kartverket-evil.
+// Entries are normalized like image references, so docker.io/library matches docker.io/library/nginx, which
+// is named index.docker.io/library/nginx.
+type repositoryPrefix struct {
+	registry string
+	// Path within the registry, empty for every repository of the registry
+	path string
+}
+
+// parseRepositoryPrefix parses a registry such as ghcr.io, or a repository path such as ghcr.io/kartverket/.
+func parseRepositoryPrefix(prefix string) (repositoryPrefix, error) {
+	trimmed := strings.TrimSuffix(prefix, "/")
+	if !strings.Contains(trimmed, "/") {
+		registry, err := name.NewRegistry(trimmed)
+		if err != nil {
+			return repositoryPrefix{}, fmt.Errorf("invalid registry %q: %w", prefix, err)
+		}
+		return repositoryPrefix{registry: registry.RegistryStr()}, nil
+	}
+	// The prefix is parsed as the parent of a repository, so a Docker Hub path such as docker.io/bitnami is not
+	// expanded to the official image docker.io/library/bitnami
+	repository, err := name.NewRepository(trimmed + "/" + parentProbe)
+	if err != nil {
+		return repositoryPrefix{}, fmt.Errorf("invalid repository %q: %w", prefix, err)
+	}
+	return repositoryPrefix{registry: repository.RegistryStr(), path: strings.TrimSuffix(repository.RepositoryStr(), "/"+parentProbe)}, nil
+}
+
+func (p repositoryPrefix) matches(repository name.Repository) bool {
+	if repository.RegistryStr() != p.registry {
+		return false
+	}
+	return p.path == "" || repository.RepositoryStr() == p.path || strings.HasPrefix(repository.RepositoryStr(), p.path+"/")
+}
+
+// length orders prefixes by how specific they are
+func (p repositoryPrefix) length() int {
+	return len(p.registry) + len(p.path)
+}
@@ -0,0 +1,557 @@
+// Package imagepolicy checks the container images of Skiperator objects against
+// the cluster's image policies before any workload is generated for them.
+package imagepolicy
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/internal/config"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/util"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// Annotations and payload types used by cosign when it stores signatures and
+// attestations next to the image, see https://github.com/sigstore/cosign/blob/main/specs/SIGNATURE_SPEC.md
+const (
+	signatureAnnotation   = "dev.cosignproject.cosign/signature"
+	certificateAnnotation = "dev.sigstore.cosign/certificate"
+	bundleAnnotation      = "dev.sigstore.cosign/bundle"
+	simpleSigningType     = "cosign container image signature"
+	inTotoPayloadType     = "application/vnd.in-toto+json"
+	signatureTagSuffix    = "sig"
+	attestationTagSuffix  = "att"
+)
+
+// ErrUntrusted is returned when an image lacks a signature or attestation from a
+// trusted signer. Other errors are problems reaching the registry.
+var ErrUntrusted = errors.New("image is not trusted")
+
+// Verify checks that the images of the reconciled object are signed, and carry the
+// required attestations, according to the ImageVerification policy in SkiperatorConfig.
+// It runs before the workload is generated, so untrusted images are never rolled out.
+// Verified images are pinned to the verified digest, which the workload then runs.
+func Verify(r reconciliation.Reconciliation) error {
+	policy := r.GetSkiperatorConfig().ImageVerification
+	if policy == nil {
+		return nil
+	}
+	ctxLog := r.GetLogger()
+	obj := r.GetSKIPObject()
+
+	v, err := newVerifier(policy)
+	if err != nil {
+		return &reconciliation.SubResourceError{Message: "Invalid image verification policy", WrapErr: err, Reason: reconciliation.InternalError}
+	}
+
+	keychain, err := util.NewKeychain(r.GetCtx(), ctxLog.GetLogger(), r.GetRestConfig(), obj)
+	if err != nil {
+		return &reconciliation.SubResourceError{Message: "Could not create registry keychain", WrapErr: err, Reason: reconciliation.InternalError}
+	}
+	options := []remote.Option{remote.WithContext(r.GetCtx()), remote.WithAuthFromKeychain(keychain)}
+
+	for _, image := range images(obj) {
+		digest, err := v.verifyImage(pinnedDigest(r.PinnedImages()[image]), image, options...)
+		if err != nil {
+			return &reconciliation.SubResourceError{
+				Message:   fmt.Sprintf("Could not verify signature of container image %s", image),
+				WrapErr:   err,
+				Reason:    reconciliation.ImageSignatureInvalid,
+				Retryable: !errors.Is(err, ErrUntrusted),
+			}
+		}
+		if digest.Hex != "" && !strings.Contains(image, "@") {
+			r.PinImage(image, image+"@"+digest.String())
+		}
+		ctxLog.Debug("verified container image", "image", image, "digest", digest.String())
+	}
+	return nil
+}
+
+// pinnedDigest returns the digest of an image that has already been pinned in this reconcile, so it is verified
+// instead of resolving the tag again
+func pinnedDigest(pinned string) v1.Hash {
+	_, digest, found := strings.Cut(pinned, "@")
+	if !found {
+		return v1.Hash{}
+	}
+	hash, err := v1.NewHash(digest)
+	if err != nil {
+		return v1.Hash{}
+	}
+	return hash
+}
+
+// images returns the distinct images run by the object.
+func images(obj v1alpha1.SKIPObject) []string {
+	var result []string
+	for _, c := range containerImages(obj) {
+		result = append(result, c.image)
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+type rule struct {
+	prefix repositoryPrefix
+	// One verifier per public key, as signatures made with a key carry no hint of which key signed them
+	keys []*verify.Verifier
+	// Verifier of keyless signatures, nil without identities
+	keyless      *verify.Verifier
+	identities   []verify.PolicyOption
+	attestations []string
+}
+
+type verifier struct {
+	rules        []rule
+	requireMatch bool
+	// Hash of the policy, so cached verifications are not reused for another policy
+	fingerprint string
+}
+
+// keylessMaterial holds the Fulcio certificate authorities and Rekor transparency logs that keyless signatures
+// are checked against
+type keylessMaterial struct {
+	root.BaseTrustedMaterial
+	authorities []root.CertificateAuthority
+	logs        map[string]*root.TransparencyLog
+}
+
+func (m *keylessMaterial) FulcioCertificateAuthorities() []root.CertificateAuthority {
+	return m.authorities
+}
+
+func (m *keylessMaterial) RekorLogs() map[string]*root.TransparencyLog {
+	return m.logs
+}
+
+func newVerifier(policy *config.ImageVerificationPolicy) (*verifier, error) {
+	v := &verifier{requireMatch: policy.RequireMatch}
+
+	policyJSON, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	v.fingerprint = fmt.Sprintf("%x", sha256.Sum256(policyJSON))
+
+	material := &keylessMaterial{logs: map[string]*root.TransparencyLog{}}
+	for _, keyPEM := range policy.RekorPublicKeys {
+		block, _ := pem.Decode([]byte(keyPEM))
+		if block == nil {
+			return nil, errors.New("rekorPublicKeys: no PEM block found")
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("rekorPublicKeys: %w", err)
+		}
+		// Rekor identifies itself by the SHA-256 of its DER-encoded public key
+		logID := sha256.Sum256(block.Bytes)
+		material.logs[hex.EncodeToString(logID[:])] = &root.TransparencyLog{
+			ID:                  logID[:],
+			ValidityPeriodStart: time.Unix(0, 0),
+			HashFunc:            crypto.SHA256,
+			PublicKey:           key,
+			SignatureHashFunc:   crypto.SHA256,
+		}
+	}
+
+	var roots, intermediates []*x509.Certificate
+	for _, rootPEM := range policy.FulcioRoots {
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(rootPEM))
+		if err != nil {
+			return nil, fmt.Errorf("fulcioRoots: %w", err)
+		}
+		for _, cert := range certs {
+			if cert.Subject.String() == cert.Issuer.String() {
+				roots = append(roots, cert)
+			} else {
+				intermediates = append(intermediates, cert)
+			}
+		}
+	}
+	for _, cert := range roots {
+		material.authorities = append(material.authorities, &root.FulcioCertificateAuthority{Root: cert, Intermediates: intermediates})
+	}
+
+	for _, registry := range policy.Registries {
+		prefix, err := parseRepositoryPrefix(registry.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("registries: %w", err)
+		}
+		r := rule{prefix: prefix, attestations: registry.Attestations}
+		for _, keyPEM := range registry.PublicKeys {
+			keyVerifier, err := newKeyVerifier([]byte(keyPEM))
+			if err != nil {
+				return nil, fmt.Errorf("public key for %s: %w", registry.Prefix, err)
+			}
+			r.keys = append(r.keys, keyVerifier)
+		}
+		for _, id := range registry.Identities {
+			identity, err := verify.NewShortCertificateIdentity(id.Issuer, "", "", "^(?:"+id.SubjectRegExp+")$")
+			if err != nil {
+				return nil, fmt.Errorf("identity for %s: %w", registry.Prefix, err)
+			}
+			r.identities = append(r.identities, verify.WithCertificateIdentity(identity))
+		}
+		if len(r.identities) > 0 {
+			if len(material.logs) == 0 {
+				return nil, fmt.Errorf("identities for %s require rekorPublicKeys, as keyless signatures are only trusted when recorded in a transparency log", registry.Prefix)
+			}
+			// Fulcio certificates are short-lived, so they are checked at the time Rekor recorded the signature
+			if r.keyless, err = verify.NewVerifier(material, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1)); err != nil {
+				return nil, err
+			}
+		}
+		v.rules = append(v.rules, r)
+	}
+
+	return v, nil
+}
+
+// newKeyVerifier returns a verifier of signatures made with the PEM-encoded public key
+func newKeyVerifier(keyPEM []byte) (*verify.Verifier, error) {
+	key, err := cryptoutils.UnmarshalPEMToPublicKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	signatureVerifier, err := signature.LoadVerifier(key, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	material := root.NewTrustedPublicKeyMaterial(func(string) (root.TimeConstrainedVerifier, error) {
+		return root.NewExpiringKey(signatureVerifier, time.Time{}, time.Time{}), nil
+	})
+	return verify.NewVerifier(material, verify.WithNoObserverTimestamps())
+}
+
+// ruleFor returns the rule with the longest prefix matching the repository of the image, or nil if the image
+// is not covered by the policy.
+func (v *verifier) ruleFor(repository name.Repository) *rule {
+	var match *rule
+	for i, r := range v.rules {
+		if r.prefix.matches(repository) && (match == nil || r.prefix.length() > match.prefix.length()) {
+			match = &v.rules[i]
+		}
+	}
+	return match
+}
+
+// verifyImage verifies the image at the given digest, or at the digest its tag resolves to if the digest is empty,
+// and returns the verified digest. The digest is empty if the image is not covered by the policy and the policy
+// does not require a match.
+func (v *verifier) verifyImage(digest v1.Hash, image string, options ...remote.Option) (v1.Hash, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	r := v.ruleFor(ref.Context())
+	if r == nil {
+		if v.requireMatch {
+			return v1.Hash{}, fmt.Errorf("%w: %s is not covered by the image verification policy", ErrUntrusted, ref.Context().Name())
+		}
+		return v1.Hash{}, nil
+	}
+
+	if digest.Hex == "" {
+		if digest, err = resolveDigest(ref, options...); err != nil {
+			return v1.Hash{}, err
+		}
+	}
+	cacheKey := v.fingerprint + "/" + ref.Context().Name() + "@" + digest.String()
+	if verified.contains(cacheKey) {
+		return digest, nil
+	}
+
+	signatures, err := fetchLayers(ref.Context(), digest, signatureTagSuffix, options...)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	if !slices.ContainsFunc(signatures, func(l layer) bool { return r.verifySignature(digest, l) }) {
+		return v1.Hash{}, fmt.Errorf("%w: %s has no signature from a trusted signer", ErrUntrusted, digest)
+	}
+
+	if len(r.attestations) > 0 {
+		attestations, err := fetchLayers(ref.Context(), digest, attestationTagSuffix, options...)
+		if err != nil {
+			return v1.Hash{}, err
+		}
+		for _, predicateType := range r.attestations {
+			if !slices.ContainsFunc(attestations, func(l layer) bool { return r.verifyAttestation(digest, predicateType, l) }) {
+				return v1.Hash{}, fmt.Errorf("%w: %s has no %s attestation from a trusted signer", ErrUntrusted, digest, predicateType)
+			}
+		}
+	}
+
+	verified.add(cacheKey)
+	return digest, nil
+}
+
+// verifiedCacheSize bounds the number of verified digests that are remembered
+const verifiedCacheSize = 4096
+
+// verified remembers the digests that passed verification under a policy, so reconciles do not fetch and check
+// their signatures again. Digests are immutable and keyless signatures are checked at the time they were logged,
+// so a result only changes with the policy, which is part of the key. Failures are not cached, as signatures
+// may be added later.
+var verified = &verifiedCache{entries: map[string]struct{}{}}
+
+type verifiedCache struct {
+	mu      sync.Mutex
+	entries map[string]struct{}
+}
+
+func (c *verifiedCache) contains(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.entries[key]
+	return ok
+}
+
+func (c *verifiedCache) add(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= verifiedCacheSize {
+		clear(c.entries)
+	}
+	c.entries[key] = struct{}{}
+}
+
+func resolveDigest(ref name.Reference, options ...remote.Option) (v1.Hash, error) {
+	if digest, ok := ref.(name.Digest); ok {
+		return v1.NewHash(digest.DigestStr())
+	}
+	desc, err := remote.Head(ref, options...)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	return desc.Digest, nil
+}
+
+type layer struct {
+	payload     []byte
+	annotations map[string]string
+}
+
+// fetchLayers reads the layers of the image cosign stores under the
+// sha256-<hex>.<suffix> tag. A missing tag means there is nothing to verify.
+func fetchLayers(repository name.Repository, digest v1.Hash, suffix string, options ...remote.Option) ([]layer, error) {
+	tag := repository.Tag(fmt.Sprintf("%s-%s.%s", digest.Algorithm, digest.Hex, suffix))
+	img, err := remote.Image(tag, options...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	layers := make([]layer, 0, len(manifest.Layers))
+	for _, desc := range manifest.Layers {
+		l, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		rc, err := l.Compressed()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer{payload: payload, annotations: desc.Annotations})
+	}
+	return layers, nil
+}
+
+type simpleSigning struct {
+	Critical struct {
+		Type  string `json:"type"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+func (r *rule) verifySignature(digest v1.Hash, l layer) bool {
+	signature, err := base64.StdEncoding.DecodeString(l.annotations[signatureAnnotation])
+	if err != nil || len(signature) == 0 {
+		return false
+	}
+	var payload simpleSigning
+	if err := json.Unmarshal(l.payload, &payload); err != nil {
+		return false
+	}
+	if payload.Critical.Type != simpleSigningType || payload.Critical.Image.DockerManifestDigest != digest.String() {
+		return false
+	}
+
+	payloadDigest := sha256.Sum256(l.payload)
+	content := bundle.NewMessageSignature(payloadDigest[:], "SHA2_256", signature)
+	_, trusted := r.trusted(content, l.annotations, func() verify.ArtifactPolicyOption {
+		return verify.WithArtifact(bytes.NewReader(l.payload))
+	})
+	return trusted
+}
+
+func (r *rule) verifyAttestation(digest v1.Hash, predicateType string, l layer) bool {
+	var envelope dsse.Envelope
+	if err := json.Unmarshal(l.payload, &envelope); err != nil || envelope.PayloadType != inTotoPayloadType || len(envelope.Signatures) == 0 {
+		return false
+	}
+	digestBytes, err := hex.DecodeString(digest.Hex)
+	if err != nil {
+		return false
+	}
+
+	result, trusted := r.trusted(&bundle.Envelope{Envelope: &envelope}, l.annotations, func() verify.ArtifactPolicyOption {
+		return verify.WithArtifactDigest(digest.Algorithm, digestBytes)
+	})
+	return trusted && result.Statement != nil && result.Statement.GetPredicateType() == predicateType
+}
+
+// trusted verifies the signature content with the rule's public keys, and as a keyless signature by one of its
+// identities. The artifact option returns the artifact the signature must be made over.
+func (r *rule) trusted(content verify.SignatureContent, annotations map[string]string, artifact func() verify.ArtifactPolicyOption) (*verify.VerificationResult, bool) {
+	for _, keyVerifier := range r.keys {
+		entity := &cosignSignature{content: content, verification: &bundle.PublicKey{}}
+		if result, err := keyVerifier.Verify(entity, verify.NewPolicy(artifact(), verify.WithKey())); err == nil {
+			return result, true
+		}
+	}
+
+	if r.keyless == nil {
+		return nil, false
+	}
+	entity, err := keylessSignature(content, annotations)
+	if err != nil {
+		return nil, false
+	}
+	result, err := r.keyless.Verify(entity, verify.NewPolicy(artifact(), r.identities...))
+	return result, err == nil
+}
+
+// rekorBundle is the offline proof, stored by cosign next to a keyless signature, that the signature was
+// recorded in the Rekor transparency log
+type rekorBundle struct {
+	SignedEntryTimestamp []byte `json:"SignedEntryTimestamp"`
+	Payload              struct {
+		Body           []byte `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogID          string `json:"logID"`
+		LogIndex       int64  `json:"logIndex"`
+	} `json:"Payload"`
+}
+
+// keylessSignature returns the keyless signature with the signing certificate and Rekor bundle from the
+// annotations cosign stores them in
+func keylessSignature(content verify.SignatureContent, annotations map[string]string) (*cosignSignature, error) {
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(annotations[certificateAnnotation]))
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) != 1 {
+		return nil, fmt.Errorf("expected one signing certificate, found %d", len(certs))
+	}
+
+	var rekor rekorBundle
+	if err := json.Unmarshal([]byte(annotations[bundleAnnotation]), &rekor); err != nil {
+		return nil, fmt.Errorf("invalid Rekor bundle: %w", err)
+	}
+	logID, err := hex.DecodeString(rekor.Payload.LogID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Rekor log ID: %w", err)
+	}
+	var kind struct {
+		Kind       string `json:"kind"`
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal(rekor.Payload.Body, &kind); err != nil {
+		return nil, fmt.Errorf("invalid Rekor entry: %w", err)
+	}
+	entry, err := tlog.ParseTransparencyLogEntry(&protorekor.TransparencyLogEntry{
+		LogIndex:          rekor.Payload.LogIndex,
+		LogId:             &protocommon.LogId{KeyId: logID},
+		KindVersion:       &protorekor.KindVersion{Kind: kind.Kind, Version: kind.APIVersion},
+		IntegratedTime:    rekor.Payload.IntegratedTime,
+		InclusionPromise:  &protorekor.InclusionPromise{SignedEntryTimestamp: rekor.SignedEntryTimestamp},
+		CanonicalizedBody: rekor.Payload.Body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid Rekor entry: %w", err)
+	}
+
+	return &cosignSignature{content: content, verification: bundle.NewCertificate(certs[0]), entries: []*tlog.Entry{entry}}, nil
+}
+
+// cosignSignature is a signature or attestation in the format cosign stores next to the image, with the key or
+// certificate it is verified with and, for keyless signatures, the Rekor entry recording it
+type cosignSignature struct {
+	content      verify.SignatureContent
+	verification verify.VerificationContent
+	entries      []*tlog.Entry
+}
+
+var _ verify.SignedEntity = &cosignSignature{}
+
+func (s *cosignSignature) HasInclusionPromise() bool {
+	return len(s.entries) > 0
+}
+
+func (s *cosignSignature) HasInclusionProof() bool {
+	return false
+}
+
+func (s *cosignSignature) SignatureContent() (verify.SignatureContent, error) {
+	return s.content, nil
+}
+
+func (s *cosignSignature) Timestamps() ([][]byte, error) {
+	return nil, nil
+}
+
+func (s *cosignSignature) TlogEntries() ([]*tlog.Entry, error) {
+	return s.entries, nil
+}
+
+func (s *cosignSignature) VerificationContent() (verify.VerificationContent, error) {
+	return s.verification, nil
+}
+
+// Version is the Sigstore bundle version with the same contents, an inclusion promise without a proof
+func (s *cosignSignature) Version() (string, error) {
+	return "v0.1", nil
+}
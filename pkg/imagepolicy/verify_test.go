@@ -0,0 +1,573 @@
+package imagepolicy
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/kartverket/skiperator/api/common/podtypes"
+	"github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/internal/config"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	githubIssuer  = "https://token.actions.githubusercontent.com"
+	provenance    = "https://slsa.dev/provenance/v1"
+	buildWorkflow = "https://github.com/kartverket/app/.github/workflows/build.yaml@refs/heads/main"
+)
+
+// oidIssuer is the Fulcio certificate extension holding the OIDC issuer of the signer, see
+// https://github.com/sigstore/fulcio/blob/main/docs/oid-info.md
+var oidIssuer = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+
+type testRegistry struct {
+	host string
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return &testRegistry{host: u.Host}
+}
+
+// pushImage pushes a random image and returns its tag reference and digest.
+func (tr *testRegistry) pushImage(t *testing.T, repository string) (string, v1.Hash) {
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(fmt.Sprintf("%s/%s:v1", tr.host, repository))
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	return ref.String(), digest
+}
+
+// pushLayers stores layers under the cosign tag for digest, the way cosign sign and
+// cosign attest do.
+func (tr *testRegistry) pushLayers(t *testing.T, image string, digest v1.Hash, suffix string, addenda ...mutate.Addendum) {
+	ref, err := name.ParseReference(image)
+	require.NoError(t, err)
+	img, err := mutate.Append(empty.Image, addenda...)
+	require.NoError(t, err)
+	tag := ref.Context().Tag(fmt.Sprintf("%s-%s.%s", digest.Algorithm, digest.Hex, suffix))
+	require.NoError(t, remote.Write(tag, img))
+}
+
+type testSigner struct {
+	key     *ecdsa.PrivateKey
+	certPEM string
+	// Transparency log that records keyless signatures, and the time the entries are recorded at
+	rekor          *ecdsa.PrivateKey
+	integratedTime time.Time
+}
+
+func newKeySigner(t *testing.T) *testSigner {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &testSigner{key: key}
+}
+
+func (s *testSigner) publicKeyPEM(t *testing.T) string {
+	der, err := x509.MarshalPKIXPublicKey(&s.key.PublicKey)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func (s *testSigner) sign(t *testing.T, message []byte) string {
+	digest := sha256.Sum256(message)
+	signature, err := ecdsa.SignASN1(rand.Reader, s.key, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func (s *testSigner) annotations(signature string) map[string]string {
+	annotations := map[string]string{signatureAnnotation: signature}
+	if s.certPEM != "" {
+		annotations[certificateAnnotation] = s.certPEM
+	}
+	return annotations
+}
+
+func (s *testSigner) rekorPublicKeyPEM(t *testing.T) string {
+	der, err := x509.MarshalPKIXPublicKey(&s.rekor.PublicKey)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// bundle returns a Rekor bundle recording the signature in an entry of the given kind
+func (s *testSigner) bundle(t *testing.T, kind string, apiVersion string, spec any) string {
+	body, err := json.Marshal(map[string]any{"kind": kind, "apiVersion": apiVersion, "spec": spec})
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&s.rekor.PublicKey)
+	require.NoError(t, err)
+	logID := sha256.Sum256(der)
+	var rekor rekorBundle
+	rekor.Payload.Body = body
+	rekor.Payload.IntegratedTime = s.integratedTime.Unix()
+	rekor.Payload.LogID = hex.EncodeToString(logID[:])
+	rekor.Payload.LogIndex = 1
+
+	// Rekor signs the canonical JSON of the entry, encoding/json sorts map keys and escapes none of these values
+	canonical, err := json.Marshal(map[string]any{
+		"body":           base64.StdEncoding.EncodeToString(body),
+		"integratedTime": rekor.Payload.IntegratedTime,
+		"logID":          rekor.Payload.LogID,
+		"logIndex":       rekor.Payload.LogIndex,
+	})
+	require.NoError(t, err)
+	digest := sha256.Sum256(canonical)
+	rekor.SignedEntryTimestamp, err = ecdsa.SignASN1(rand.Reader, s.rekor, digest[:])
+	require.NoError(t, err)
+
+	bundle, err := json.Marshal(rekor)
+	require.NoError(t, err)
+	return string(bundle)
+}
+
+func (s *testSigner) signature(t *testing.T, digest v1.Hash) mutate.Addendum {
+	payload := fmt.Appendf(nil, `{"critical":{"identity":{"docker-reference":"app"},"image":{"docker-manifest-digest":%q},"type":%q},"optional":null}`, digest.String(), simpleSigningType)
+	signature := s.sign(t, payload)
+	annotations := s.annotations(signature)
+	if s.rekor != nil {
+		payloadDigest := sha256.Sum256(payload)
+		annotations[bundleAnnotation] = s.bundle(t, "hashedrekord", "0.0.1", map[string]any{
+			"data":      map[string]any{"hash": map[string]string{"algorithm": "sha256", "value": hex.EncodeToString(payloadDigest[:])}},
+			"signature": map[string]any{"content": signature, "publicKey": map[string]string{"content": base64.StdEncoding.EncodeToString([]byte(s.certPEM))}},
+		})
+	}
+	return mutate.Addendum{
+		Layer:       static.NewLayer(payload, "application/vnd.dev.cosign.simplesigning.v1+json"),
+		Annotations: annotations,
+	}
+}
+
+func (s *testSigner) attestation(t *testing.T, digest v1.Hash, predicateType string) mutate.Addendum {
+	statement := fmt.Appendf(nil, `{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"app","digest":{%q:%q}}],"predicateType":%q,"predicate":{}}`, digest.Algorithm, digest.Hex, predicateType)
+	signature := s.sign(t, dsse.PAE(inTotoPayloadType, statement))
+	env, err := json.Marshal(dsse.Envelope{
+		PayloadType: inTotoPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(statement),
+		Signatures:  []dsse.Signature{{Sig: signature}},
+	})
+	require.NoError(t, err)
+
+	annotations := s.annotations("")
+	annotations["predicateType"] = predicateType
+	if s.rekor != nil {
+		statementDigest := sha256.Sum256(statement)
+		annotations[bundleAnnotation] = s.bundle(t, "intoto", "0.0.2", map[string]any{"content": map[string]any{
+			"envelope": map[string]any{"payloadType": inTotoPayloadType, "signatures": []map[string]string{{
+				"sig":       base64.StdEncoding.EncodeToString([]byte(signature)),
+				"publicKey": base64.StdEncoding.EncodeToString([]byte(s.certPEM)),
+			}}},
+			"payloadHash": map[string]string{"algorithm": "sha256", "value": hex.EncodeToString(statementDigest[:])},
+		}})
+	}
+	return mutate.Addendum{
+		Layer:       static.NewLayer(env, types.MediaType("application/vnd.dsse.envelope.v1+json")),
+		Annotations: annotations,
+	}
+}
+
+// newKeylessSigner returns a signer with a short-lived code signing certificate for
+// subject, issued by a test CA standing in for Fulcio, which records its signatures in
+// a test transparency log standing in for Rekor. The CA certificate is returned as PEM.
+func newKeylessSigner(t *testing.T, issuer string, subject string) (*testSigner, string) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test fulcio"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	signer := newKeySigner(t)
+	issuerExt, err := asn1.MarshalWithParams(issuer, "utf8")
+	require.NoError(t, err)
+	subjectURI, err := url.Parse(subject)
+	require.NoError(t, err)
+	leafTemplate := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       time.Now().Add(-time.Minute),
+		NotAfter:        time.Now().Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		URIs:            []*url.URL{subjectURI},
+		ExtraExtensions: []pkix.Extension{{Id: oidIssuer, Value: issuerExt}},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, crypto.PublicKey(&signer.key.PublicKey), caKey)
+	require.NoError(t, err)
+	signer.certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}))
+	signer.rekor, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer.integratedTime = time.Now()
+
+	return signer, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))
+}
+
+func verifyWithPolicy(t *testing.T, policy *config.ImageVerificationPolicy, image string) error {
+	v, err := newVerifier(policy)
+	require.NoError(t, err)
+	_, err = v.verifyImage(v1.Hash{}, image)
+	return err
+}
+
+func TestVerifyImage_PublicKey(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, digest := tr.pushImage(t, "team/app")
+	signer := newKeySigner(t)
+	tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, digest))
+
+	policy := &config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{{Prefix: tr.host + "/team/", PublicKeys: []string{signer.publicKeyPEM(t)}}},
+	}
+
+	assert.NoError(t, verifyWithPolicy(t, policy, image))
+	assert.NoError(t, verifyWithPolicy(t, policy, fmt.Sprintf("%s/team/app@%s", tr.host, digest)))
+}
+
+func TestVerifyImage_UntrustedKey(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, digest := tr.pushImage(t, "team/app")
+	tr.pushLayers(t, image, digest, signatureTagSuffix, newKeySigner(t).signature(t, digest))
+
+	policy := &config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{{Prefix: tr.host + "/team/", PublicKeys: []string{newKeySigner(t).publicKeyPEM(t)}}},
+	}
+
+	assert.ErrorIs(t, verifyWithPolicy(t, policy, image), ErrUntrusted)
+}
+
+func TestVerifyImage_Unsigned(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, _ := tr.pushImage(t, "team/app")
+
+	policy := &config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{{Prefix: tr.host + "/team/", PublicKeys: []string{newKeySigner(t).publicKeyPEM(t)}}},
+	}
+
+	assert.ErrorIs(t, verifyWithPolicy(t, policy, image), ErrUntrusted)
+}
+
+func TestVerifyImage_SignatureForOtherDigest(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, digest := tr.pushImage(t, "team/app")
+	_, otherDigest := tr.pushImage(t, "team/other")
+	signer := newKeySigner(t)
+	// A valid signature copied over from another image must not be accepted.
+	tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, otherDigest))
+
+	policy := &config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{{Prefix: tr.host + "/team/", PublicKeys: []string{signer.publicKeyPEM(t)}}},
+	}
+
+	assert.ErrorIs(t, verifyWithPolicy(t, policy, image), ErrUntrusted)
+}
+
+func TestVerifyImage_NotCoveredByPolicy(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, _ := tr.pushImage(t, "other-team/app")
+
+	policy := &config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{{Prefix: tr.host + "/team/", PublicKeys: []string{newKeySigner(t).publicKeyPEM(t)}}},
+	}
+
+	assert.NoError(t, verifyWithPolicy(t, policy, image))
+}
+
+func TestVerifyImage_RequireMatch(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, _ := tr.pushImage(t, "other-team/app")
+
+	policy := &config.ImageVerificationPolicy{
+		RequireMatch: true,
+		Registries:   []config.RegistryVerification{{Prefix: tr.host + "/team/", PublicKeys: []string{newKeySigner(t).publicKeyPEM(t)}}},
+	}
+
+	err := verifyWithPolicy(t, policy, image)
+	assert.ErrorIs(t, err, ErrUntrusted)
+	assert.Contains(t, err.Error(), "not covered")
+}
+
+func TestVerifyImage_PrefixMatchesPathSegments(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, digest := tr.pushImage(t, "team-evil/app")
+	signer := newKeySigner(t)
+	tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, digest))
+	untrusted := newKeySigner(t).publicKeyPEM(t)
+
+	// team-evil is not below team/, so the rule for the registry applies, not the one for team/
+	policy := &config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{
+			{Prefix: tr.host + "/team/", PublicKeys: []string{signer.publicKeyPEM(t)}},
+			{Prefix: tr.host, PublicKeys: []string{untrusted}},
+		},
+	}
+	assert.ErrorIs(t, verifyWithPolicy(t, policy, image), ErrUntrusted)
+
+	policy.Registries[1].PublicKeys = []string{signer.publicKeyPEM(t)}
+	assert.NoError(t, verifyWithPolicy(t, policy, image))
+}
+
+func TestVerifyImage_Keyless(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, digest := tr.pushImage(t, "team/app")
+	signer, root := newKeylessSigner(t, githubIssuer, buildWorkflow)
+	tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, digest))
+
+	policyFor := func(issuer, subject string) *config.ImageVerificationPolicy {
+		return &config.ImageVerificationPolicy{
+			FulcioRoots:     []string{root},
+			RekorPublicKeys: []string{signer.rekorPublicKeyPEM(t)},
+			Registries: []config.RegistryVerification{{
+				Prefix:     tr.host + "/team/",
+				Identities: []config.KeylessIdentity{{Issuer: issuer, SubjectRegExp: subject}},
+			}},
+		}
+	}
+
+	assert.NoError(t, verifyWithPolicy(t, policyFor(githubIssuer, `https://github\.com/kartverket/.*`), image))
+	assert.ErrorIs(t, verifyWithPolicy(t, policyFor(githubIssuer, `https://github\.com/other-org/.*`), image), ErrUntrusted)
+	assert.ErrorIs(t, verifyWithPolicy(t, policyFor("https://accounts.google.com", `.*`), image), ErrUntrusted)
+}
+
+func TestVerifyImage_KeylessTransparencyLog(t *testing.T) {
+	policyFor := func(signer *testSigner, root string, prefix string) *config.ImageVerificationPolicy {
+		return &config.ImageVerificationPolicy{
+			FulcioRoots:     []string{root},
+			RekorPublicKeys: []string{signer.rekorPublicKeyPEM(t)},
+			Registries: []config.RegistryVerification{{
+				Prefix:     prefix,
+				Identities: []config.KeylessIdentity{{Issuer: githubIssuer, SubjectRegExp: ".*"}},
+			}},
+		}
+	}
+
+	t.Run("signature not recorded in the log", func(t *testing.T) {
+		tr := newTestRegistry(t)
+		image, digest := tr.pushImage(t, "team/app")
+		signer, root := newKeylessSigner(t, githubIssuer, buildWorkflow)
+		addendum := signer.signature(t, digest)
+		delete(addendum.Annotations, bundleAnnotation)
+		tr.pushLayers(t, image, digest, signatureTagSuffix, addendum)
+
+		assert.ErrorIs(t, verifyWithPolicy(t, policyFor(signer, root, tr.host+"/team/"), image), ErrUntrusted)
+	})
+
+	t.Run("signature recorded after the certificate expired", func(t *testing.T) {
+		tr := newTestRegistry(t)
+		image, digest := tr.pushImage(t, "team/app")
+		signer, root := newKeylessSigner(t, githubIssuer, buildWorkflow)
+		signer.integratedTime = time.Now().Add(30 * time.Minute)
+		tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, digest))
+
+		assert.ErrorIs(t, verifyWithPolicy(t, policyFor(signer, root, tr.host+"/team/"), image), ErrUntrusted)
+	})
+
+	t.Run("untrusted log", func(t *testing.T) {
+		tr := newTestRegistry(t)
+		image, digest := tr.pushImage(t, "team/app")
+		signer, root := newKeylessSigner(t, githubIssuer, buildWorkflow)
+		tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, digest))
+		other, _ := newKeylessSigner(t, githubIssuer, buildWorkflow)
+
+		assert.ErrorIs(t, verifyWithPolicy(t, policyFor(other, root, tr.host+"/team/"), image), ErrUntrusted)
+	})
+
+	t.Run("log entry for another signature", func(t *testing.T) {
+		tr := newTestRegistry(t)
+		image, digest := tr.pushImage(t, "team/app")
+		_, otherDigest := tr.pushImage(t, "team/other")
+		signer, root := newKeylessSigner(t, githubIssuer, buildWorkflow)
+		addendum := signer.signature(t, digest)
+		addendum.Annotations[bundleAnnotation] = signer.signature(t, otherDigest).Annotations[bundleAnnotation]
+		tr.pushLayers(t, image, digest, signatureTagSuffix, addendum)
+
+		assert.ErrorIs(t, verifyWithPolicy(t, policyFor(signer, root, tr.host+"/team/"), image), ErrUntrusted)
+	})
+
+	t.Run("attestation recorded in the log", func(t *testing.T) {
+		tr := newTestRegistry(t)
+		image, digest := tr.pushImage(t, "team/app")
+		signer, root := newKeylessSigner(t, githubIssuer, buildWorkflow)
+		tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, digest))
+		tr.pushLayers(t, image, digest, attestationTagSuffix, signer.attestation(t, digest, provenance))
+		policy := policyFor(signer, root, tr.host+"/team/")
+		policy.Registries[0].Attestations = []string{provenance}
+
+		assert.NoError(t, verifyWithPolicy(t, policy, image))
+	})
+}
+
+func TestVerifyImage_VerifiesGivenDigest(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, digest := tr.pushImage(t, "team/app")
+	signer := newKeySigner(t)
+	tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, digest))
+
+	v, err := newVerifier(&config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{{Prefix: tr.host + "/team/", PublicKeys: []string{signer.publicKeyPEM(t)}}},
+	})
+	require.NoError(t, err)
+
+	verified, err := v.verifyImage(v1.Hash{}, image)
+	require.NoError(t, err)
+	assert.Equal(t, digest, verified)
+
+	// The tag moves to an unsigned image, but the digest resolved earlier in the reconcile is still the one verified
+	_, movedDigest := tr.pushImage(t, "team/app")
+	verified, err = v.verifyImage(digest, image)
+	require.NoError(t, err)
+	assert.Equal(t, digest, verified)
+
+	_, err = v.verifyImage(movedDigest, image)
+	assert.ErrorIs(t, err, ErrUntrusted)
+}
+
+func TestVerifyImage_CachesVerifiedDigests(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, digest := tr.pushImage(t, "team/app")
+	signer := newKeySigner(t)
+	tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, digest))
+	policy := &config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{{Prefix: tr.host + "/team/", PublicKeys: []string{signer.publicKeyPEM(t)}}},
+	}
+	require.NoError(t, verifyWithPolicy(t, policy, image))
+
+	// Signatures are not fetched again for a verified digest
+	tr.pushLayers(t, image, digest, signatureTagSuffix)
+	assert.NoError(t, verifyWithPolicy(t, policy, image))
+
+	policy.Registries[0].PublicKeys = []string{newKeySigner(t).publicKeyPEM(t)}
+	assert.ErrorIs(t, verifyWithPolicy(t, policy, image), ErrUntrusted)
+}
+
+func TestVerifyImage_KeylessUntrustedRoot(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, digest := tr.pushImage(t, "team/app")
+	signer, _ := newKeylessSigner(t, githubIssuer, buildWorkflow)
+	_, otherRoot := newKeylessSigner(t, githubIssuer, buildWorkflow)
+	tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, digest))
+
+	policy := &config.ImageVerificationPolicy{
+		FulcioRoots:     []string{otherRoot},
+		RekorPublicKeys: []string{signer.rekorPublicKeyPEM(t)},
+		Registries: []config.RegistryVerification{{
+			Prefix:     tr.host + "/team/",
+			Identities: []config.KeylessIdentity{{Issuer: githubIssuer, SubjectRegExp: ".*"}},
+		}},
+	}
+
+	assert.ErrorIs(t, verifyWithPolicy(t, policy, image), ErrUntrusted)
+}
+
+func TestVerifyImage_Attestations(t *testing.T) {
+	tr := newTestRegistry(t)
+	image, digest := tr.pushImage(t, "team/app")
+	signer := newKeySigner(t)
+	tr.pushLayers(t, image, digest, signatureTagSuffix, signer.signature(t, digest))
+	tr.pushLayers(t, image, digest, attestationTagSuffix, signer.attestation(t, digest, provenance))
+
+	policyRequiring := func(predicateTypes ...string) *config.ImageVerificationPolicy {
+		return &config.ImageVerificationPolicy{
+			Registries: []config.RegistryVerification{{
+				Prefix:       tr.host + "/team/",
+				PublicKeys:   []string{signer.publicKeyPEM(t)},
+				Attestations: predicateTypes,
+			}},
+		}
+	}
+
+	assert.NoError(t, verifyWithPolicy(t, policyRequiring(provenance), image))
+
+	err := verifyWithPolicy(t, policyRequiring(provenance, "https://spdx.dev/Document"), image)
+	assert.ErrorIs(t, err, ErrUntrusted)
+	assert.True(t, strings.Contains(err.Error(), "https://spdx.dev/Document"))
+}
+
+func TestVerifyImage_RegistryErrorIsNotUntrusted(t *testing.T) {
+	tr := newTestRegistry(t)
+
+	policy := &config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{{Prefix: tr.host + "/team/", PublicKeys: []string{newKeySigner(t).publicKeyPEM(t)}}},
+	}
+
+	err := verifyWithPolicy(t, policy, tr.host+"/team/missing:v1")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUntrusted)
+}
+
+func TestNewVerifier_InvalidPolicy(t *testing.T) {
+	_, err := newVerifier(&config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{{Prefix: "ghcr.io/", PublicKeys: []string{"not a key"}}},
+	})
+	assert.Error(t, err)
+
+	_, err = newVerifier(&config.ImageVerificationPolicy{
+		RekorPublicKeys: []string{newKeySigner(t).publicKeyPEM(t)},
+		Registries:      []config.RegistryVerification{{Prefix: "ghcr.io/", Identities: []config.KeylessIdentity{{Issuer: githubIssuer, SubjectRegExp: "("}}}},
+	})
+	assert.Error(t, err)
+
+	// Keyless signatures are only trusted with a transparency log to check them against
+	_, err = newVerifier(&config.ImageVerificationPolicy{
+		Registries: []config.RegistryVerification{{Prefix: "ghcr.io/", Identities: []config.KeylessIdentity{{Issuer: githubIssuer, SubjectRegExp: ".*"}}}},
+	})
+	assert.Error(t, err)
+}
+
+func TestImages(t *testing.T) {
+	application := &v1alpha1.Application{
+		Spec: v1alpha1.ApplicationSpec{
+			Image:         "ghcr.io/kartverket/app:v1",
+			IstioSettings: &v1alpha1.IstioSettingsApplication{},
+			ExtraContainers: []podtypes.ContainerSpec{
+				{Name: "proxy", Image: "ghcr.io/kartverket/proxy:v1"},
+				{Name: "same", Image: "ghcr.io/kartverket/app:v1"},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"ghcr.io/kartverket/app:v1", "ghcr.io/kartverket/proxy:v1"}, images(application))
+}
@@ -12,6 +12,7 @@ const (
 	ResourceDependencyNotFound Reason = "ResourceDependencyNotFound"
 	UnsupportedTypeResource    Reason = "UnsupportedTypeResource"
 	ContainerImageNotFound     Reason = "ContainerImageNotFound"
+	ImageSignatureInvalid      Reason = "ImageSignatureInvalid"
 )
 
 type SubResourceError struct {
@@ -52,6 +53,9 @@ func (e *SubResourceError) IsRetryable() bool {
 	// Will not automatically fix itself
 	case InternalError, UnsupportedTypeResource, ContainerImageNotFound:
 		return false
+	// Untrusted images will not fix themselves, but registry lookups can fail transiently
+	case ImageSignatureInvalid:
+		return e.Retryable
 	// New/unknown statuses must signal whether they are retriable
 	default:
 		return e.Retryable
@@ -127,6 +127,16 @@ func TestSubResourceError_IsRetryable(t *testing.T) {
 			err:  &SubResourceError{Reason: ContainerImageNotFound, Retryable: true},
 			want: false,
 		},
+		{
+			name: "image signature invalid uses retryable field",
+			err:  &SubResourceError{Reason: ImageSignatureInvalid, Retryable: true},
+			want: true,
+		},
+		{
+			name: "untrusted image signature is not retryable",
+			err:  &SubResourceError{Reason: ImageSignatureInvalid, Retryable: false},
+			want: false,
+		},
 		{
 			name: "default branch uses retryable field when true",
 			err:  &SubResourceError{Reason: SubResourceGenerateFailed, Retryable: true},
@@ -8,6 +8,7 @@ import (
 	"github.com/kartverket/skiperator/pkg/auth"
 	"github.com/kartverket/skiperator/pkg/log"
 	"github.com/kartverket/skiperator/pkg/mesh"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -35,18 +36,41 @@ type Reconciliation interface {
 	GetSkiperatorConfig() config.SkiperatorConfig
 	GenerateLegacyRouting() bool
 	SetGenerateLegacyRouting(bool)
+	EnvFromKeysHash() string
+	SetEnvFromKeysHash(string)
+	WaitingForDependencies() bool
+	SetWaitingForDependencies(bool)
+	RolloutHeld() bool
+	HeldPodTemplate() *corev1.PodTemplateSpec
+	HoldRollout(*corev1.PodTemplateSpec)
+	StatefulPartition() *int32
+	SetStatefulPartition(*int32)
+	RolledBackImage() string
+	RollBackImage(string)
+	PinnedImages() map[string]string
+	PinImage(image string, pinned string)
+	RecreatePending() bool
+	SetRecreatePending(bool)
 }
 
 type baseReconciliation struct {
-	ctx                   context.Context
-	logger                log.Logger
-	resources             []client.Object
-	meshMode              mesh.Mode
-	restConfig            *rest.Config
-	skipObject            v1alpha1.SKIPObject
-	authConfigs           *auth.AuthConfigs
-	skiperatorConfig      config.SkiperatorConfig
-	generateLegacyRouting bool
+	ctx                    context.Context
+	logger                 log.Logger
+	resources              []client.Object
+	meshMode               mesh.Mode
+	restConfig             *rest.Config
+	skipObject             v1alpha1.SKIPObject
+	authConfigs            *auth.AuthConfigs
+	skiperatorConfig       config.SkiperatorConfig
+	generateLegacyRouting  bool
+	envFromKeysHash        string
+	waitingForDependencies bool
+	rolloutHeld            bool
+	heldPodTemplate        *corev1.PodTemplateSpec
+	statefulPartition      *int32
+	rolledBackImage        string
+	pinnedImages           map[string]string
+	recreatePending        bool
 }
 
 func (b *baseReconciliation) GetLogger() log.Logger {
@@ -102,3 +126,82 @@ func (b *baseReconciliation) GenerateLegacyRouting() bool {
 func (b *baseReconciliation) SetGenerateLegacyRouting(generate bool) {
 	b.generateLegacyRouting = generate
 }
+
+// EnvFromKeysHash is a hash of the values projected by envFromKeys, resolved by the controller.
+// Pod templates carry it so Pods are restarted when one of the values changes.
+func (b *baseReconciliation) EnvFromKeysHash() string {
+	return b.envFromKeysHash
+}
+
+func (b *baseReconciliation) SetEnvFromKeysHash(hash string) {
+	b.envFromKeysHash = hash
+}
+
+// WaitingForDependencies reports whether a SKIPJob has dependencies that have not finished yet, resolved by the
+// controller. The Job is then created suspended.
+func (b *baseReconciliation) WaitingForDependencies() bool {
+	return b.waitingForDependencies
+}
+
+func (b *baseReconciliation) SetWaitingForDependencies(waiting bool) {
+	b.waitingForDependencies = waiting
+}
+
+// RolloutHeld reports whether the rollout of an Application waits for its pre-deploy Job, resolved by the
+// controller. The workload then keeps the pod template in HeldPodTemplate, and is not created if it is nil.
+func (b *baseReconciliation) RolloutHeld() bool {
+	return b.rolloutHeld
+}
+
+func (b *baseReconciliation) HeldPodTemplate() *corev1.PodTemplateSpec {
+	return b.heldPodTemplate
+}
+
+func (b *baseReconciliation) HoldRollout(heldPodTemplate *corev1.PodTemplateSpec) {
+	b.rolloutHeld = true
+	b.heldPodTemplate = heldPodTemplate
+}
+
+// StatefulPartition is the partition of the current step of a staged StatefulSet rollout, resolved by the
+// controller. It replaces spec.stateful.partition when set.
+func (b *baseReconciliation) StatefulPartition() *int32 {
+	return b.statefulPartition
+}
+
+func (b *baseReconciliation) SetStatefulPartition(partition *int32) {
+	b.statefulPartition = partition
+}
+
+// RolledBackImage is the image the application container is pinned to after a failed rollout, resolved by the
+// controller. The image in spec is used when it is empty.
+func (b *baseReconciliation) RolledBackImage() string {
+	return b.rolledBackImage
+}
+
+func (b *baseReconciliation) RollBackImage(image string) {
+	b.rolledBackImage = image
+}
+
+// PinnedImages maps images in spec to the image pinned to the digest they were resolved to, e.g. when their
+// signature was verified. Workloads run the pinned images, so a tag that moves during the reconcile does not change
+// what is rolled out.
+func (b *baseReconciliation) PinnedImages() map[string]string {
+	return b.pinnedImages
+}
+
+func (b *baseReconciliation) PinImage(image string, pinned string) {
+	if b.pinnedImages == nil {
+		b.pinnedImages = make(map[string]string)
+	}
+	b.pinnedImages[image] = pinned
+}
+
+// RecreatePending reports whether the resource processor deleted resources to recreate them, set by the processor.
+// They are created on a later reconcile, once the deletion has finished, so the controller requeues.
+func (b *baseReconciliation) RecreatePending() bool {
+	return b.recreatePending
+}
+
+func (b *baseReconciliation) SetRecreatePending(pending bool) {
+	b.recreatePending = pending
+}
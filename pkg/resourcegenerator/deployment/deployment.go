@@ -47,12 +47,24 @@ func Generate(r reconciliation.Reconciliation) error {
 	podOpts := pod.PodOpts{
 		IstioEnabled:     r.IsSidecarEnabled(),
 		LocalBuiltImages: r.GetSkiperatorConfig().EnableLocallyBuiltImages,
+		SecurityContext:  pod.PodSecurityContext(application.Spec.PodSettings),
 	}
 
-	skiperatorContainer := pod.CreateApplicationContainer(application, podOpts)
+	skiperatorContainer, err := pod.CreateApplicationContainer(application, podOpts)
+	if err != nil {
+		return &reconciliation.SubResourceError{Message: "Failed to generate application container", WrapErr: err, Reason: reconciliation.InternalError}
+	}
 
-	podVolumes := volume.GetPodVolumes(application.Spec.FilesFrom)
+	podVolumes, err := volume.GetPodVolumes(application.Name, application.Spec.FilesFrom)
+	if err != nil {
+		return &reconciliation.SubResourceError{Message: "Failed to generate pod volumes", WrapErr: err, Reason: reconciliation.InternalError}
+	}
 	containerVolumeMounts := volume.GetContainerVolumeMounts(application.Spec.FilesFrom)
+	if !application.HasTmpVolume() {
+		podVolumes, containerVolumeMounts = volume.RemoveTmpVolume(podVolumes, containerVolumeMounts)
+	}
+	podVolumes, containerVolumeMounts = volume.AppendScratchVolumes(podVolumes, containerVolumeMounts, application.Spec.ScratchVolumes)
+	volume.AddScratchStorageRequest(&skiperatorContainer, application.Spec.ScratchVolumes)
 
 	if util.IsGCPAuthEnabled(application.Spec.GCP) {
 		gcpPodVolume := gcp.GetGCPContainerVolume(r.GetSkiperatorConfig().GCPWorkloadIdentityPool, application.Name)
@@ -129,11 +141,17 @@ func Generate(r reconciliation.Reconciliation) error {
 			// "failed scraping application metrics: error scraping http://localhost:80/metrics"
 			generatedSpecAnnotations["prometheus.istio.io/merge-metrics"] = "false"
 		}
+		if pod.HasOneshotContainers(application.Spec.ExtraContainers) {
+			generatedSpecAnnotations[resourceutils.AnnotationKeyIstioNativeSidecar] = "true"
+		}
 	}
 
 	if application.Spec.PodSettings != nil && len(application.Spec.PodSettings.Annotations) > 0 {
 		maps.Copy(generatedSpecAnnotations, application.Spec.PodSettings.Annotations)
 	}
+	if envFromKeysHash := r.EnvFromKeysHash(); envFromKeysHash != "" {
+		generatedSpecAnnotations[resourceutils.AnnotationKeyEnvFromKeysHash] = envFromKeysHash
+	}
 
 	containers := []corev1.Container{skiperatorContainer}
 
@@ -142,7 +160,10 @@ func Generate(r reconciliation.Reconciliation) error {
 		containers = append(containers, cloudSqlProxyContainer)
 	}
 
-	extraSidecars, extraInitContainers, extraVolumes := pod.CreateExtraContainers(application.Spec.ExtraContainers, podOpts)
+	extraSidecars, extraInitContainers, extraVolumes, err := pod.CreateExtraContainers(application.Name, application.Spec.ExtraContainers, podOpts)
+	if err != nil {
+		return &reconciliation.SubResourceError{Message: "Failed to generate extra containers", WrapErr: err, Reason: reconciliation.InternalError}
+	}
 	containers = append(containers, extraSidecars...)
 	podVolumes = pod.AppendUniqueVolumes(podVolumes, extraVolumes...)
 
@@ -167,6 +188,7 @@ func Generate(r reconciliation.Reconciliation) error {
 	}
 
 	podForDeploymentTemplate.Spec.InitContainers = extraInitContainers
+	pod.ApplyGracefulShutdown(&podForDeploymentTemplate.Spec, application.Spec.GracefulShutdown)
 
 	//we need to set the pod labels like this as its a template, not a resource.
 	//TODO: figure out a smoother solution?
@@ -213,7 +235,19 @@ func Generate(r reconciliation.Reconciliation) error {
 		deployment.Annotations[resourceutils.AnnotationKeyLinkPrefix] = fmt.Sprintf("https://%s", ingresses[0])
 	}
 
-	if !podOpts.LocalBuiltImages {
+	// Keep the pods of the previous image running until the pre-deploy Job for the new image has succeeded.
+	// The held template has already been resolved.
+	if r.RolloutHeld() {
+		if r.HeldPodTemplate() == nil {
+			ctxLog.Debug("waiting for pre-deploy job before creating deployment")
+			return nil
+		}
+		deployment.Spec.Template = *r.HeldPodTemplate()
+	}
+
+	if !podOpts.LocalBuiltImages && !r.RolloutHeld() {
+		// Run the digests that were verified, instead of resolving the tags again
+		util.PinImages(&deployment.Spec.Template.Spec, r.PinnedImages())
 		err := util.ResolveImageTags(r.GetCtx(), ctxLog.GetLogger(), r.GetRestConfig(), &deployment)
 		if err != nil {
 			//TODO fix this
@@ -225,6 +259,15 @@ func Generate(r reconciliation.Reconciliation) error {
 		}
 	}
 
+	// Keep the last healthy image after a failed rollout, until the spec changes
+	if image := r.RolledBackImage(); image != "" && !r.RolloutHeld() {
+		for i := range deployment.Spec.Template.Spec.Containers {
+			if deployment.Spec.Template.Spec.Containers[i].Name == application.Name {
+				deployment.Spec.Template.Spec.Containers[i].Image = image
+			}
+		}
+	}
+
 	r.AddResource(&deployment)
 
 	ctxLog.Debug("successfully created deployment resource")
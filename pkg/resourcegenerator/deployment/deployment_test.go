@@ -1,6 +1,7 @@
 package deployment
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/kartverket/skiperator/api/v1alpha1"
@@ -8,6 +9,7 @@ import (
 	"github.com/kartverket/skiperator/pkg/util"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 )
 
@@ -38,3 +40,33 @@ func TestHPAReplicasIsZero(t *testing.T) {
 	depl := r.GetResources()[0].(*appsv1.Deployment)
 	assert.Equal(t, depl.Spec.Replicas, util.PointTo(int32(0)))
 }
+
+func TestDeploymentKeepsHeldPodTemplate(t *testing.T) {
+	r := testutil.GetTestMinimalAppReconciliation()
+	r.GetSKIPObject().(*v1alpha1.Application).Spec.Image = "ghcr.io/kartverket/minimal:2.0"
+	held := corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "minimal", Image: "ghcr.io/kartverket/minimal:1.0@sha256:" + strings.Repeat("0", 64)}}}}
+	r.HoldRollout(&held)
+
+	assert.Nil(t, Generate(r))
+	depl := r.GetResources()[0].(*appsv1.Deployment)
+	assert.Equal(t, held, depl.Spec.Template)
+}
+
+func TestDeploymentNotCreatedWhileRolloutHeld(t *testing.T) {
+	r := testutil.GetTestMinimalAppReconciliation()
+	r.HoldRollout(nil)
+
+	assert.Nil(t, Generate(r))
+	assert.Empty(t, r.GetResources())
+}
+
+func TestDeploymentRunsPinnedImage(t *testing.T) {
+	r := testutil.GetTestMinimalAppReconciliation()
+	image := r.GetSKIPObject().(*v1alpha1.Application).Spec.Image
+	pinned := image + "@sha256:" + strings.Repeat("1", 64)
+	r.PinImage(image, pinned)
+
+	assert.Nil(t, Generate(r))
+	depl := r.GetResources()[0].(*appsv1.Deployment)
+	assert.Equal(t, pinned, depl.Spec.Template.Spec.Containers[0].Image)
+}
@@ -0,0 +1,35 @@
+package externalsecret
+
+import (
+	"fmt"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+)
+
+func init() {
+	multiGenerator.Register(reconciliation.ApplicationType, generateForApplication)
+}
+
+func generateForApplication(r reconciliation.Reconciliation) error {
+	ctxLog := r.GetLogger()
+	ctxLog.Debug("Attempting to generate external secrets for application", "application", r.GetSKIPObject().GetName())
+
+	application, ok := r.GetSKIPObject().(*skiperatorv1alpha1.Application)
+	if !ok {
+		return fmt.Errorf("failed to cast object to Application")
+	}
+
+	envFrom := application.Spec.EnvFrom
+	filesFrom := application.Spec.FilesFrom
+	for _, container := range application.Spec.ExtraContainers {
+		envFrom = append(envFrom, container.EnvFrom...)
+		filesFrom = append(filesFrom, container.FilesFrom...)
+	}
+	if err := addExternalSecrets(r, envFrom, filesFrom); err != nil {
+		return err
+	}
+
+	ctxLog.Debug("Finished generating external secrets for application", "application", application.Name)
+	return nil
+}
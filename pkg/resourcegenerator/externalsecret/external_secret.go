@@ -0,0 +1,149 @@
+package externalsecret
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kartverket/skiperator/api/common/podtypes"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/resourceutils/generator"
+	"github.com/kartverket/skiperator/pkg/resourceschemas/externalkinds"
+	"github.com/nais/liberator/pkg/namegen"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultRefreshInterval = time.Hour
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+var multiGenerator = generator.NewMulti()
+
+func Generate(r reconciliation.Reconciliation) error {
+	return multiGenerator.Generate(r, "ExternalSecret")
+}
+
+// SecretName returns the name of the ExternalSecret generated for source, which is also the name
+// of the Secret it syncs to. The name is scoped to the owning workload so two workloads reading
+// the same remote secret do not fight over ownership.
+func SecretName(ownerName string, source *podtypes.SecretStoreSource) (string, error) {
+	storeHash := sha256.Sum256([]byte(storeKind(source) + "/" + source.Name + "/" + source.RemoteKey))
+	rawName := strings.ToLower(fmt.Sprintf("%s-%s-%x", ownerName, source.RemoteKey, storeHash[:4]))
+	name := strings.Trim(invalidNameChars.ReplaceAllString(rawName, "-"), "-")
+
+	if len(name) <= validation.DNS1123LabelMaxLength {
+		return name, nil
+	}
+	return namegen.ShortName(name, validation.DNS1123LabelMaxLength)
+}
+
+// addExternalSecrets adds an ExternalSecret for every distinct secret store source
+func addExternalSecrets(r reconciliation.Reconciliation, envFrom []podtypes.EnvFrom, filesFrom []podtypes.FilesFrom) error {
+	object := r.GetSKIPObject()
+	seen := map[string]struct{}{}
+	add := func(source *podtypes.SecretStoreSource) error {
+		if source == nil {
+			return nil
+		}
+		name, err := SecretName(object.GetName(), source)
+		if err != nil {
+			return &reconciliation.SubResourceError{Message: "Failed to get external secret name", WrapErr: err, Reason: reconciliation.InternalError}
+		}
+		if _, ok := seen[name]; ok {
+			return nil
+		}
+		seen[name] = struct{}{}
+		r.AddResource(newExternalSecret(object.GetNamespace(), name, source))
+		return nil
+	}
+
+	for _, env := range envFrom {
+		if err := add(env.SecretStore); err != nil {
+			return err
+		}
+	}
+	for _, file := range filesFrom {
+		if err := add(file.SecretStore); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newExternalSecret(namespace, name string, source *podtypes.SecretStoreSource) *unstructured.Unstructured {
+	refreshInterval := defaultRefreshInterval
+	if source.RefreshInterval != nil {
+		refreshInterval = source.RefreshInterval.Duration
+	}
+
+	externalSecret := &unstructured.Unstructured{}
+	externalSecret.SetGroupVersionKind(externalkinds.ExternalSecret)
+	externalSecret.SetNamespace(namespace)
+	externalSecret.SetName(name)
+	externalSecret.Object["spec"] = map[string]any{
+		"refreshInterval": refreshInterval.String(),
+		"secretStoreRef": map[string]any{
+			"name": source.Name,
+			"kind": storeKind(source),
+		},
+		"target": map[string]any{
+			"name":           name,
+			"creationPolicy": "Owner",
+			"deletionPolicy": "Delete",
+		},
+		"dataFrom": []any{
+			map[string]any{
+				"extract": map[string]any{
+					"key": source.RemoteKey,
+				},
+			},
+		},
+	}
+	return externalSecret
+}
+
+func storeKind(source *podtypes.SecretStoreSource) string {
+	if source.Kind == "" {
+		return "ClusterSecretStore"
+	}
+	return source.Kind
+}
+
+// Unsynced returns the names of the generated ExternalSecrets that External Secrets Operator has
+// not yet synced into a Secret.
+func Unsynced(ctx context.Context, c client.Client, resources []client.Object) ([]string, error) {
+	var unsynced []string
+	for _, resource := range resources {
+		if resource.GetObjectKind().GroupVersionKind() != externalkinds.ExternalSecret {
+			continue
+		}
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(externalkinds.ExternalSecret)
+		if err := c.Get(ctx, client.ObjectKeyFromObject(resource), live); err != nil {
+			return nil, fmt.Errorf("failed to get ExternalSecret %s: %w", resource.GetName(), err)
+		}
+		if !isReady(live) {
+			unsynced = append(unsynced, resource.GetName())
+		}
+	}
+	return unsynced, nil
+}
+
+func isReady(externalSecret *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(externalSecret.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Ready" {
+			return condition["status"] == "True"
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package externalsecret
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kartverket/skiperator/api/common/podtypes"
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/resourceschemas/externalkinds"
+	"github.com/kartverket/skiperator/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestGenerateForApplication(t *testing.T) {
+	r := testutil.GetTestMinimalAppReconciliation()
+	application := r.GetSKIPObject().(*skiperatorv1alpha1.Application)
+	database := &podtypes.SecretStoreSource{Name: "gsm", Kind: "ClusterSecretStore", RemoteKey: "minimal-database"}
+	application.Spec.EnvFrom = []podtypes.EnvFrom{{SecretStore: database}, {Secret: "existing"}}
+	application.Spec.FilesFrom = []podtypes.FilesFrom{{MountPath: "/var/run/certs", SecretStore: &podtypes.SecretStoreSource{Name: "vault", Kind: "SecretStore", RemoteKey: "team/certs"}}}
+	application.Spec.ExtraContainers = []podtypes.ContainerSpec{{Name: "proxy", EnvFrom: []podtypes.EnvFrom{{SecretStore: database}}}}
+
+	assert.NoError(t, Generate(r))
+	assert.Len(t, r.GetResources(), 2)
+
+	externalSecret := r.GetResources()[0].(*unstructured.Unstructured)
+	assert.Equal(t, externalkinds.ExternalSecret, externalSecret.GroupVersionKind())
+	assert.Equal(t, "test", externalSecret.GetNamespace())
+	secretName, err := SecretName("minimal", database)
+	require.NoError(t, err)
+	assert.Equal(t, secretName, externalSecret.GetName())
+
+	storeRef, _, _ := unstructured.NestedStringMap(externalSecret.Object, "spec", "secretStoreRef")
+	assert.Equal(t, map[string]string{"name": "gsm", "kind": "ClusterSecretStore"}, storeRef)
+	targetName, _, _ := unstructured.NestedString(externalSecret.Object, "spec", "target", "name")
+	assert.Equal(t, externalSecret.GetName(), targetName)
+	refreshInterval, _, _ := unstructured.NestedString(externalSecret.Object, "spec", "refreshInterval")
+	assert.Equal(t, "1h0m0s", refreshInterval)
+	dataFrom, _, _ := unstructured.NestedSlice(externalSecret.Object, "spec", "dataFrom")
+	assert.Equal(t, []any{map[string]any{"extract": map[string]any{"key": "minimal-database"}}}, dataFrom)
+}
+
+func TestSecretName(t *testing.T) {
+	source := &podtypes.SecretStoreSource{Name: "vault", Kind: "SecretStore", RemoteKey: "Team/Certs"}
+	secretName := func(ownerName string, source *podtypes.SecretStoreSource) string {
+		name, err := SecretName(ownerName, source)
+		require.NoError(t, err)
+		return name
+	}
+	name := secretName("app", source)
+	assert.Regexp(t, `^app-team-certs-[0-9a-f]{8}$`, name)
+	assert.Empty(t, validation.IsDNS1123Label(name))
+	assert.Equal(t, name, secretName("app", source))
+
+	assert.NotEqual(t, name, secretName("other-app", source))
+	assert.NotEqual(t, name, secretName("app", &podtypes.SecretStoreSource{Name: "gsm", RemoteKey: "Team/Certs"}))
+
+	long := secretName(strings.Repeat("a", 60), &podtypes.SecretStoreSource{Name: "gsm", RemoteKey: strings.Repeat("b", 60)})
+	assert.Empty(t, validation.IsDNS1123Label(long))
+}
+
+func TestIsReady(t *testing.T) {
+	withCondition := func(status string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"status": map[string]any{"conditions": []any{map[string]any{"type": "Ready", "status": status}}},
+		}}
+	}
+
+	assert.True(t, isReady(withCondition("True")))
+	assert.False(t, isReady(withCondition("False")))
+	assert.False(t, isReady(&unstructured.Unstructured{Object: map[string]any{}}))
+}
@@ -0,0 +1,35 @@
+package externalsecret
+
+import (
+	"fmt"
+
+	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+)
+
+func init() {
+	multiGenerator.Register(reconciliation.JobType, generateForSKIPJob)
+}
+
+func generateForSKIPJob(r reconciliation.Reconciliation) error {
+	ctxLog := r.GetLogger()
+	ctxLog.Debug("Attempting to generate external secrets for skipjob", "skipjob", r.GetSKIPObject().GetName())
+
+	skipJob, ok := r.GetSKIPObject().(*skiperatorv1beta1.SKIPJob)
+	if !ok {
+		return fmt.Errorf("failed to cast object to skipjob")
+	}
+
+	envFrom := skipJob.Spec.EnvFrom
+	filesFrom := skipJob.Spec.FilesFrom
+	for _, container := range skipJob.Spec.ExtraContainers {
+		envFrom = append(envFrom, container.EnvFrom...)
+		filesFrom = append(filesFrom, container.FilesFrom...)
+	}
+	if err := addExternalSecrets(r, envFrom, filesFrom); err != nil {
+		return err
+	}
+
+	ctxLog.Debug("Finished generating external secrets for skipjob", "skipjob", skipJob.Name)
+	return nil
+}
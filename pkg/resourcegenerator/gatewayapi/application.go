@@ -5,6 +5,7 @@ import (
 
 	"github.com/kartverket/skiperator/api/common/istiotypes"
 	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/gwapi"
 	"github.com/kartverket/skiperator/pkg/reconciliation"
 	gatewayapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
@@ -29,12 +30,22 @@ func generateForApplication(r reconciliation.Reconciliation) error {
 	if err != nil {
 		return err
 	}
+
+	// Only TLS passthrough is reachable here, the CRD rejects plain TCP
+	// ingresses together with standard routing.
+	for _, ingress := range application.Spec.TCPIngresses {
+		name := gwapi.TLSPassthroughName(application.Name, ingress.Port)
+		listenerSetName := gwapi.ListenerSetName(name, ingress.Hostname)
+		r.AddResource(newTLSPassthroughListenerSet(application.Namespace, listenerSetName, ingress.Hostname, ingress.Port))
+		r.AddResource(newTLSRoute(application.Namespace, name, listenerSetName, ingress.Hostname, application.Name, application.TCPIngressTargetPort(ingress)))
+	}
+
 	// Without ingresses there is no ListenerSet to attach to, and the routes
 	// below would be created with no parentRefs: inert objects that Istio never
 	// programs. Readiness already treats an object with no hosts as ready, so
 	// generate nothing and stay consistent with it.
 	if hosts.Count() == 0 {
-		ctxLog.Debug("Standard routing without ingresses, no Gateway API resources to generate", "application", application.Name)
+		ctxLog.Debug("Standard routing without ingresses, no HTTP Gateway API resources to generate", "application", application.Name)
 		return nil
 	}
 
@@ -48,6 +59,12 @@ func generateForApplication(r reconciliation.Reconciliation) error {
 		r.AddResource(newRedirectRoute(application.Namespace, application.Name, "", listenerSetNames, hostnames))
 	}
 
+	if application.UsesGRPC() {
+		r.AddResource(newGRPCRoute(application.Namespace, application.Name, "", listenerSetNames, hostnames, []gatewayapiv1.GRPCRouteRule{applicationGRPCRule(application)}))
+		ctxLog.Debug("Finished generating gateway api resources for application", "application", application.Name)
+		return nil
+	}
+
 	backend := backendRule("default-app-route", application.Name, int32(application.Spec.Port), "/", false)
 	if err := applyRetries(&backend, applicationRetries(application), func(field string, value string) {
 		ctxLog.Warn("Ignoring unsupported Gateway API retry option", "kind", "Application", "namespace", application.Namespace, "name", application.Name, "field", field, "value", value)
@@ -67,3 +84,39 @@ func applicationRetries(application *skiperatorv1alpha1.Application) *istiotypes
 	}
 	return application.Spec.IstioSettings.Retries
 }
+
+// applicationGRPCRule returns the GRPCRoute rule for a gRPC Application. Each
+// configured service/method becomes a match; without any, all gRPC requests on
+// the hostnames reach the Application Service.
+func applicationGRPCRule(application *skiperatorv1alpha1.Application) gatewayapiv1.GRPCRouteRule {
+	portNumber := gatewayapiv1.PortNumber(application.Spec.Port)
+	ruleName := gatewayapiv1.SectionName("default-app-route")
+	rule := gatewayapiv1.GRPCRouteRule{
+		Name: &ruleName,
+		BackendRefs: []gatewayapiv1.GRPCBackendRef{
+			{
+				BackendRef: gatewayapiv1.BackendRef{
+					BackendObjectReference: gatewayapiv1.BackendObjectReference{
+						Name: gatewayapiv1.ObjectName(application.Name),
+						Port: &portNumber,
+					},
+				},
+			},
+		},
+	}
+	if application.Spec.GRPC == nil {
+		return rule
+	}
+	matchType := gatewayapiv1.GRPCMethodMatchExact
+	for _, match := range application.Spec.GRPC.Matches {
+		method := &gatewayapiv1.GRPCMethodMatch{
+			Type:    &matchType,
+			Service: new(match.Service),
+		}
+		if match.Method != "" {
+			method.Method = new(match.Method)
+		}
+		rule.Matches = append(rule.Matches, gatewayapiv1.GRPCRouteMatch{Method: method})
+	}
+	return rule
+}
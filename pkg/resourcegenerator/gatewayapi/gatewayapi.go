@@ -20,6 +20,7 @@ import (
 const (
 	httpSectionName  gatewayapiv1.SectionName = "http"
 	httpsSectionName gatewayapiv1.SectionName = "https"
+	tlsSectionName   gatewayapiv1.SectionName = "tls"
 )
 
 var multiGenerator = generator.NewMulti()
@@ -162,6 +163,80 @@ func newHTTPRoute(namespace string, name string, listenerSetNamespace string, li
 	}
 }
 
+// newGRPCRoute creates the HTTPS listener route that sends gRPC traffic to
+// Kubernetes Services. Gateways serve gRPC over the same TLS listener as HTTP,
+// negotiating HTTP/2 through ALPN.
+func newGRPCRoute(namespace string, name string, listenerSetNamespace string, listenerSetNames []string, hostnames []gatewayapiv1.Hostname, rules []gatewayapiv1.GRPCRouteRule) *gatewayapiv1.GRPCRoute {
+	return &gatewayapiv1.GRPCRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: gatewayapiv1.GRPCRouteSpec{
+			CommonRouteSpec: gatewayapiv1.CommonRouteSpec{
+				ParentRefs: parentRefs(listenerSetNamespace, listenerSetNames, httpsSectionName),
+			},
+			Hostnames: hostnames,
+			Rules:     rules,
+		},
+	}
+}
+
+// newTLSPassthroughListenerSet adds one TLS listener for a hostname on a
+// non-HTTP gateway port. The TLS stream is forwarded unterminated, so no
+// certificate is involved.
+func newTLSPassthroughListenerSet(namespace string, name string, hostname string, port int32) *gatewayapiv1.ListenerSet {
+	passthrough := gatewayapiv1.TLSModePassthrough
+	return &gatewayapiv1.ListenerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: gatewayapiv1.ListenerSetSpec{
+			ParentRef: parentGatewayRef(hostname),
+			Listeners: []gatewayapiv1.ListenerEntry{
+				{
+					Name:     tlsSectionName,
+					Hostname: new(gatewayapiv1.Hostname(hostname)),
+					Port:     gatewayapiv1.PortNumber(port),
+					Protocol: gatewayapiv1.TLSProtocolType,
+					TLS:      &gatewayapiv1.ListenerTLSConfig{Mode: &passthrough},
+				},
+			},
+		},
+	}
+}
+
+// newTLSRoute routes SNI-matched TLS connections on a passthrough listener to
+// a Kubernetes Service.
+func newTLSRoute(namespace string, name string, listenerSetName string, hostname string, serviceName string, port int32) *gatewayapiv1.TLSRoute {
+	portNumber := gatewayapiv1.PortNumber(port)
+	return &gatewayapiv1.TLSRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: gatewayapiv1.TLSRouteSpec{
+			CommonRouteSpec: gatewayapiv1.CommonRouteSpec{
+				ParentRefs: []gatewayapiv1.ParentReference{parentListenerSetRef("", listenerSetName, tlsSectionName)},
+			},
+			Hostnames: []gatewayapiv1.Hostname{gatewayapiv1.Hostname(hostname)},
+			Rules: []gatewayapiv1.TLSRouteRule{
+				{
+					BackendRefs: []gatewayapiv1.BackendRef{
+						{
+							BackendObjectReference: gatewayapiv1.BackendObjectReference{
+								Name: gatewayapiv1.ObjectName(serviceName),
+								Port: &portNumber,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 // listeners returns the two listeners Skiperator exposes for each hostname:
 // port 80 HTTP for redirects and port 443 HTTPS for backend routes.
 func listeners(hostname string, secretName string, allowCrossNamespaceRoutes bool) []gatewayapiv1.ListenerEntry {
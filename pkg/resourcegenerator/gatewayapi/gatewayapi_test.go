@@ -101,6 +101,104 @@ func TestApplicationStandardRoutingWithExtraContainerUsesServicePort(t *testing.
 	assert.Equal(t, gatewayapiv1.PortNumber(8080), *route.Spec.Rules[0].BackendRefs[0].Port)
 }
 
+func TestApplicationStandardRoutingGRPC(t *testing.T) {
+	app := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Image:           "image",
+			Port:            9090,
+			AppProtocol:     "grpc",
+			Ingresses:       []string{"app.example.com"},
+			RoutingProvider: skiperatorv1alpha1.RoutingProviderStandard,
+			RedirectToHTTPS: skiperatorv1alpha1Bool(true),
+			GRPC: &skiperatorv1alpha1.GRPCSettings{
+				Matches: []skiperatorv1alpha1.GRPCMethodMatch{
+					{Service: "orders.v1.OrderService"},
+					{Service: "grpc.health.v1.Health", Method: "Check"},
+				},
+			},
+		},
+	}
+	r := reconciliation.NewApplicationReconciliation(context.Background(), app, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+
+	require.NoError(t, Generate(r))
+	require.Len(t, r.GetResources(), 3)
+
+	redirectRoute := r.GetResources()[1].(*gatewayapiv1.HTTPRoute)
+	assert.Equal(t, "app-redirect", redirectRoute.Name)
+
+	route := r.GetResources()[2].(*gatewayapiv1.GRPCRoute)
+	assert.Equal(t, "app", route.Name)
+	assert.Equal(t, httpsSectionName, *route.Spec.ParentRefs[0].SectionName)
+	assert.Equal(t, []gatewayapiv1.Hostname{"app.example.com"}, route.Spec.Hostnames)
+	require.Len(t, route.Spec.Rules, 1)
+	rule := route.Spec.Rules[0]
+	assert.Equal(t, gatewayapiv1.ObjectName("app"), rule.BackendRefs[0].Name)
+	assert.Equal(t, gatewayapiv1.PortNumber(9090), *rule.BackendRefs[0].Port)
+	require.Len(t, rule.Matches, 2)
+	assert.Equal(t, "orders.v1.OrderService", *rule.Matches[0].Method.Service)
+	assert.Nil(t, rule.Matches[0].Method.Method)
+	assert.Equal(t, "grpc.health.v1.Health", *rule.Matches[1].Method.Service)
+	assert.Equal(t, "Check", *rule.Matches[1].Method.Method)
+}
+
+func TestApplicationStandardRoutingGRPCWithoutMatches(t *testing.T) {
+	app := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Image:           "image",
+			Port:            9090,
+			AppProtocol:     "grpc",
+			Ingresses:       []string{"app.example.com"},
+			RoutingProvider: skiperatorv1alpha1.RoutingProviderStandard,
+		},
+	}
+	r := reconciliation.NewApplicationReconciliation(context.Background(), app, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+
+	require.NoError(t, Generate(r))
+	require.Len(t, r.GetResources(), 2)
+
+	route := r.GetResources()[1].(*gatewayapiv1.GRPCRoute)
+	assert.Empty(t, route.Spec.Rules[0].Matches)
+}
+
+func TestApplicationStandardRoutingTLSPassthrough(t *testing.T) {
+	app := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Image:           "image",
+			Port:            5432,
+			RoutingProvider: skiperatorv1alpha1.RoutingProviderStandard,
+			TCPIngresses: []skiperatorv1alpha1.TCPIngress{
+				{Hostname: "db.example.com", Port: 5432, Protocol: "TLS"},
+			},
+		},
+	}
+	r := reconciliation.NewApplicationReconciliation(context.Background(), app, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+
+	require.NoError(t, Generate(r))
+	require.Len(t, r.GetResources(), 2)
+
+	listenerSet := r.GetResources()[0].(*gatewayapiv1.ListenerSet)
+	assert.Equal(t, gwapi.ListenerSetName("db-tls-5432", "db.example.com"), listenerSet.Name)
+	assert.Equal(t, gatewayapiv1.ObjectName(gwapi.ExternalGatewayName), listenerSet.Spec.ParentRef.Name)
+	require.Len(t, listenerSet.Spec.Listeners, 1)
+	listener := listenerSet.Spec.Listeners[0]
+	assert.Equal(t, tlsSectionName, listener.Name)
+	assert.Equal(t, gatewayapiv1.PortNumber(5432), listener.Port)
+	assert.Equal(t, gatewayapiv1.TLSProtocolType, listener.Protocol)
+	assert.Equal(t, gatewayapiv1.TLSModePassthrough, *listener.TLS.Mode)
+	assert.Empty(t, listener.TLS.CertificateRefs)
+
+	route := r.GetResources()[1].(*gatewayapiv1.TLSRoute)
+	assert.Equal(t, "db-tls-5432", route.Name)
+	assert.Equal(t, gatewayapiv1.ObjectName(listenerSet.Name), route.Spec.ParentRefs[0].Name)
+	assert.Equal(t, tlsSectionName, *route.Spec.ParentRefs[0].SectionName)
+	assert.Equal(t, []gatewayapiv1.Hostname{"db.example.com"}, route.Spec.Hostnames)
+	assert.Equal(t, gatewayapiv1.ObjectName("db"), route.Spec.Rules[0].BackendRefs[0].Name)
+	assert.Equal(t, gatewayapiv1.PortNumber(5432), *route.Spec.Rules[0].BackendRefs[0].Port)
+}
+
 func TestApplicationLegacyRoutingSkipsGatewayAPI(t *testing.T) {
 	app := &skiperatorv1alpha1.Application{
 		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
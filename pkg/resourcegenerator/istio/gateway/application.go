@@ -79,6 +79,36 @@ func generateForApplication(r reconciliation.Reconciliation) error {
 		r.AddResource(&gateway)
 	}
 
+	for _, ingress := range application.Spec.TCPIngresses {
+		r.AddResource(tcpGateway(application, ingress))
+	}
+
 	ctxLog.Debug("Finished generating ingress gateways for application", "application", application.Name)
 	return nil
 }
+
+// tcpGateway creates a legacy Istio Gateway listening on the port of one TCP
+// ingress. TLS ingresses are passed through unterminated and matched on SNI in
+// the VirtualService, while TCP ingresses forward everything on the port.
+func tcpGateway(application *skiperatorv1alpha1.Application, ingress skiperatorv1alpha1.TCPIngress) *networkingv1.Gateway {
+	gateway := &networkingv1.Gateway{ObjectMeta: metav1.ObjectMeta{Namespace: application.Namespace, Name: application.GetTCPGatewayName(ingress)}}
+	gateway.Spec.Selector = mesh.IngressGatewayLabels(util.IsInternal(ingress.Hostname))
+
+	server := &networkingv1api.Server{
+		Hosts: []string{ingress.Hostname},
+		Port: &networkingv1api.Port{
+			Number:   uint32(ingress.Port),
+			Name:     fmt.Sprintf("tcp-%d", ingress.Port),
+			Protocol: "TCP",
+		},
+	}
+	if ingress.IsTLSPassthrough() {
+		server.Port.Name = fmt.Sprintf("tls-%d", ingress.Port)
+		server.Port.Protocol = "TLS"
+		server.Tls = &networkingv1api.ServerTLSSettings{
+			Mode: networkingv1api.ServerTLSSettings_PASSTHROUGH,
+		}
+	}
+	gateway.Spec.Servers = []*networkingv1api.Server{server}
+	return gateway
+}
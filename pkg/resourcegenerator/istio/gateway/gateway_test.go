@@ -55,6 +55,38 @@ func TestApplicationLegacyRoutingGeneratesGateway(t *testing.T) {
 	assert.Equal(t, application.GetGatewayName("app.example.com"), gateway.Name)
 }
 
+func TestApplicationLegacyRoutingGeneratesTCPGateways(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Image:           "image",
+			Port:            5432,
+			RoutingProvider: skiperatorv1alpha1.RoutingProviderLegacy,
+			TCPIngresses: []skiperatorv1alpha1.TCPIngress{
+				{Hostname: "db.example.com", Port: 5432, Protocol: "TLS"},
+				{Hostname: "sftp.skip.statkart.no", Port: 2222, Protocol: "TCP"},
+			},
+		},
+	}
+	r := reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+
+	require.NoError(t, Generate(r))
+	require.Len(t, r.GetResources(), 2)
+
+	tlsGateway := r.GetResources()[0].(*istionetworkingv1.Gateway)
+	assert.Equal(t, "db-tcp-ingress-5432", tlsGateway.Name)
+	assert.Equal(t, mesh.IngressGatewayLabels(false), tlsGateway.Spec.Selector)
+	assert.Equal(t, "TLS", tlsGateway.Spec.Servers[0].Port.Protocol)
+	assert.Equal(t, uint32(5432), tlsGateway.Spec.Servers[0].Port.Number)
+	assert.Equal(t, "PASSTHROUGH", tlsGateway.Spec.Servers[0].Tls.Mode.String())
+
+	tcpGateway := r.GetResources()[1].(*istionetworkingv1.Gateway)
+	assert.Equal(t, "db-tcp-ingress-2222", tcpGateway.Name)
+	assert.Equal(t, mesh.IngressGatewayLabels(true), tcpGateway.Spec.Selector)
+	assert.Equal(t, "TCP", tcpGateway.Spec.Servers[0].Port.Protocol)
+	assert.Nil(t, tcpGateway.Spec.Servers[0].Tls)
+}
+
 func TestRoutingLegacyRoutingGeneratesGateway(t *testing.T) {
 	routing := &skiperatorv1alpha1.Routing{
 		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team-a"},
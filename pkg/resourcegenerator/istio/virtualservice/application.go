@@ -2,6 +2,7 @@ package virtualservice
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -43,13 +44,17 @@ func generateForApplication(r reconciliation.Reconciliation) error {
 		return err
 	}
 
-	if len(hosts.Hostnames()) > 0 {
+	if len(hosts.Hostnames()) > 0 || len(application.Spec.TCPIngresses) > 0 {
 		virtualService.Spec = networkingv1api.VirtualService{
 			ExportTo: []string{".", mesh.SystemNamespace, mesh.GatewayNamespace},
 			Gateways: getGatewaysFromApplication(application),
-			Hosts:    hosts.Hostnames(),
-			Http:     []*networkingv1api.HTTPRoute{},
+			Hosts:    getHostsFromApplication(application, hosts.Hostnames()),
 		}
+		addTCPRoutes(&virtualService.Spec, application)
+	}
+
+	if len(hosts.Hostnames()) > 0 {
+		virtualService.Spec.Http = []*networkingv1api.HTTPRoute{}
 
 		if application.Spec.RedirectToHTTPS != nil && *application.Spec.RedirectToHTTPS {
 			virtualService.Spec.Http = append(virtualService.Spec.Http, &networkingv1api.HTTPRoute{
@@ -80,6 +85,9 @@ func generateForApplication(r reconciliation.Reconciliation) error {
 			},
 			Retries: generateRetryPolicy(application.Spec.IstioSettings.Retries),
 		})
+	}
+
+	if len(virtualService.Spec.Hosts) > 0 {
 		r.AddResource(&virtualService)
 		ctxLog.Debug("Added virtual service to application", "application", application.Name)
 	}
@@ -94,10 +102,65 @@ func getGatewaysFromApplication(application *skiperatorv1alpha1.Application) []s
 	for _, hostname := range hosts.Hostnames() {
 		gateways = append(gateways, application.GetGatewayName(hostname))
 	}
+	for _, ingress := range application.Spec.TCPIngresses {
+		gateways = append(gateways, application.GetTCPGatewayName(ingress))
+	}
 
 	return gateways
 }
 
+// getHostsFromApplication adds TCP ingress hostnames not already served over
+// HTTP, since a VirtualService binds to a gateway server only through hosts.
+func getHostsFromApplication(application *skiperatorv1alpha1.Application, hostnames []string) []string {
+	result := slices.Clone(hostnames)
+	for _, ingress := range application.Spec.TCPIngresses {
+		if !slices.Contains(result, ingress.Hostname) {
+			result = append(result, ingress.Hostname)
+		}
+	}
+	return result
+}
+
+// addTCPRoutes routes every TCP ingress to the application Service. TLS
+// ingresses match on gateway port and SNI hostname, TCP ingresses on port only.
+func addTCPRoutes(spec *networkingv1api.VirtualService, application *skiperatorv1alpha1.Application) {
+	for _, ingress := range application.Spec.TCPIngresses {
+		destination := []*networkingv1api.RouteDestination{
+			{
+				Destination: &networkingv1api.Destination{
+					Host: application.Name,
+					Port: &networkingv1api.PortSelector{
+						Number: uint32(application.TCPIngressTargetPort(ingress)),
+					},
+				},
+			},
+		}
+		gateways := []string{application.GetTCPGatewayName(ingress)}
+		if ingress.IsTLSPassthrough() {
+			spec.Tls = append(spec.Tls, &networkingv1api.TLSRoute{
+				Match: []*networkingv1api.TLSMatchAttributes{
+					{
+						SniHosts: []string{ingress.Hostname},
+						Port:     uint32(ingress.Port),
+						Gateways: gateways,
+					},
+				},
+				Route: destination,
+			})
+			continue
+		}
+		spec.Tcp = append(spec.Tcp, &networkingv1api.TCPRoute{
+			Match: []*networkingv1api.L4MatchAttributes{
+				{
+					Port:     uint32(ingress.Port),
+					Gateways: gateways,
+				},
+			},
+			Route: destination,
+		})
+	}
+}
+
 func generateRetryPolicy(re *istiotypes.Retries) *networkingv1api.HTTPRetry {
 	conditions := "connect-failure,refused-stream,unavailable,cancelled"
 
@@ -57,6 +57,64 @@ func TestApplicationLegacyRoutingGeneratesVirtualService(t *testing.T) {
 	assert.Equal(t, []string{"app.example.com"}, virtualService.Spec.Hosts)
 }
 
+func TestApplicationLegacyRoutingGeneratesTCPRoutes(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "broker", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Image:           "image",
+			Port:            8080,
+			Ingresses:       []string{"broker.example.com"},
+			RoutingProvider: skiperatorv1alpha1.RoutingProviderLegacy,
+			IstioSettings:   &skiperatorv1alpha1.IstioSettingsApplication{},
+			TCPIngresses: []skiperatorv1alpha1.TCPIngress{
+				{Hostname: "broker.example.com", Port: 8883, TargetPort: 8883, Protocol: "TLS"},
+				{Hostname: "mqtt.example.com", Port: 1883, TargetPort: 1883, Protocol: "TCP"},
+			},
+		},
+	}
+	r := reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+
+	require.NoError(t, Generate(r))
+	require.Len(t, r.GetResources(), 1)
+	virtualService := r.GetResources()[0].(*istionetworkingv1.VirtualService)
+	assert.Equal(t, []string{"broker.example.com", "mqtt.example.com"}, virtualService.Spec.Hosts)
+	assert.Contains(t, virtualService.Spec.Gateways, "broker-tcp-ingress-8883")
+	assert.Contains(t, virtualService.Spec.Gateways, "broker-tcp-ingress-1883")
+	require.Len(t, virtualService.Spec.Http, 1)
+
+	require.Len(t, virtualService.Spec.Tls, 1)
+	assert.Equal(t, []string{"broker.example.com"}, virtualService.Spec.Tls[0].Match[0].SniHosts)
+	assert.Equal(t, uint32(8883), virtualService.Spec.Tls[0].Match[0].Port)
+	assert.Equal(t, uint32(8883), virtualService.Spec.Tls[0].Route[0].Destination.Port.Number)
+
+	require.Len(t, virtualService.Spec.Tcp, 1)
+	assert.Equal(t, uint32(1883), virtualService.Spec.Tcp[0].Match[0].Port)
+	assert.Equal(t, "broker", virtualService.Spec.Tcp[0].Route[0].Destination.Host)
+}
+
+func TestApplicationLegacyRoutingTCPIngressOnly(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Image:           "image",
+			Port:            5432,
+			RoutingProvider: skiperatorv1alpha1.RoutingProviderLegacy,
+			IstioSettings:   &skiperatorv1alpha1.IstioSettingsApplication{},
+			TCPIngresses: []skiperatorv1alpha1.TCPIngress{
+				{Hostname: "db.example.com", Port: 5432},
+			},
+		},
+	}
+	r := reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+
+	require.NoError(t, Generate(r))
+	require.Len(t, r.GetResources(), 1)
+	virtualService := r.GetResources()[0].(*istionetworkingv1.VirtualService)
+	assert.Empty(t, virtualService.Spec.Http)
+	require.Len(t, virtualService.Spec.Tls, 1)
+	assert.Equal(t, uint32(5432), virtualService.Spec.Tls[0].Route[0].Destination.Port.Number)
+}
+
 func TestRoutingLegacyRoutingGeneratesVirtualService(t *testing.T) {
 	routing := &skiperatorv1alpha1.Routing{
 		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team-a"},
@@ -1,9 +1,13 @@
 package job
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"maps"
+	"slices"
+	"strings"
 
+	"github.com/kartverket/skiperator/api/common/podtypes"
 	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
 	"github.com/kartverket/skiperator/internal/config"
 	"github.com/kartverket/skiperator/pkg/log"
@@ -16,6 +20,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // TODO completely butchered, need to be thorougly checked
@@ -52,17 +57,49 @@ func Generate(r reconciliation.Reconciliation) error {
 	}
 
 	if skipJob.Spec.Cron != nil {
-		cronJob.Spec = getCronJobSpec(&ctxLog, skipJob, cronJob.Spec.JobTemplate.Spec.Selector, cronJob.Spec.JobTemplate.Spec.Template.Labels, r.GetSkiperatorConfig())
+		spec, err := getCronJobSpec(&ctxLog, skipJob, cronJob.Spec.JobTemplate.Spec.Selector, cronJob.Spec.JobTemplate.Spec.Template.Labels, r.GetSkiperatorConfig())
+		if err != nil {
+			return &reconciliation.SubResourceError{Message: "Failed to generate cron job", WrapErr: err, Reason: reconciliation.InternalError}
+		}
+		cronJob.Spec = spec
+		setIstioNativeSidecar(r, skipJob, &cronJob.Spec.JobTemplate.Spec.Template)
+		util.PinImages(&cronJob.Spec.JobTemplate.Spec.Template.Spec, r.PinnedImages())
 		r.AddResource(&cronJob)
 	} else {
-		job.Spec = getJobSpec(&ctxLog, skipJob, job.Spec.Selector, job.Spec.Template.Labels, r.GetSkiperatorConfig())
+		spec, err := getJobSpec(&ctxLog, skipJob, job.Spec.Selector, job.Spec.Template.Labels, r.GetSkiperatorConfig())
+		if err != nil {
+			return &reconciliation.SubResourceError{Message: "Failed to generate job", WrapErr: err, Reason: reconciliation.InternalError}
+		}
+		job.Spec = spec
+		setIstioNativeSidecar(r, skipJob, &job.Spec.Template)
+		util.PinImages(&job.Spec.Template.Spec, r.PinnedImages())
+		// Jobs with unfinished dependencies are created suspended, and started when the dependencies have finished
+		if r.WaitingForDependencies() {
+			job.Spec.Suspend = util.PointTo(true)
+		}
 		r.AddResource(&job)
 	}
 
 	return nil
 }
 
-func getCronJobSpec(logger *log.Logger, skipJob *skiperatorv1beta1.SKIPJob, selector *metav1.LabelSelector, podLabels map[string]string, skiperatorConfig config.SkiperatorConfig) batchv1.CronJobSpec {
+// setIstioNativeSidecar runs the Istio proxy as a native sidecar when the SKIPJob has one-shot init containers,
+// as they would otherwise start before the proxy and have no network access
+func setIstioNativeSidecar(r reconciliation.Reconciliation, skipJob *skiperatorv1beta1.SKIPJob, template *corev1.PodTemplateSpec) {
+	if !r.IsSidecarEnabled() || !pod.HasOneshotContainers(skipJob.Spec.ExtraContainers) {
+		return
+	}
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[resourceutils.AnnotationKeyIstioNativeSidecar] = "true"
+}
+
+func getCronJobSpec(logger *log.Logger, skipJob *skiperatorv1beta1.SKIPJob, selector *metav1.LabelSelector, podLabels map[string]string, skiperatorConfig config.SkiperatorConfig) (batchv1.CronJobSpec, error) {
+	jobSpec, err := getJobSpec(logger, skipJob, selector, podLabels, skiperatorConfig)
+	if err != nil {
+		return batchv1.CronJobSpec{}, err
+	}
 	spec := batchv1.CronJobSpec{
 		Schedule:                skipJob.Spec.Cron.Schedule,
 		TimeZone:                skipJob.Spec.Cron.TimeZone,
@@ -73,7 +110,7 @@ func getCronJobSpec(logger *log.Logger, skipJob *skiperatorv1beta1.SKIPJob, sele
 			ObjectMeta: metav1.ObjectMeta{
 				Labels: skipJob.GetDefaultLabels(),
 			},
-			Spec: getJobSpec(logger, skipJob, selector, podLabels, skiperatorConfig),
+			Spec: jobSpec,
 		},
 		SuccessfulJobsHistoryLimit: util.PointTo(int32(3)),
 		FailedJobsHistoryLimit:     util.PointTo(int32(1)),
@@ -82,12 +119,15 @@ func getCronJobSpec(logger *log.Logger, skipJob *skiperatorv1beta1.SKIPJob, sele
 	// used for selecting workloads by netpols, grafana etc
 	setJobLabels(logger, skipJob, spec.JobTemplate.Labels)
 
-	return spec
+	return spec, nil
 }
 
-func getJobSpec(logger *log.Logger, skipJob *skiperatorv1beta1.SKIPJob, selector *metav1.LabelSelector, podLabels map[string]string, skiperatorConfig config.SkiperatorConfig) batchv1.JobSpec {
+func getJobSpec(logger *log.Logger, skipJob *skiperatorv1beta1.SKIPJob, selector *metav1.LabelSelector, podLabels map[string]string, skiperatorConfig config.SkiperatorConfig) (batchv1.JobSpec, error) {
 	envVars := skipJob.Spec.Env
-	podVolumes := volume.GetPodVolumes(skipJob.Spec.FilesFrom)
+	podVolumes, err := volume.GetPodVolumes(skipJob.Name, skipJob.Spec.FilesFrom)
+	if err != nil {
+		return batchv1.JobSpec{}, err
+	}
 	containerVolumeMounts := volume.GetContainerVolumeMounts(skipJob.Spec.FilesFrom)
 	gcpPodVolume := gcp.GetGCPContainerVolume(skiperatorConfig.GCPWorkloadIdentityPool, skipJob.Name)
 	if skipJob.Spec.GCP != nil {
@@ -99,7 +139,10 @@ func getJobSpec(logger *log.Logger, skipJob *skiperatorv1beta1.SKIPJob, selector
 		envVars = append(envVars, gcpEnvVar)
 	}
 
-	skipJobContainer := pod.CreateJobContainer(skipJob, containerVolumeMounts, envVars)
+	skipJobContainer, err := pod.CreateJobContainer(skipJob, containerVolumeMounts, envVars)
+	if err != nil {
+		return batchv1.JobSpec{}, err
+	}
 
 	containers := []corev1.Container{skipJobContainer}
 	if util.IsCloudSqlProxyEnabled(skipJob.Spec.GCP) {
@@ -107,12 +150,28 @@ func getJobSpec(logger *log.Logger, skipJob *skiperatorv1beta1.SKIPJob, selector
 		containers = append(containers, cloudSqlProxyContainer)
 	}
 
+	// Regular containers would keep the pods running after the job container has exited, so all extra containers
+	// that are not one-shot run as native sidecars, which are stopped when it exits
+	extraContainerSpecs := slices.Clone(skipJob.Spec.ExtraContainers)
+	for i := range extraContainerSpecs {
+		if extraContainerSpecs[i].Type != podtypes.ContainerTypeOneshot {
+			extraContainerSpecs[i].Type = podtypes.ContainerTypeInit
+		}
+	}
+	podOpts := pod.PodOpts{SecurityContext: pod.PodSecurityContext(skipJob.Spec.PodSettings)}
+	_, extraInitContainers, extraVolumes, err := pod.CreateExtraContainers(skipJob.Name, extraContainerSpecs, podOpts)
+	if err != nil {
+		return batchv1.JobSpec{}, err
+	}
+	podVolumes = pod.AppendUniqueVolumes(podVolumes, extraVolumes...)
+
 	jobSpec := batchv1.JobSpec{
 		Parallelism:           util.PointTo(int32(1)),
 		Completions:           util.PointTo(int32(1)),
 		ActiveDeadlineSeconds: skipJob.Spec.Job.ActiveDeadlineSeconds,
-		PodFailurePolicy:      nil,
+		PodFailurePolicy:      util.WithPodFailurePolicyDefaults(skipJob.Spec.Job.PodFailurePolicy),
 		BackoffLimit:          skipJob.Spec.Job.BackoffLimit,
+		BackoffLimitPerIndex:  skipJob.Spec.Job.BackoffLimitPerIndex,
 		Selector:              nil,
 		ManualSelector:        nil,
 		Template: corev1.PodTemplateSpec{
@@ -133,13 +192,54 @@ func getJobSpec(logger *log.Logger, skipJob *skiperatorv1beta1.SKIPJob, selector
 		CompletionMode:          util.PointTo(batchv1.NonIndexedCompletion),
 		Suspend:                 skipJob.Spec.Job.Suspend,
 	}
+	jobSpec.Template.Spec.InitContainers = extraInitContainers
+
+	if skipJob.Spec.Job.Parallelism != nil {
+		jobSpec.Parallelism = skipJob.Spec.Job.Parallelism
+	}
+	if skipJob.Spec.Job.Completions != nil {
+		jobSpec.Completions = skipJob.Spec.Job.Completions
+	}
+	if skipJob.Spec.Job.CompletionMode != nil {
+		jobSpec.CompletionMode = skipJob.Spec.Job.CompletionMode
+	}
 
 	// it's not a default label, maybe it could be?
 	// used for selecting workloads by netpols, grafana etc
 
 	setJobLabels(logger, skipJob, jobSpec.Template.Labels)
 
-	return jobSpec
+	return jobSpec, nil
+}
+
+// FromCronJob creates a Job from the job template of the CronJob, like kubectl create job --from=cronjob.
+// The Job is owned by the CronJob, so it is cleaned up with the history of the CronJob and left alone by the
+// resource processor. The name is derived from the trigger, so a trigger only ever starts one Job.
+func FromCronJob(cronJob *batchv1.CronJob, trigger string) *batchv1.Job {
+	// Job names must fit in the job-name label of the Pods
+	prefix := cronJob.Name
+	if maxLength := validation.DNS1123LabelMaxLength - len("-manual-") - 8; len(prefix) > maxLength {
+		prefix = strings.TrimSuffix(prefix[:maxLength], "-")
+	}
+	name := fmt.Sprintf("%s-manual-%s", prefix, fmt.Sprintf("%x", sha256.Sum256([]byte(trigger)))[:8])
+
+	annotations := maps.Clone(cronJob.Spec.JobTemplate.Annotations)
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations["cronjob.kubernetes.io/instantiate"] = "manual"
+	annotations[skiperatorv1beta1.TriggerAnnotationKey] = trigger
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       cronJob.Namespace,
+			Labels:          maps.Clone(cronJob.Spec.JobTemplate.Labels),
+			Annotations:     annotations,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob"))},
+		},
+		Spec: *cronJob.Spec.JobTemplate.Spec.DeepCopy(),
+	}
 }
 
 func setJobLabels(logger *log.Logger, skipJob *skiperatorv1beta1.SKIPJob, labels map[string]string) {
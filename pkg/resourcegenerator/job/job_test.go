@@ -0,0 +1,186 @@
+package job
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kartverket/skiperator/api/common/podtypes"
+	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
+	"github.com/kartverket/skiperator/internal/config"
+	"github.com/kartverket/skiperator/pkg/log"
+	"github.com/kartverket/skiperator/pkg/mesh"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestSKIPJob() *skiperatorv1beta1.SKIPJob {
+	skipJob := &skiperatorv1beta1.SKIPJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "render-tiles", Namespace: "test"},
+		Spec:       skiperatorv1beta1.SKIPJobSpec{Image: "image", RestartPolicy: util.PointTo(corev1.RestartPolicyNever)},
+	}
+	skipJob.FillDefaultSpec()
+	return skipJob
+}
+
+func TestGetJobSpec_DefaultsToSingleNonIndexedPod(t *testing.T) {
+	logger := log.NewLogger()
+	skipJob := newTestSKIPJob()
+
+	spec, err := getJobSpec(&logger, skipJob, nil, nil, config.SkiperatorConfig{})
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), *spec.Parallelism)
+	assert.Equal(t, int32(1), *spec.Completions)
+	assert.Equal(t, batchv1.NonIndexedCompletion, *spec.CompletionMode)
+}
+
+func TestGetJobSpec_Indexed(t *testing.T) {
+	logger := log.NewLogger()
+	skipJob := newTestSKIPJob()
+	skipJob.Spec.Job.Parallelism = util.PointTo(int32(4))
+	skipJob.Spec.Job.Completions = util.PointTo(int32(16))
+	skipJob.Spec.Job.CompletionMode = util.PointTo(batchv1.IndexedCompletion)
+
+	spec, err := getJobSpec(&logger, skipJob, nil, nil, config.SkiperatorConfig{})
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(4), *spec.Parallelism)
+	assert.Equal(t, int32(16), *spec.Completions)
+	assert.Equal(t, batchv1.IndexedCompletion, *spec.CompletionMode)
+}
+
+func TestGetJobSpec_PodFailurePolicy(t *testing.T) {
+	logger := log.NewLogger()
+	skipJob := newTestSKIPJob()
+	skipJob.Spec.Job.PodFailurePolicy = &batchv1.PodFailurePolicy{Rules: []batchv1.PodFailurePolicyRule{
+		{
+			Action:      batchv1.PodFailurePolicyActionFailJob,
+			OnExitCodes: &batchv1.PodFailurePolicyOnExitCodesRequirement{Operator: batchv1.PodFailurePolicyOnExitCodesOpIn, Values: []int32{42}},
+		},
+		{
+			Action:          batchv1.PodFailurePolicyActionIgnore,
+			OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{{Type: corev1.DisruptionTarget}},
+		},
+	}}
+
+	spec, err := getJobSpec(&logger, skipJob, nil, nil, config.SkiperatorConfig{})
+	require.NoError(t, err)
+
+	// The status of onPodConditions patterns is defaulted like the API server does, so the Job is not recreated
+	expected := skipJob.Spec.Job.PodFailurePolicy.DeepCopy()
+	expected.Rules[1].OnPodConditions[0].Status = corev1.ConditionTrue
+	assert.Equal(t, expected, spec.PodFailurePolicy)
+	assert.Equal(t, int32(6), *spec.BackoffLimit)
+	assert.Nil(t, spec.BackoffLimitPerIndex)
+}
+
+func TestGetJobSpec_BackoffLimitPerIndexLeavesBackoffLimitUnset(t *testing.T) {
+	logger := log.NewLogger()
+	skipJob := &skiperatorv1beta1.SKIPJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "render-tiles", Namespace: "test"},
+		Spec: skiperatorv1beta1.SKIPJobSpec{
+			Image:         "image",
+			RestartPolicy: util.PointTo(corev1.RestartPolicyNever),
+			Job: &skiperatorv1beta1.JobSettings{
+				Completions:          util.PointTo(int32(8)),
+				CompletionMode:       util.PointTo(batchv1.IndexedCompletion),
+				BackoffLimitPerIndex: util.PointTo(int32(2)),
+			},
+		},
+	}
+	skipJob.FillDefaultSpec()
+
+	spec, err := getJobSpec(&logger, skipJob, nil, nil, config.SkiperatorConfig{})
+	require.NoError(t, err)
+
+	assert.Nil(t, spec.BackoffLimit)
+	assert.Equal(t, int32(2), *spec.BackoffLimitPerIndex)
+}
+
+func TestFromCronJob(t *testing.T) {
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly-export", Namespace: "test", UID: "cronjob-uid"},
+		Spec: batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{skiperatorv1beta1.SKIPJobReferenceLabelKey: "nightly-export"}},
+			Spec:       batchv1.JobSpec{BackoffLimit: util.PointTo(int32(2))},
+		}},
+	}
+
+	job := FromCronJob(cronJob, "2026-10-19T12:00:00Z")
+
+	assert.Regexp(t, `^nightly-export-manual-[0-9a-f]{8}$`, job.Name)
+	assert.Equal(t, "test", job.Namespace)
+	assert.Equal(t, "nightly-export", job.Labels[skiperatorv1beta1.SKIPJobReferenceLabelKey])
+	assert.Equal(t, "manual", job.Annotations["cronjob.kubernetes.io/instantiate"])
+	assert.Equal(t, "2026-10-19T12:00:00Z", job.Annotations[skiperatorv1beta1.TriggerAnnotationKey])
+	assert.Equal(t, "CronJob", job.OwnerReferences[0].Kind)
+	assert.Equal(t, int32(2), *job.Spec.BackoffLimit)
+	assert.Equal(t, job.Name, FromCronJob(cronJob, "2026-10-19T12:00:00Z").Name)
+	assert.NotEqual(t, job.Name, FromCronJob(cronJob, "2026-10-20T12:00:00Z").Name)
+}
+
+func TestFromCronJob_TruncatesLongNames(t *testing.T) {
+	cronJob := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "a-very-long-scheduled-job-name-that-is-fifty-two-chars"}}
+
+	job := FromCronJob(cronJob, "1")
+
+	assert.LessOrEqual(t, len(job.Name), 63)
+	assert.Regexp(t, `^a-very-long-[a-z-]*[a-z]-manual-[0-9a-f]{8}$`, job.Name)
+}
+
+func TestGenerate_SuspendedWhileWaitingForDependencies(t *testing.T) {
+	skipJob := newTestSKIPJob()
+	skipJob.Spec.DependsOn = []string{"extract"}
+	r := reconciliation.NewJobReconciliation(context.TODO(), skipJob, log.NewLogger(), mesh.ModeNone, nil, config.SkiperatorConfig{})
+	r.SetWaitingForDependencies(true)
+
+	assert.NoError(t, Generate(r))
+	assert.True(t, *r.GetResources()[0].(*batchv1.Job).Spec.Suspend)
+
+	r = reconciliation.NewJobReconciliation(context.TODO(), skipJob, log.NewLogger(), mesh.ModeNone, nil, config.SkiperatorConfig{})
+	assert.NoError(t, Generate(r))
+	assert.False(t, *r.GetResources()[0].(*batchv1.Job).Spec.Suspend)
+}
+
+func TestGetJobSpec_ExtraContainersRunAsNativeSidecars(t *testing.T) {
+	logger := log.NewLogger()
+	skipJob := newTestSKIPJob()
+	skipJob.Spec.ExtraContainers = []podtypes.ContainerSpec{
+		{Name: "fixtures", Image: "fixtures:1.0", Type: podtypes.ContainerTypeOneshot},
+		{Name: "postgres", Image: "postgres:17"},
+		{Name: "log-shipper", Image: "fluent-bit:3", Type: podtypes.ContainerTypeInit},
+	}
+
+	spec, err := getJobSpec(&logger, skipJob, nil, nil, config.SkiperatorConfig{})
+	require.NoError(t, err)
+
+	assert.Len(t, spec.Template.Spec.Containers, 1)
+	initContainers := spec.Template.Spec.InitContainers
+	assert.Len(t, initContainers, 3)
+	assert.Equal(t, "fixtures", initContainers[0].Name)
+	assert.Nil(t, initContainers[0].RestartPolicy)
+	assert.Equal(t, "postgres", initContainers[1].Name)
+	assert.Equal(t, corev1.ContainerRestartPolicyAlways, *initContainers[1].RestartPolicy)
+	assert.Equal(t, "log-shipper", initContainers[2].Name)
+	assert.Equal(t, corev1.ContainerRestartPolicyAlways, *initContainers[2].RestartPolicy)
+	// The spec of the SKIPJob is left as it is
+	assert.Empty(t, skipJob.Spec.ExtraContainers[1].Type)
+}
+
+func TestGenerate_IstioNativeSidecarWithOneshotContainers(t *testing.T) {
+	skipJob := newTestSKIPJob()
+	skipJob.Spec.ExtraContainers = []podtypes.ContainerSpec{{Name: "fixtures", Image: "fixtures:1.0", Type: podtypes.ContainerTypeOneshot}}
+
+	r := reconciliation.NewJobReconciliation(context.TODO(), skipJob, log.NewLogger(), mesh.ModeSidecar, nil, config.SkiperatorConfig{})
+	assert.NoError(t, Generate(r))
+	assert.Equal(t, "true", r.GetResources()[0].(*batchv1.Job).Spec.Template.Annotations["sidecar.istio.io/nativeSidecar"])
+
+	r = reconciliation.NewJobReconciliation(context.TODO(), skipJob, log.NewLogger(), mesh.ModeNone, nil, config.SkiperatorConfig{})
+	assert.NoError(t, Generate(r))
+	assert.NotContains(t, r.GetResources()[0].(*batchv1.Job).Spec.Template.Annotations, "sidecar.istio.io/nativeSidecar")
+}
@@ -9,6 +9,7 @@ import (
 	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
 	"github.com/kartverket/skiperator/pkg/mesh"
 	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/predeploy"
 	"github.com/kartverket/skiperator/pkg/util"
 	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -43,6 +44,9 @@ func generateForCommon(r reconciliation.Reconciliation) error {
 	accessPolicy := object.GetCommonSpec().AccessPolicy
 	var ingresses []string
 	var inboundPort int32
+	var tcpIngressRules []networkingv1.NetworkPolicyIngressRule
+	var peerIngressRules []networkingv1.NetworkPolicyIngressRule
+	var peerEgressRules []networkingv1.NetworkPolicyEgressRule
 	if r.GetType() == reconciliation.ApplicationType {
 		application := object.(*skiperatorv1alpha1.Application)
 		ingresses = application.Spec.Ingresses
@@ -50,10 +54,20 @@ func generateForCommon(r reconciliation.Reconciliation) error {
 		// port that actually receives traffic — an extra container's
 		// IngressPort when one fronts the app, otherwise spec.Port.
 		inboundPort = int32(application.IngressTargetPort())
+		tcpIngressRules = getTCPIngressRules(application, r.MeshMode())
+		if application.IsStateful() && application.Spec.Stateful.Network != nil {
+			peerIngressRules, peerEgressRules = getStatefulPeerRules(application, r.MeshMode())
+		}
 	}
 
-	ingressRules := getIngressRules(accessPolicy, ingresses, r.MeshMode(), namespace, inboundPort)
-	egressRules := getEgressRules(accessPolicy, object)
+	ingressRules := slices.Concat(getIngressRules(accessPolicy, ingresses, r.MeshMode(), namespace, inboundPort), tcpIngressRules, peerIngressRules)
+	egressRules := append(getEgressRules(accessPolicy, object), peerEgressRules...)
+
+	if application, ok := object.(*skiperatorv1alpha1.Application); ok && application.Spec.PreDeploy != nil {
+		if preDeployPolicy := getPreDeployNetworkPolicy(application); preDeployPolicy != nil {
+			r.AddResource(preDeployPolicy)
+		}
+	}
 
 	netpolSpec := networkingv1.NetworkPolicySpec{
 		PodSelector: metav1.LabelSelector{MatchLabels: util.GetPodAppSelector(name)},
@@ -73,6 +87,24 @@ func generateForCommon(r reconciliation.Reconciliation) error {
 	return nil
 }
 
+// getPreDeployNetworkPolicy lets the pods of the pre-deploy Job reach the same services as the Application. They
+// do not serve traffic, so the policy has no ingress rules.
+func getPreDeployNetworkPolicy(application *skiperatorv1alpha1.Application) *networkingv1.NetworkPolicy {
+	egressRules := getEgressRules(application.Spec.AccessPolicy, application)
+	if len(egressRules) == 0 {
+		return nil
+	}
+	appLabel := predeploy.PodAppLabel(application.Name)
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: application.Namespace, Name: appLabel},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: util.GetPodAppSelector(appLabel)},
+			Egress:      egressRules,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		},
+	}
+}
+
 func getPolicyTypes(ingressRules []networkingv1.NetworkPolicyIngressRule, egressRules []networkingv1.NetworkPolicyEgressRule) []networkingv1.PolicyType {
 	var policyType []networkingv1.PolicyType
 
@@ -297,6 +329,37 @@ func getGatewayIngressRule(isInternal bool, port int32, meshMode mesh.Mode) netw
 	}
 }
 
+// getTCPIngressRules lets the ingress gateway reach the container port behind
+// each TCP ingress. Traffic to spec.port lands on the ingress-facing port, like
+// HTTP ingresses.
+func getTCPIngressRules(application *skiperatorv1alpha1.Application, meshMode mesh.Mode) []networkingv1.NetworkPolicyIngressRule {
+	var rules []networkingv1.NetworkPolicyIngressRule
+	for _, ingress := range application.Spec.TCPIngresses {
+		port := application.TCPIngressTargetPort(ingress)
+		if port == int32(application.Spec.Port) {
+			port = int32(application.IngressTargetPort())
+		}
+		rules = append(rules, getGatewayIngressRule(util.IsInternal(ingress.Hostname), port, meshMode))
+	}
+	return rules
+}
+
+// getStatefulPeerRules lets the pods of a StatefulSet reach each other on the application port, the additional
+// ports and the peer ports
+func getStatefulPeerRules(application *skiperatorv1alpha1.Application, meshMode mesh.Mode) ([]networkingv1.NetworkPolicyIngressRule, []networkingv1.NetworkPolicyEgressRule) {
+	ports := []networkingv1.NetworkPolicyPort{{Port: new(intstr.FromInt(application.Spec.Port))}}
+	for _, p := range slices.Concat(application.Spec.AdditionalPorts, application.Spec.Stateful.Network.PeerPorts) {
+		ports = append(ports, networkingv1.NetworkPolicyPort{Protocol: new(p.Protocol), Port: new(intstr.FromInt32(p.Port))})
+	}
+	if meshMode == mesh.ModeAmbient {
+		ports = append(ports, networkingv1.NetworkPolicyPort{Protocol: new(v1.ProtocolTCP), Port: new(mesh.ZtunnelInboundPort)})
+	}
+
+	peers := []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: util.GetPodAppSelector(application.Name)}}}
+	return []networkingv1.NetworkPolicyIngressRule{{From: peers, Ports: ports}},
+		[]networkingv1.NetworkPolicyEgressRule{{To: peers, Ports: ports}}
+}
+
 // getInboundPorts restricts an ingress rule to the port that receives traffic.
 // Ambient tunnels mesh traffic to ztunnel's HBONE port instead of the
 // application port, so ambient pods must accept both from the same sources.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/kartverket/skiperator/api/common/podtypes"
 	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
 	"github.com/kartverket/skiperator/internal/config"
 	"github.com/kartverket/skiperator/pkg/log"
@@ -58,3 +59,102 @@ func TestSidecarKeepsApplicationPortOnly(t *testing.T) {
 		{Port: new(intstr.FromInt32(8080))},
 	}, rules[0].Ports)
 }
+
+func TestTCPIngressOpensTargetPortForGateway(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "broker", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Image:           "image",
+			Port:            8080,
+			AdditionalPorts: []skiperatorv1alpha1.InternalPort{{Name: "mqtts", Port: 8883, Protocol: corev1.ProtocolTCP}},
+			TCPIngresses: []skiperatorv1alpha1.TCPIngress{
+				{Hostname: "broker.skip.statkart.no", Port: 8883, TargetPort: 8883},
+			},
+			IstioSettings: &skiperatorv1alpha1.IstioSettingsApplication{},
+		},
+	}
+	application.FillDefaultsSpec()
+	r := reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+
+	require.NoError(t, Generate(r))
+	require.Len(t, r.GetResources(), 1)
+
+	rules := r.GetResources()[0].(*networkingv1.NetworkPolicy).Spec.Ingress
+	require.Len(t, rules, 1)
+	assert.Equal(t, mesh.IngressGatewayLabels(true), rules[0].From[0].PodSelector.MatchLabels)
+	assert.Equal(t, []networkingv1.NetworkPolicyPort{
+		{Port: new(intstr.FromInt32(8883))},
+	}, rules[0].Ports)
+}
+
+func TestPreDeployPodsHaveEgressOnlyNetworkPolicy(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Image:     "image",
+			Port:      8080,
+			Ingresses: []string{"orders.example.com"},
+			PreDeploy: &skiperatorv1alpha1.PreDeploySpec{Command: []string{"./migrate"}},
+			AccessPolicy: &podtypes.AccessPolicy{Outbound: &podtypes.OutboundPolicy{Rules: []podtypes.InternalRule{
+				{Application: "postgres", Ports: []networkingv1.NetworkPolicyPort{{Port: new(intstr.FromInt32(5432))}}},
+			}}},
+			IstioSettings: &skiperatorv1alpha1.IstioSettingsApplication{},
+		},
+	}
+	application.FillDefaultsSpec()
+	r := reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeNone, nil, nil, config.SkiperatorConfig{})
+
+	require.NoError(t, Generate(r))
+	policies := map[string]*networkingv1.NetworkPolicy{}
+	for _, resource := range r.GetResources() {
+		policies[resource.GetName()] = resource.(*networkingv1.NetworkPolicy)
+	}
+	require.Len(t, policies, 2)
+
+	// The ingress rules of the Application do not apply to the pre-deploy pods
+	app := policies["orders"]
+	assert.Equal(t, map[string]string{"app": "orders"}, app.Spec.PodSelector.MatchLabels)
+	assert.NotEmpty(t, app.Spec.Ingress)
+
+	preDeploy := policies["orders-predeploy"]
+	require.NotNil(t, preDeploy)
+	assert.Equal(t, map[string]string{"app": "orders-predeploy"}, preDeploy.Spec.PodSelector.MatchLabels)
+	assert.Empty(t, preDeploy.Spec.Ingress)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}, preDeploy.Spec.PolicyTypes)
+	assert.Equal(t, app.Spec.Egress, preDeploy.Spec.Egress)
+}
+
+func TestStatefulPeersReachEachOther(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "team-a"},
+		Spec: skiperatorv1alpha1.ApplicationSpec{
+			Image:           "image",
+			Port:            9092,
+			AdditionalPorts: []skiperatorv1alpha1.InternalPort{{Name: "jmx", Port: 9999, Protocol: corev1.ProtocolTCP}},
+			Stateful: &skiperatorv1alpha1.StatefulSpec{
+				Enabled: true,
+				Network: &skiperatorv1alpha1.StatefulNetwork{
+					PeerPorts: []skiperatorv1alpha1.InternalPort{{Name: "controller", Port: 9093, Protocol: corev1.ProtocolTCP}},
+				},
+			},
+			IstioSettings: &skiperatorv1alpha1.IstioSettingsApplication{},
+		},
+	}
+	application.FillDefaultsSpec()
+	r := reconciliation.NewApplicationReconciliation(context.Background(), application, log.NewLogger(), mesh.ModeAmbient, nil, nil, config.SkiperatorConfig{})
+
+	require.NoError(t, Generate(r))
+	require.Len(t, r.GetResources(), 1)
+	spec := r.GetResources()[0].(*networkingv1.NetworkPolicy).Spec
+
+	peers := []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "kafka"}}}}
+	ports := []networkingv1.NetworkPolicyPort{
+		{Port: new(intstr.FromInt32(9092))},
+		{Protocol: new(corev1.ProtocolTCP), Port: new(intstr.FromInt32(9999))},
+		{Protocol: new(corev1.ProtocolTCP), Port: new(intstr.FromInt32(9093))},
+		{Protocol: new(corev1.ProtocolTCP), Port: new(mesh.ZtunnelInboundPort)},
+	}
+	assert.Contains(t, spec.Ingress, networkingv1.NetworkPolicyIngressRule{From: peers, Ports: ports})
+	assert.Contains(t, spec.Egress, networkingv1.NetworkPolicyEgressRule{To: peers, Ports: ports})
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}, spec.PolicyTypes)
+}
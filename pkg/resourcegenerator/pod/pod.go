@@ -2,12 +2,14 @@ package pod
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/kartverket/skiperator/api/common/podtypes"
 	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
 	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
 	"github.com/kartverket/skiperator/internal/config"
 	"github.com/kartverket/skiperator/pkg/mesh"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/externalsecret"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/volume"
 	"github.com/kartverket/skiperator/pkg/util"
 	"github.com/kartverket/skiperator/pkg/util/array"
@@ -23,6 +25,17 @@ const (
 type PodOpts struct {
 	IstioEnabled     bool
 	LocalBuiltImages bool
+	// SecurityContext holds the pod-wide overrides from podSettings, applied to the
+	// main container and extra containers.
+	SecurityContext *podtypes.PodSecurityContext
+}
+
+// PodSecurityContext returns the security context overrides from podSettings, if any.
+func PodSecurityContext(podSettings *podtypes.PodSettings) *podtypes.PodSecurityContext {
+	if podSettings == nil {
+		return nil
+	}
+	return podSettings.SecurityContext
 }
 
 func (po *PodOpts) ImagePullPolicy() corev1.PullPolicy {
@@ -55,7 +68,7 @@ func CreatePodSpec(containers []corev1.Container, volumes []corev1.Volume, servi
 		HostIPC:                       false,
 		SecurityContext: &corev1.PodSecurityContext{
 			SupplementalGroups: []int64{util.SkiperatorUser},
-			FSGroup:            new(util.SkiperatorUser),
+			FSGroup:            getFSGroup(podSettings),
 			SeccompProfile: &corev1.SeccompProfile{
 				Type: corev1.SeccompProfileTypeRuntimeDefault,
 			},
@@ -65,8 +78,14 @@ func CreatePodSpec(containers []corev1.Container, volumes []corev1.Volume, servi
 		PriorityClassName: fmt.Sprintf("skip-%s", priority),
 	}
 
+	p.NodeSelector = podSettings.NodeSelector
+	p.Tolerations = podSettings.Tolerations
+	p.Affinity = podSettings.Affinity
+
 	// Allow override per application
-	if !podSettings.DisablePodSpreadTopologyConstraints {
+	if len(podSettings.TopologySpreadConstraints) > 0 {
+		p.TopologySpreadConstraints = podSettings.TopologySpreadConstraints
+	} else if !podSettings.DisablePodSpreadTopologyConstraints {
 		keys := array.TrimmedUniqueStrings(config.GetActiveConfig().TopologyKeys)
 		constraints := make([]corev1.TopologySpreadConstraint, 0, len(keys))
 
@@ -80,6 +99,13 @@ func CreatePodSpec(containers []corev1.Container, volumes []corev1.Volume, servi
 	return p
 }
 
+func getFSGroup(podSettings *podtypes.PodSettings) *int64 {
+	if podSettings.SecurityContext != nil && podSettings.SecurityContext.FSGroup != nil {
+		return new(*podSettings.SecurityContext.FSGroup)
+	}
+	return new(util.SkiperatorUser)
+}
+
 // defaultSecurityContext returns the hardened, least-privilege security context
 // Skiperator applies to every container it manages (the main application
 // container, the CloudSQL proxy and user-provided extra containers). A fresh
@@ -97,6 +123,35 @@ func defaultSecurityContext(allowPrivilegedPorts bool) *corev1.SecurityContext {
 	return sc
 }
 
+// containerSecurityContext applies the user's overrides on top of the hardened
+// default. Overrides on the container take precedence over the pod-wide ones from
+// podSettings. Policy checks happen in the reconcilers before generation.
+func containerSecurityContext(allowPrivilegedPorts bool, podOverrides *podtypes.PodSecurityContext, overrides *podtypes.ContainerSecurityContext) *corev1.SecurityContext {
+	sc := defaultSecurityContext(allowPrivilegedPorts)
+	if podOverrides != nil {
+		if podOverrides.RunAsUser != nil {
+			sc.RunAsUser = new(*podOverrides.RunAsUser)
+		}
+		if podOverrides.RunAsGroup != nil {
+			sc.RunAsGroup = new(*podOverrides.RunAsGroup)
+		}
+	}
+	if overrides != nil {
+		if overrides.RunAsUser != nil {
+			sc.RunAsUser = new(*overrides.RunAsUser)
+		}
+		if overrides.RunAsGroup != nil {
+			sc.RunAsGroup = new(*overrides.RunAsGroup)
+		}
+		for _, capability := range overrides.AddCapabilities {
+			if !slices.Contains(sc.Capabilities.Add, capability) {
+				sc.Capabilities.Add = append(sc.Capabilities.Add, capability)
+			}
+		}
+	}
+	return sc
+}
+
 // bindsPrivilegedPort reports whether any of the container's declared ports is
 // below 1024 and therefore requires NET_BIND_SERVICE. IngressPort is always one
 // of AdditionalPorts (enforced by validation), so checking AdditionalPorts is
@@ -110,37 +165,89 @@ func bindsPrivilegedPort(spec podtypes.ContainerSpec) bool {
 	return false
 }
 
-func CreateApplicationContainer(application *skiperatorv1alpha1.Application, opts PodOpts) corev1.Container {
+func CreateApplicationContainer(application *skiperatorv1alpha1.Application, opts PodOpts) (corev1.Container, error) {
+	envFrom, err := getEnvFrom(application.Name, application.Spec.EnvFrom)
+	if err != nil {
+		return corev1.Container{}, err
+	}
 	return corev1.Container{
 		Name:                     application.Name,
 		Image:                    application.Spec.Image,
 		ImagePullPolicy:          opts.ImagePullPolicy(),
 		Command:                  application.Spec.Command,
-		SecurityContext:          defaultSecurityContext(true),
+		SecurityContext:          containerSecurityContext(true, opts.SecurityContext, application.Spec.SecurityContext),
 		Ports:                    getContainerPorts(application, opts),
-		EnvFrom:                  getEnvFrom(application.Spec.EnvFrom),
+		EnvFrom:                  envFrom,
 		Resources:                getResourceRequirements(application.Spec.Resources),
-		Env:                      getEnv(application.Spec.Env),
+		Env:                      slices.Concat(getEnv(application.Spec.Env), getEnvFromKeys(application.Spec.EnvFromKeys)),
 		ReadinessProbe:           getProbe(application.Spec.Readiness),
 		LivenessProbe:            getProbe(application.Spec.Liveness),
 		StartupProbe:             getProbe(application.Spec.Startup),
+		Lifecycle:                getLifecycle(application.Spec.Lifecycle, application.Spec.GracefulShutdown),
+		TerminationMessagePath:   corev1.TerminationMessagePathDefault,
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}, nil
+}
+
+// CreatePreDeployContainer creates the container of the pre-deploy Job, which runs with the environment of the
+// application container, without its ports, probes and lifecycle hooks.
+func CreatePreDeployContainer(application *skiperatorv1alpha1.Application, opts PodOpts) (corev1.Container, error) {
+	resources := application.Spec.Resources
+	if application.Spec.PreDeploy.Resources != nil {
+		resources = application.Spec.PreDeploy.Resources
+	}
+	envFrom, err := getEnvFrom(application.Name, application.Spec.EnvFrom)
+	if err != nil {
+		return corev1.Container{}, err
+	}
+	return corev1.Container{
+		Name:                     "predeploy",
+		Image:                    application.PreDeployImage(),
+		ImagePullPolicy:          opts.ImagePullPolicy(),
+		Command:                  application.Spec.PreDeploy.Command,
+		SecurityContext:          containerSecurityContext(false, opts.SecurityContext, application.Spec.SecurityContext),
+		EnvFrom:                  envFrom,
+		Resources:                getResourceRequirements(resources),
+		Env:                      slices.Concat(getEnv(application.Spec.Env), getEnvFromKeys(application.Spec.EnvFromKeys)),
 		TerminationMessagePath:   corev1.TerminationMessagePathDefault,
 		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}, nil
+}
+
+// ApplyGracefulShutdown extends the pod's termination grace period by the drain
+// time, so the preStop sleep does not eat into the application's own shutdown budget.
+func ApplyGracefulShutdown(spec *corev1.PodSpec, gracefulShutdown *podtypes.GracefulShutdown) {
+	if gracefulShutdown == nil {
+		return
 	}
+	gracePeriod := gracefulShutdown.DrainSeconds
+	if spec.TerminationGracePeriodSeconds != nil {
+		gracePeriod += *spec.TerminationGracePeriodSeconds
+	}
+	spec.TerminationGracePeriodSeconds = new(gracePeriod)
 }
 
 // CreateExtraContainers turns the user-provided ExtraContainers specs into pod
 // containers. Standard entries are returned as sidecars (appended to
 // PodSpec.Containers), while init entries become native sidecars (init
-// containers with restartPolicy: Always) returned separately. Volumes derived
+// containers with restartPolicy: Always) and oneshot entries regular init
+// containers, returned separately in the declared order. Volumes derived
 // from each container's FilesFrom are returned as-is; callers merge them with
 // the pod's existing volumes via AppendUniqueVolumes, which deduplicates by
-// name across the whole pod.
-func CreateExtraContainers(specs []podtypes.ContainerSpec, opts PodOpts) (sidecars []corev1.Container, initContainers []corev1.Container, volumes []corev1.Volume) {
+// name across the whole pod. ownerName is the name of the workload, which Secrets
+// synced from secret stores are named after.
+func CreateExtraContainers(ownerName string, specs []podtypes.ContainerSpec, opts PodOpts) (sidecars []corev1.Container, initContainers []corev1.Container, volumes []corev1.Volume, err error) {
 	for _, spec := range specs {
-		containerVolumes := volume.GetPodVolumes(spec.FilesFrom)
+		containerVolumes, err := volume.GetPodVolumes(ownerName, spec.FilesFrom)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 		volumeMounts := volume.GetContainerVolumeMounts(spec.FilesFrom)
 		volumes = append(volumes, containerVolumes...)
+		envFrom, err := getEnvFrom(ownerName, spec.EnvFrom)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 
 		container := corev1.Container{
 			Name:                     spec.Name,
@@ -148,9 +255,9 @@ func CreateExtraContainers(specs []podtypes.ContainerSpec, opts PodOpts) (sideca
 			ImagePullPolicy:          opts.ImagePullPolicy(),
 			Command:                  spec.Command,
 			Args:                     spec.Args,
-			SecurityContext:          defaultSecurityContext(bindsPrivilegedPort(spec)),
+			SecurityContext:          containerSecurityContext(bindsPrivilegedPort(spec), opts.SecurityContext, spec.SecurityContext),
 			Ports:                    getInternalContainerPorts(spec.AdditionalPorts),
-			EnvFrom:                  getEnvFrom(spec.EnvFrom),
+			EnvFrom:                  envFrom,
 			Env:                      getEnv(spec.Env),
 			Resources:                getResourceRequirements(spec.Resources),
 			ReadinessProbe:           getProbe(spec.Readiness),
@@ -161,17 +268,30 @@ func CreateExtraContainers(specs []podtypes.ContainerSpec, opts PodOpts) (sideca
 			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
 		}
 
-		if spec.Type == podtypes.ContainerTypeInit {
+		switch spec.Type {
+		case podtypes.ContainerTypeInit:
 			// Native sidecar: an init container that keeps running for the
 			// lifetime of the pod.
 			container.RestartPolicy = new(corev1.ContainerRestartPolicyAlways)
 			initContainers = append(initContainers, container)
-		} else {
+		case podtypes.ContainerTypeOneshot:
+			// Runs to completion before the next init container and the main
+			// container start.
+			initContainers = append(initContainers, container)
+		default:
 			sidecars = append(sidecars, container)
 		}
 	}
 
-	return sidecars, initContainers, volumes
+	return sidecars, initContainers, volumes, nil
+}
+
+// HasOneshotContainers reports whether any of the extra containers runs to
+// completion before the main container.
+func HasOneshotContainers(specs []podtypes.ContainerSpec) bool {
+	return slices.ContainsFunc(specs, func(spec podtypes.ContainerSpec) bool {
+		return spec.Type == podtypes.ContainerTypeOneshot
+	})
 }
 
 // AppendUniqueVolumes appends the given volumes to existing, skipping any whose
@@ -218,10 +338,6 @@ func CreateCloudSqlProxyContainer(cs *podtypes.CloudSQLProxySettings) corev1.Con
 		args = append(args, "--private-ip") // Forces the use of private IP
 	}
 
-	if cs.Version == "" {
-		cs.Version = DefaultCloudSQLProxyVersion
-	}
-
 	// The CloudSQL proxy binds only high ports (5432, 9090, 9091), so it does
 	// not need NET_BIND_SERVICE. It also runs as its own dedicated UID/GID.
 	googleSC := defaultSecurityContext(false)
@@ -230,7 +346,7 @@ func CreateCloudSqlProxyContainer(cs *podtypes.CloudSQLProxySettings) corev1.Con
 
 	return corev1.Container{
 		Name:            "cloudsql-proxy",
-		Image:           "gcr.io/cloud-sql-connectors/cloud-sql-proxy:" + cs.Version,
+		Image:           CloudSQLProxyImage(cs),
 		ImagePullPolicy: corev1.PullAlways,
 		Args:            args,
 		SecurityContext: googleSC,
@@ -245,25 +361,41 @@ func CreateCloudSqlProxyContainer(cs *podtypes.CloudSQLProxySettings) corev1.Con
 	}
 }
 
-func CreateJobContainer(skipJob *skiperatorv1beta1.SKIPJob, volumeMounts []corev1.VolumeMount, envVars []corev1.EnvVar) corev1.Container {
+// CloudSQLProxyImage returns the image of the CloudSQL proxy sidecar, falling back
+// to DefaultCloudSQLProxyVersion when no version is set.
+func CloudSQLProxyImage(cs *podtypes.CloudSQLProxySettings) string {
+	version := cs.Version
+	if version == "" {
+		version = DefaultCloudSQLProxyVersion
+	}
+	return "gcr.io/cloud-sql-connectors/cloud-sql-proxy:" + version
+}
+
+func CreateJobContainer(skipJob *skiperatorv1beta1.SKIPJob, volumeMounts []corev1.VolumeMount, envVars []corev1.EnvVar) (corev1.Container, error) {
+	envFrom, err := getEnvFrom(skipJob.Name, skipJob.Spec.EnvFrom)
+	if err != nil {
+		return corev1.Container{}, err
+	}
 	return corev1.Container{
 		Name:                     skipJob.KindPostFixedName(),
 		Image:                    skipJob.Spec.Image,
 		ImagePullPolicy:          corev1.PullAlways,
 		Command:                  skipJob.Spec.Command,
-		SecurityContext:          &util.LeastPrivilegeContainerSecurityContext,
-		EnvFrom:                  getEnvFrom(skipJob.Spec.EnvFrom),
+		SecurityContext:          containerSecurityContext(false, PodSecurityContext(skipJob.Spec.PodSettings), skipJob.Spec.SecurityContext),
+		EnvFrom:                  envFrom,
 		Resources:                getResourceRequirements(skipJob.Spec.Resources),
-		Env:                      envVars,
+		Env:                      slices.Concat(envVars, getEnvFromKeys(skipJob.Spec.EnvFromKeys)),
 		ReadinessProbe:           getProbe(skipJob.Spec.Readiness),
 		LivenessProbe:            getProbe(skipJob.Spec.Liveness),
 		StartupProbe:             getProbe(skipJob.Spec.Startup),
 		TerminationMessagePath:   corev1.TerminationMessagePathDefault,
 		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
 		VolumeMounts:             volumeMounts,
-	}
+	}, nil
 }
 
+// getProbe is used for every probe Skiperator generates: Application, SKIPJob
+// and extra containers alike.
 func getProbe(appProbe *podtypes.Probe) *corev1.Probe {
 	if appProbe != nil {
 		probe := corev1.Probe{
@@ -272,13 +404,7 @@ func getProbe(appProbe *podtypes.Probe) *corev1.Probe {
 			FailureThreshold:    appProbe.FailureThreshold,
 			SuccessThreshold:    appProbe.SuccessThreshold,
 			PeriodSeconds:       appProbe.Period,
-			ProbeHandler: corev1.ProbeHandler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path:   appProbe.Path,
-					Port:   appProbe.Port,
-					Scheme: corev1.URISchemeHTTP,
-				},
-			},
+			ProbeHandler:        getProbeHandler(appProbe),
 		}
 		return &probe
 	}
@@ -286,6 +412,73 @@ func getProbe(appProbe *podtypes.Probe) *corev1.Probe {
 	return nil
 }
 
+// getProbeHandler picks the handler for the probe variant. The CRD ensures
+// exactly one variant is set, and an HTTP GET on path is the default.
+func getProbeHandler(appProbe *podtypes.Probe) corev1.ProbeHandler {
+	switch {
+	case appProbe.Exec != nil:
+		return corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: appProbe.Exec.Command},
+		}
+	case appProbe.TCPSocket != nil:
+		return corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: appProbe.Port},
+		}
+	case appProbe.GRPC != nil:
+		return corev1.ProbeHandler{
+			GRPC: &corev1.GRPCAction{
+				Port:    int32(appProbe.Port.IntValue()),
+				Service: appProbe.GRPC.Service,
+			},
+		}
+	default:
+		return corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:   appProbe.Path,
+				Port:   appProbe.Port,
+				Scheme: corev1.URISchemeHTTP,
+			},
+		}
+	}
+}
+
+// getLifecycle builds the container lifecycle hooks. GracefulShutdown is a shorthand
+// for a preStop sleep; the CRD ensures it is not combined with lifecycle.preStop.
+func getLifecycle(lifecycle *podtypes.Lifecycle, gracefulShutdown *podtypes.GracefulShutdown) *corev1.Lifecycle {
+	result := &corev1.Lifecycle{}
+	if lifecycle != nil {
+		result.PreStop = getLifecycleHandler(lifecycle.PreStop)
+		result.PostStart = getLifecycleHandler(lifecycle.PostStart)
+	}
+	if gracefulShutdown != nil {
+		result.PreStop = &corev1.LifecycleHandler{
+			Sleep: &corev1.SleepAction{Seconds: gracefulShutdown.DrainSeconds},
+		}
+	}
+
+	if result.PreStop == nil && result.PostStart == nil {
+		return nil
+	}
+	return result
+}
+
+func getLifecycleHandler(handler *podtypes.LifecycleHandler) *corev1.LifecycleHandler {
+	switch {
+	case handler == nil:
+		return nil
+	case handler.Exec != nil:
+		return &corev1.LifecycleHandler{
+			Exec: &corev1.ExecAction{Command: handler.Exec.Command},
+		}
+	case handler.SleepSeconds != nil:
+		return &corev1.LifecycleHandler{
+			Sleep: &corev1.SleepAction{Seconds: *handler.SleepSeconds},
+		}
+	default:
+		return nil
+	}
+}
+
 func getResourceRequirements(resources *podtypes.ResourceRequirements) corev1.ResourceRequirements {
 	if resources == nil {
 		return corev1.ResourceRequirements{}
@@ -297,7 +490,7 @@ func getResourceRequirements(resources *podtypes.ResourceRequirements) corev1.Re
 	}
 }
 
-func getEnvFrom(envFromApplication []podtypes.EnvFrom) []corev1.EnvFromSource {
+func getEnvFrom(ownerName string, envFromApplication []podtypes.EnvFrom) ([]corev1.EnvFromSource, error) {
 	var envFromSource []corev1.EnvFromSource
 
 	for _, env := range envFromApplication {
@@ -311,6 +504,20 @@ func getEnvFrom(envFromApplication []podtypes.EnvFrom) []corev1.EnvFromSource {
 					},
 				},
 			)
+		} else if env.SecretStore != nil {
+			secretName, err := externalsecret.SecretName(ownerName, env.SecretStore)
+			if err != nil {
+				return nil, err
+			}
+			envFromSource = append(envFromSource,
+				corev1.EnvFromSource{
+					SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: secretName,
+						},
+					},
+				},
+			)
 		} else if len(env.Secret) > 0 {
 			envFromSource = append(envFromSource,
 				corev1.EnvFromSource{
@@ -324,7 +531,27 @@ func getEnvFrom(envFromApplication []podtypes.EnvFrom) []corev1.EnvFromSource {
 		}
 	}
 
-	return envFromSource
+	return envFromSource, nil
+}
+
+func getEnvFromKeys(envFromKeys []podtypes.EnvFromKey) []corev1.EnvVar {
+	var envVars []corev1.EnvVar
+	for _, envFromKey := range envFromKeys {
+		source := &corev1.EnvVarSource{}
+		if len(envFromKey.ConfigMap) > 0 {
+			source.ConfigMapKeyRef = &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: envFromKey.ConfigMap},
+				Key:                  envFromKey.Key,
+			}
+		} else {
+			source.SecretKeyRef = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: envFromKey.Secret},
+				Key:                  envFromKey.Key,
+			}
+		}
+		envVars = append(envVars, corev1.EnvVar{Name: envFromKey.As, ValueFrom: source})
+	}
+	return envVars
 }
 
 func getEnv(variables []corev1.EnvVar) []corev1.EnvVar {
@@ -4,8 +4,14 @@ import (
 	"testing"
 
 	"github.com/kartverket/skiperator/api/common/podtypes"
+	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/externalsecret"
+	"github.com/kartverket/skiperator/pkg/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestCreateExtraContainers_SplitsByType(t *testing.T) {
@@ -14,7 +20,8 @@ func TestCreateExtraContainers_SplitsByType(t *testing.T) {
 		{Name: "config-loader", Image: "loader:1.0", Type: podtypes.ContainerTypeInit},
 	}
 
-	sidecars, initContainers, _ := CreateExtraContainers(specs, PodOpts{})
+	sidecars, initContainers, _, err := CreateExtraContainers("app", specs, PodOpts{})
+	require.NoError(t, err)
 
 	assert.Len(t, sidecars, 1)
 	assert.Equal(t, "logging-agent", sidecars[0].Name)
@@ -27,10 +34,37 @@ func TestCreateExtraContainers_SplitsByType(t *testing.T) {
 	}
 }
 
+func TestCreateExtraContainers_OneshotRunsToCompletionInDeclaredOrder(t *testing.T) {
+	specs := []podtypes.ContainerSpec{
+		{Name: "migrate", Image: "migrate:1.0", Type: podtypes.ContainerTypeOneshot},
+		{Name: "config-loader", Image: "loader:1.0", Type: podtypes.ContainerTypeInit},
+		{Name: "warm-cache", Image: "warm:1.0", Type: podtypes.ContainerTypeOneshot},
+	}
+
+	sidecars, initContainers, _, err := CreateExtraContainers("app", specs, PodOpts{})
+	require.NoError(t, err)
+
+	assert.Empty(t, sidecars)
+	assert.Len(t, initContainers, 3)
+	assert.Equal(t, "migrate", initContainers[0].Name)
+	assert.Nil(t, initContainers[0].RestartPolicy)
+	assert.Equal(t, "config-loader", initContainers[1].Name)
+	assert.NotNil(t, initContainers[1].RestartPolicy)
+	assert.Equal(t, "warm-cache", initContainers[2].Name)
+	assert.Nil(t, initContainers[2].RestartPolicy)
+}
+
+func TestHasOneshotContainers(t *testing.T) {
+	assert.False(t, HasOneshotContainers(nil))
+	assert.False(t, HasOneshotContainers([]podtypes.ContainerSpec{{Name: "side"}, {Name: "init", Type: podtypes.ContainerTypeInit}}))
+	assert.True(t, HasOneshotContainers([]podtypes.ContainerSpec{{Name: "migrate", Type: podtypes.ContainerTypeOneshot}}))
+}
+
 func TestCreateExtraContainers_EnforcesSecurityContext(t *testing.T) {
-	sidecars, _, _ := CreateExtraContainers([]podtypes.ContainerSpec{
+	sidecars, _, _, err := CreateExtraContainers("app", []podtypes.ContainerSpec{
 		{Name: "side", Image: "side:1.0"},
 	}, PodOpts{})
+	require.NoError(t, err)
 
 	sc := sidecars[0].SecurityContext
 	if assert.NotNil(t, sc) {
@@ -48,7 +82,8 @@ func TestCreateExtraContainers_NetBindServiceOnlyForPrivilegedPorts(t *testing.T
 		{Name: "low-port", Image: "x:1.0", AdditionalPorts: []podtypes.InternalPort{{Name: "p", Port: 443}}},
 	}
 
-	sidecars, _, _ := CreateExtraContainers(specs, PodOpts{})
+	sidecars, _, _, err := CreateExtraContainers("app", specs, PodOpts{})
+	require.NoError(t, err)
 
 	byName := map[string]corev1.Container{}
 	for _, c := range sidecars {
@@ -66,7 +101,8 @@ func TestCreateExtraContainers_VolumesDedupedByAppendUniqueVolumes(t *testing.T)
 		{Name: "b", Image: "b:1.0", FilesFrom: []podtypes.FilesFrom{{MountPath: "/etc/cfg", ConfigMap: "shared"}}},
 	}
 
-	_, _, volumes := CreateExtraContainers(specs, PodOpts{})
+	_, _, volumes, err := CreateExtraContainers("app", specs, PodOpts{})
+	require.NoError(t, err)
 
 	// Merging through AppendUniqueVolumes (as the deployment/statefulset callers
 	// do) is the single dedup point across the whole pod.
@@ -83,7 +119,8 @@ func TestCreateExtraContainers_VolumesDedupedByAppendUniqueVolumes(t *testing.T)
 }
 
 func TestCreateExtraContainers_Empty(t *testing.T) {
-	sidecars, initContainers, volumes := CreateExtraContainers(nil, PodOpts{})
+	sidecars, initContainers, volumes, err := CreateExtraContainers("app", nil, PodOpts{})
+	require.NoError(t, err)
 	assert.Empty(t, sidecars)
 	assert.Empty(t, initContainers)
 	assert.Empty(t, volumes)
@@ -92,10 +129,12 @@ func TestCreateExtraContainers_Empty(t *testing.T) {
 func TestCreateExtraContainers_ImagePullPolicyFromOpts(t *testing.T) {
 	specs := []podtypes.ContainerSpec{{Name: "side", Image: "side:1.0"}}
 
-	remote, _, _ := CreateExtraContainers(specs, PodOpts{})
+	remote, _, _, err := CreateExtraContainers("app", specs, PodOpts{})
+	require.NoError(t, err)
 	assert.Equal(t, corev1.PullAlways, remote[0].ImagePullPolicy)
 
-	local, _, _ := CreateExtraContainers(specs, PodOpts{LocalBuiltImages: true})
+	local, _, _, err := CreateExtraContainers("app", specs, PodOpts{LocalBuiltImages: true})
+	require.NoError(t, err)
 	assert.Equal(t, corev1.PullNever, local[0].ImagePullPolicy)
 }
 
@@ -111,3 +150,218 @@ func TestAppendUniqueVolumes(t *testing.T) {
 	names := []string{result[0].Name, result[1].Name, result[2].Name}
 	assert.Equal(t, []string{"tmp", "shared", "new"}, names)
 }
+
+func TestGetProbe_HTTP(t *testing.T) {
+	probe := getProbe(&podtypes.Probe{Port: intstr.FromInt32(8080), Path: "/healthz", Timeout: 1})
+
+	if assert.NotNil(t, probe.HTTPGet) {
+		assert.Equal(t, "/healthz", probe.HTTPGet.Path)
+		assert.Equal(t, intstr.FromInt32(8080), probe.HTTPGet.Port)
+	}
+	assert.Nil(t, probe.GRPC)
+	assert.Equal(t, int32(1), probe.TimeoutSeconds)
+}
+
+func TestGetProbe_GRPC(t *testing.T) {
+	service := "orders.v1.Orders"
+	probe := getProbe(&podtypes.Probe{Port: intstr.FromInt32(9090), GRPC: &podtypes.GRPCProbe{Service: &service}})
+
+	assert.Nil(t, probe.HTTPGet)
+	if assert.NotNil(t, probe.GRPC) {
+		assert.Equal(t, int32(9090), probe.GRPC.Port)
+		assert.Equal(t, &service, probe.GRPC.Service)
+	}
+}
+
+func TestGetProbe_Exec(t *testing.T) {
+	probe := getProbe(&podtypes.Probe{Exec: &podtypes.ExecProbe{Command: []string{"pg_isready", "-U", "postgres"}}, Period: 5})
+
+	assert.Nil(t, probe.HTTPGet)
+	if assert.NotNil(t, probe.Exec) {
+		assert.Equal(t, []string{"pg_isready", "-U", "postgres"}, probe.Exec.Command)
+	}
+	assert.Equal(t, int32(5), probe.PeriodSeconds)
+}
+
+func TestGetProbe_TCPSocket(t *testing.T) {
+	probe := getProbe(&podtypes.Probe{Port: intstr.FromString("amqp"), TCPSocket: &podtypes.TCPSocketProbe{}})
+
+	assert.Nil(t, probe.HTTPGet)
+	if assert.NotNil(t, probe.TCPSocket) {
+		assert.Equal(t, intstr.FromString("amqp"), probe.TCPSocket.Port)
+	}
+}
+
+func TestCreateExtraContainers_UsesSharedProbeHandling(t *testing.T) {
+	sidecars, _, _, err := CreateExtraContainers("app", []podtypes.ContainerSpec{
+		{
+			Name:      "cache",
+			Image:     "redis:7",
+			Liveness:  &podtypes.Probe{Exec: &podtypes.ExecProbe{Command: []string{"redis-cli", "ping"}}},
+			Readiness: &podtypes.Probe{Port: intstr.FromInt32(6379), TCPSocket: &podtypes.TCPSocketProbe{}},
+		},
+	}, PodOpts{})
+	require.NoError(t, err)
+
+	assert.NotNil(t, sidecars[0].LivenessProbe.Exec)
+	assert.NotNil(t, sidecars[0].ReadinessProbe.TCPSocket)
+}
+
+func TestCreatePodSpec_NodePlacement(t *testing.T) {
+	podSettings := &podtypes.PodSettings{
+		TerminationGracePeriodSeconds: 30,
+		NodeSelector:                  map[string]string{"skip.kartverket.no/pool": "high-memory"},
+		Tolerations:                   []corev1.Toleration{{Key: "skip.kartverket.no/high-memory", Operator: corev1.TolerationOpExists}},
+		Affinity:                      &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}},
+	}
+
+	spec := CreatePodSpec(nil, nil, "sa", "medium", new(corev1.RestartPolicyAlways), podSettings, "app")
+
+	assert.Equal(t, podSettings.NodeSelector, spec.NodeSelector)
+	assert.Equal(t, podSettings.Tolerations, spec.Tolerations)
+	assert.Equal(t, podSettings.Affinity, spec.Affinity)
+}
+
+func TestCreatePodSpec_CustomTopologySpreadConstraintsReplaceDefaults(t *testing.T) {
+	constraints := []corev1.TopologySpreadConstraint{{TopologyKey: "topology.kubernetes.io/zone", MaxSkew: 2}}
+
+	spec := CreatePodSpec(nil, nil, "sa", "medium", new(corev1.RestartPolicyAlways), &podtypes.PodSettings{
+		TopologySpreadConstraints:           constraints,
+		DisablePodSpreadTopologyConstraints: true,
+	}, "app")
+
+	assert.Equal(t, constraints, spec.TopologySpreadConstraints)
+}
+
+func TestGetLifecycle_Hooks(t *testing.T) {
+	lifecycle := getLifecycle(&podtypes.Lifecycle{
+		PreStop:   &podtypes.LifecycleHandler{Exec: &podtypes.ExecProbe{Command: []string{"/bin/drain"}}},
+		PostStart: &podtypes.LifecycleHandler{SleepSeconds: new(int64(2))},
+	}, nil)
+
+	assert.Equal(t, []string{"/bin/drain"}, lifecycle.PreStop.Exec.Command)
+	assert.Nil(t, lifecycle.PreStop.Sleep)
+	assert.Equal(t, int64(2), lifecycle.PostStart.Sleep.Seconds)
+}
+
+func TestGetLifecycle_Empty(t *testing.T) {
+	assert.Nil(t, getLifecycle(nil, nil))
+	assert.Nil(t, getLifecycle(&podtypes.Lifecycle{}, nil))
+}
+
+func TestGetLifecycle_GracefulShutdownSetsPreStopSleep(t *testing.T) {
+	lifecycle := getLifecycle(&podtypes.Lifecycle{
+		PostStart: &podtypes.LifecycleHandler{Exec: &podtypes.ExecProbe{Command: []string{"/bin/warmup"}}},
+	}, &podtypes.GracefulShutdown{DrainSeconds: 15})
+
+	assert.Equal(t, int64(15), lifecycle.PreStop.Sleep.Seconds)
+	assert.Equal(t, []string{"/bin/warmup"}, lifecycle.PostStart.Exec.Command)
+}
+
+func TestApplyGracefulShutdown(t *testing.T) {
+	spec := CreatePodSpec(nil, nil, "sa", "medium", new(corev1.RestartPolicyAlways), &podtypes.PodSettings{TerminationGracePeriodSeconds: 30}, "app")
+
+	ApplyGracefulShutdown(&spec, nil)
+	assert.Equal(t, int64(30), *spec.TerminationGracePeriodSeconds)
+
+	ApplyGracefulShutdown(&spec, &podtypes.GracefulShutdown{DrainSeconds: 15})
+	assert.Equal(t, int64(45), *spec.TerminationGracePeriodSeconds)
+}
+
+func TestContainerSecurityContext_Overrides(t *testing.T) {
+	sc := containerSecurityContext(false,
+		&podtypes.PodSecurityContext{RunAsUser: new(int64(1000)), RunAsGroup: new(int64(1000))},
+		&podtypes.ContainerSecurityContext{RunAsUser: new(int64(2000)), AddCapabilities: []corev1.Capability{"NET_BIND_SERVICE"}},
+	)
+
+	assert.Equal(t, int64(2000), *sc.RunAsUser)
+	assert.Equal(t, int64(1000), *sc.RunAsGroup)
+	assert.Equal(t, []corev1.Capability{"NET_BIND_SERVICE"}, sc.Capabilities.Add)
+	assert.Equal(t, []corev1.Capability{"ALL"}, sc.Capabilities.Drop)
+	assert.True(t, *sc.RunAsNonRoot)
+	assert.True(t, *sc.ReadOnlyRootFilesystem)
+}
+
+func TestContainerSecurityContext_DefaultsAndNoDuplicateCapabilities(t *testing.T) {
+	sc := containerSecurityContext(true, nil, &podtypes.ContainerSecurityContext{AddCapabilities: []corev1.Capability{"NET_BIND_SERVICE"}})
+
+	assert.Equal(t, []corev1.Capability{"NET_BIND_SERVICE"}, sc.Capabilities.Add)
+	assert.Equal(t, int64(150), *sc.RunAsUser)
+	assert.Empty(t, util.LeastPrivilegeContainerSecurityContext.Capabilities.Add)
+}
+
+func TestCreateExtraContainers_PodSecurityContext(t *testing.T) {
+	sidecars, _, _, err := CreateExtraContainers("app", []podtypes.ContainerSpec{
+		{Name: "vendor", Image: "vendor"},
+		{Name: "own-uid", Image: "vendor", SecurityContext: &podtypes.ContainerSecurityContext{RunAsUser: new(int64(3000))}},
+	}, PodOpts{SecurityContext: &podtypes.PodSecurityContext{RunAsUser: new(int64(1000))}})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1000), *sidecars[0].SecurityContext.RunAsUser)
+	assert.Equal(t, int64(3000), *sidecars[1].SecurityContext.RunAsUser)
+}
+
+func TestCreatePodSpec_FSGroup(t *testing.T) {
+	spec := CreatePodSpec(nil, nil, "sa", "medium", new(corev1.RestartPolicyAlways), &podtypes.PodSettings{}, "app")
+	assert.Equal(t, int64(150), *spec.SecurityContext.FSGroup)
+
+	spec = CreatePodSpec(nil, nil, "sa", "medium", new(corev1.RestartPolicyAlways), &podtypes.PodSettings{
+		SecurityContext: &podtypes.PodSecurityContext{FSGroup: new(int64(2000))},
+	}, "app")
+	assert.Equal(t, int64(2000), *spec.SecurityContext.FSGroup)
+}
+
+func TestGetEnvFrom_SecretStore(t *testing.T) {
+	source := &podtypes.SecretStoreSource{Name: "gsm", RemoteKey: "database"}
+
+	envFrom, err := getEnvFrom("app", []podtypes.EnvFrom{{ConfigMap: "config"}, {SecretStore: source}})
+	require.NoError(t, err)
+
+	assert.Len(t, envFrom, 2)
+	assert.Equal(t, "config", envFrom[0].ConfigMapRef.Name)
+	secretName, err := externalsecret.SecretName("app", source)
+	require.NoError(t, err)
+	assert.Equal(t, secretName, envFrom[1].SecretRef.Name)
+}
+
+func TestGetEnvFromKeys(t *testing.T) {
+	env := getEnvFromKeys([]podtypes.EnvFromKey{
+		{Secret: "db", Key: "password", As: "DB_PASSWORD"},
+		{ConfigMap: "settings", Key: "log-level", As: "LOG_LEVEL"},
+	})
+
+	assert.Len(t, env, 2)
+	assert.Equal(t, "DB_PASSWORD", env[0].Name)
+	assert.Equal(t, "db", env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "password", env[0].ValueFrom.SecretKeyRef.Key)
+	assert.Nil(t, env[0].ValueFrom.ConfigMapKeyRef)
+	assert.Equal(t, "LOG_LEVEL", env[1].Name)
+	assert.Equal(t, "settings", env[1].ValueFrom.ConfigMapKeyRef.Name)
+	assert.Equal(t, "log-level", env[1].ValueFrom.ConfigMapKeyRef.Key)
+	assert.Nil(t, env[1].ValueFrom.SecretKeyRef)
+}
+
+func TestCreateJobContainer_SecurityContext(t *testing.T) {
+	skipJob := &skiperatorv1beta1.SKIPJob{
+		TypeMeta:   metav1.TypeMeta{Kind: "SKIPJob"},
+		ObjectMeta: metav1.ObjectMeta{Name: "seed"},
+		Spec: skiperatorv1beta1.SKIPJobSpec{
+			Image:       "fixtures:1.0",
+			PodSettings: &podtypes.PodSettings{SecurityContext: &podtypes.PodSecurityContext{RunAsUser: new(int64(1000)), RunAsGroup: new(int64(1000))}},
+			SecurityContext: &podtypes.ContainerSecurityContext{
+				RunAsUser:       new(int64(2000)),
+				AddCapabilities: []corev1.Capability{"NET_RAW"},
+			},
+		},
+	}
+
+	container, err := CreateJobContainer(skipJob, nil, nil)
+	require.NoError(t, err)
+
+	sc := container.SecurityContext
+	assert.Equal(t, int64(2000), *sc.RunAsUser)
+	assert.Equal(t, int64(1000), *sc.RunAsGroup)
+	assert.Equal(t, []corev1.Capability{"NET_RAW"}, sc.Capabilities.Add)
+	assert.Equal(t, []corev1.Capability{"ALL"}, sc.Capabilities.Drop)
+	assert.True(t, *sc.ReadOnlyRootFilesystem)
+}
@@ -0,0 +1,170 @@
+package predeploy
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/reconciliation"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/gcp"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/pod"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/resourceutils"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/volume"
+	"github.com/kartverket/skiperator/pkg/util"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const hashLength = 8
+
+// Generate creates the pre-deploy Job of the Application. The Job is named after the digests of the images and the
+// pre-deploy settings, so it runs once for every image, also when a tag is moved to a new image. It is kept after it has finished, as the rollout of the image is
+// released by its status, and is deleted with the resources of the Application when the image changes.
+func Generate(r reconciliation.Reconciliation) error {
+	ctxLog := r.GetLogger()
+	if r.GetType() != reconciliation.ApplicationType {
+		err := &reconciliation.SubResourceError{Message: "Unsupported type in pre-deploy job resource", WrapErr: fmt.Errorf("unsupported type %s", r.GetType()), Reason: reconciliation.UnsupportedTypeResource}
+		return err
+	}
+	application, ok := r.GetSKIPObject().(*skiperatorv1alpha1.Application)
+	if !ok {
+		err := &reconciliation.SubResourceError{Message: "Failed to generate pre-deploy job", WrapErr: fmt.Errorf("failed to cast resource to application"), Reason: reconciliation.InternalError}
+		return err
+	}
+	if application.Spec.PreDeploy == nil {
+		return nil
+	}
+
+	ctxLog.Debug("Attempting to generate pre-deploy job for application", "application", application.Name)
+
+	jobName, err := JobName(application, r.PinnedImages())
+	if err != nil {
+		err := &reconciliation.SubResourceError{Message: "Failed to generate pre-deploy job", WrapErr: err, Reason: reconciliation.InternalError}
+		return err
+	}
+
+	podOpts := pod.PodOpts{
+		IstioEnabled:     r.IsSidecarEnabled(),
+		LocalBuiltImages: r.GetSkiperatorConfig().EnableLocallyBuiltImages,
+		SecurityContext:  pod.PodSecurityContext(application.Spec.PodSettings),
+	}
+
+	container, err := pod.CreatePreDeployContainer(application, podOpts)
+	if err != nil {
+		return &reconciliation.SubResourceError{Message: "Failed to generate pre-deploy job", WrapErr: err, Reason: reconciliation.InternalError}
+	}
+
+	podVolumes, err := volume.GetPodVolumes(application.Name, application.Spec.FilesFrom)
+	if err != nil {
+		return &reconciliation.SubResourceError{Message: "Failed to generate pre-deploy job", WrapErr: err, Reason: reconciliation.InternalError}
+	}
+	container.VolumeMounts = volume.GetContainerVolumeMounts(application.Spec.FilesFrom)
+	if util.IsGCPAuthEnabled(application.Spec.GCP) {
+		podVolumes = append(podVolumes, gcp.GetGCPContainerVolume(r.GetSkiperatorConfig().GCPWorkloadIdentityPool, application.Name))
+		container.VolumeMounts = append(container.VolumeMounts, gcp.GetGCPContainerVolumeMount())
+		container.Env = append(container.Env, gcp.GetGCPEnvVar())
+	}
+
+	// The Cloud SQL proxy runs as a native sidecar, which is stopped when the Job container exits
+	var initContainers []corev1.Container
+	if util.IsCloudSqlProxyEnabled(application.Spec.GCP) {
+		cloudSqlProxyContainer := pod.CreateCloudSqlProxyContainer(application.Spec.GCP.CloudSQLProxy)
+		cloudSqlProxyContainer.RestartPolicy = util.PointTo(corev1.ContainerRestartPolicyAlways)
+		initContainers = append(initContainers, cloudSqlProxyContainer)
+	}
+
+	podLabels := util.GetPodAppSelector(PodAppLabel(application.Name))
+	if len(application.Spec.Team) > 0 {
+		podLabels = util.GetPodAppAndTeamSelector(PodAppLabel(application.Name), application.Spec.Team)
+	}
+	podLabels["app.kubernetes.io/version"] = resourceutils.HumanReadableVersion(&ctxLog, container.Image)
+
+	podAnnotations := map[string]string{"argocd.argoproj.io/sync-options": "Prune=false"}
+	if r.IsSidecarEnabled() {
+		// The Istio proxy must be stopped when the Job container exits, or the Job never completes
+		podAnnotations[resourceutils.AnnotationKeyIstioNativeSidecar] = "true"
+	}
+	if envFromKeysHash := r.EnvFromKeysHash(); envFromKeysHash != "" {
+		podAnnotations[resourceutils.AnnotationKeyEnvFromKeysHash] = envFromKeysHash
+	}
+
+	podSpec := pod.CreatePodSpec(
+		[]corev1.Container{container},
+		podVolumes,
+		application.Name,
+		application.Spec.Priority,
+		util.PointTo(corev1.RestartPolicyNever),
+		application.Spec.PodSettings,
+		application.Name,
+	)
+	podSpec.InitContainers = initContainers
+	// Spreading a single pod has no effect
+	podSpec.TopologySpreadConstraints = nil
+	util.PinImages(&podSpec, r.PinnedImages())
+
+	job := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: application.Namespace,
+			Name:      jobName,
+		},
+		Spec: batchv1.JobSpec{
+			Parallelism:           util.PointTo(int32(1)),
+			Completions:           util.PointTo(int32(1)),
+			CompletionMode:        util.PointTo(batchv1.NonIndexedCompletion),
+			BackoffLimit:          application.Spec.PreDeploy.BackoffLimit,
+			ActiveDeadlineSeconds: application.Spec.PreDeploy.ActiveDeadlineSeconds,
+			Suspend:               util.PointTo(false),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      podLabels,
+					Annotations: podAnnotations,
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+
+	r.AddResource(&job)
+
+	ctxLog.Debug("successfully created pre-deploy job resource")
+	return nil
+}
+
+// JobName returns the name of the pre-deploy Job for the current images and pre-deploy settings of the Application.
+// pinnedImages maps the images to the digests they were resolved to, and images without a digest are named by tag.
+func JobName(application *skiperatorv1alpha1.Application, pinnedImages map[string]string) (string, error) {
+	digests := map[string]string{}
+	for _, image := range []string{application.Spec.Image, application.PreDeployImage()} {
+		if pinned, ok := pinnedImages[image]; ok {
+			digests[image] = pinned
+		}
+	}
+	settings, err := json.Marshal(struct {
+		Image     string                            `json:"image"`
+		PreDeploy *skiperatorv1alpha1.PreDeploySpec `json:"preDeploy"`
+		Digests   map[string]string                 `json:"digests,omitempty"`
+	}{application.Spec.Image, application.Spec.PreDeploy, digests})
+	if err != nil {
+		return "", err
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(settings))[:hashLength]
+	return truncate(application.Name, len("-predeploy-")+hashLength) + "-predeploy-" + hash, nil
+}
+
+// PodAppLabel returns the app label of the pre-deploy pods. It differs from the app label of the Application, so
+// the pods do not receive traffic from the Service of the Application.
+func PodAppLabel(name string) string {
+	return truncate(name, len("-predeploy")) + "-predeploy"
+}
+
+// truncate shortens name so a suffix of the given length fits in a label value
+func truncate(name string, suffixLength int) string {
+	if maxLength := validation.DNS1123LabelMaxLength - suffixLength; len(name) > maxLength {
+		return strings.TrimSuffix(name[:maxLength], "-")
+	}
+	return name
+}
@@ -0,0 +1,124 @@
+package predeploy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kartverket/skiperator/api/common/podtypes"
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGenerate_NoPreDeploy(t *testing.T) {
+	r := testutil.GetTestMinimalAppReconciliation()
+
+	require.NoError(t, Generate(r))
+	assert.Empty(t, r.GetResources())
+}
+
+func TestGenerate_PreDeployJob(t *testing.T) {
+	r := testutil.GetTestMinimalAppReconciliation()
+	application := r.GetSKIPObject().(*skiperatorv1alpha1.Application)
+	application.Spec.Env = []corev1.EnvVar{{Name: "DB_HOST", Value: "db"}}
+	application.Spec.GCP = &podtypes.GCP{CloudSQLProxy: &podtypes.CloudSQLProxySettings{ConnectionName: "project:region:db", IP: "10.0.0.1", ServiceAccount: "sa@project.iam.gserviceaccount.com"}}
+	application.Spec.PreDeploy = &skiperatorv1alpha1.PreDeploySpec{Command: []string{"./migrate", "up"}}
+
+	require.NoError(t, Generate(r))
+	require.Len(t, r.GetResources(), 1)
+	job := r.GetResources()[0].(*batchv1.Job)
+
+	name, err := JobName(application, r.PinnedImages())
+	require.NoError(t, err)
+	assert.Equal(t, name, job.Name)
+	assert.Regexp(t, `^minimal-predeploy-[0-9a-f]{8}$`, job.Name)
+	assert.Equal(t, "minimal-predeploy", job.Spec.Template.Labels["app"])
+	assert.Equal(t, corev1.RestartPolicyNever, job.Spec.Template.Spec.RestartPolicy)
+	assert.Equal(t, "minimal", job.Spec.Template.Spec.ServiceAccountName)
+
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "image", container.Image)
+	assert.Equal(t, []string{"./migrate", "up"}, container.Command)
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "DB_HOST", Value: "db"})
+	assert.Empty(t, container.Ports)
+	assert.Nil(t, container.ReadinessProbe)
+
+	// The Cloud SQL proxy must not keep the Job running
+	require.Len(t, job.Spec.Template.Spec.InitContainers, 1)
+	assert.Equal(t, "cloudsql-proxy", job.Spec.Template.Spec.InitContainers[0].Name)
+	assert.Equal(t, corev1.ContainerRestartPolicyAlways, *job.Spec.Template.Spec.InitContainers[0].RestartPolicy)
+}
+
+func TestGenerate_RunsPinnedImage(t *testing.T) {
+	r := testutil.GetTestMinimalAppReconciliation()
+	application := r.GetSKIPObject().(*skiperatorv1alpha1.Application)
+	application.Spec.PreDeploy = &skiperatorv1alpha1.PreDeploySpec{Command: []string{"./migrate"}}
+	pinned := "image@sha256:" + strings.Repeat("a", 64)
+	r.PinImage("image", pinned)
+
+	require.NoError(t, Generate(r))
+	job := r.GetResources()[0].(*batchv1.Job)
+	assert.Equal(t, pinned, job.Spec.Template.Spec.Containers[0].Image)
+
+	unpinned, err := JobName(application, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, unpinned, job.Name)
+}
+
+func TestJobName_ChangesWithImage(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{}
+	application.Name = "orders"
+	application.Spec.Image = "ghcr.io/kartverket/orders:1.0"
+	application.Spec.PreDeploy = &skiperatorv1alpha1.PreDeploySpec{Command: []string{"./migrate"}}
+
+	first, err := JobName(application, nil)
+	require.NoError(t, err)
+	again, err := JobName(application, nil)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	application.Spec.Image = "ghcr.io/kartverket/orders:1.1"
+	second, err := JobName(application, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	application.Spec.PreDeploy.Image = "ghcr.io/kartverket/orders-migrations:1.1"
+	third, err := JobName(application, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, second, third)
+}
+
+func TestJobName_ChangesWithDigest(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{}
+	application.Name = "orders"
+	application.Spec.Image = "ghcr.io/kartverket/orders:latest"
+	application.Spec.PreDeploy = &skiperatorv1alpha1.PreDeploySpec{Command: []string{"./migrate"}}
+
+	first, err := JobName(application, map[string]string{application.Spec.Image: application.Spec.Image + "@sha256:" + strings.Repeat("a", 64)})
+	require.NoError(t, err)
+	second, err := JobName(application, map[string]string{application.Spec.Image: application.Spec.Image + "@sha256:" + strings.Repeat("b", 64)})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	// Pinned images that the Job does not run do not change its name
+	third, err := JobName(application, map[string]string{
+		application.Spec.Image:           application.Spec.Image + "@sha256:" + strings.Repeat("b", 64),
+		"ghcr.io/kartverket/sidecar:1.0": "ghcr.io/kartverket/sidecar:1.0@sha256:" + strings.Repeat("c", 64),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, second, third)
+}
+
+func TestJobName_TruncatesLongNames(t *testing.T) {
+	application := &skiperatorv1alpha1.Application{}
+	application.Name = strings.Repeat("a", 60)
+	application.Spec.PreDeploy = &skiperatorv1alpha1.PreDeploySpec{Command: []string{"./migrate"}}
+
+	name, err := JobName(application, nil)
+	require.NoError(t, err)
+	assert.Len(t, name, 63)
+	assert.Len(t, PodAppLabel(application.Name), 63)
+}
@@ -19,6 +19,13 @@ var (
 	}
 
 	AnnotationKeyLinkPrefix = "link.argocd.argoproj.io/external-link"
+
+	// AnnotationKeyEnvFromKeysHash is set on pod templates to restart Pods when a value projected by envFromKeys changes
+	AnnotationKeyEnvFromKeysHash = "skiperator.kartverket.no/env-from-keys-hash"
+
+	// AnnotationKeyIstioNativeSidecar makes Istio inject its proxy as a native sidecar, which starts before the
+	// other init containers, so one-shot init containers have network access
+	AnnotationKeyIstioNativeSidecar = "sidecar.istio.io/nativeSidecar"
 )
 
 func SetCommonAnnotations(object client.Object) {
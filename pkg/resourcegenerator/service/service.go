@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/kartverket/skiperator/api/common/podtypes"
@@ -11,6 +12,7 @@ import (
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/resourceutils"
 	"github.com/kartverket/skiperator/pkg/resourcegenerator/statefulset"
 	"github.com/kartverket/skiperator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -61,6 +63,16 @@ func Generate(r reconciliation.Reconciliation) error {
 	r.AddResource(&service)
 
 	if application.IsStateful() {
+		network := application.Spec.Stateful.Network
+		peerPorts := ports
+		publishNotReadyAddresses := true
+		if network != nil {
+			peerPorts = slices.Concat(ports, getAdditionalPorts(network.PeerPorts))
+			if network.PublishNotReadyAddresses != nil {
+				publishNotReadyAddresses = *network.PublishNotReadyAddresses
+			}
+		}
+
 		// statefulset needs headless service
 		// https://kubernetes.io/docs/concepts/workloads/controllers/statefulset/#limitations
 		headless := corev1.Service{
@@ -71,12 +83,38 @@ func Generate(r reconciliation.Reconciliation) error {
 			Spec: corev1.ServiceSpec{
 				ClusterIP:                corev1.ClusterIPNone,
 				Selector:                 util.GetPodAppSelector(application.Name),
-				Ports:                    ports,
-				PublishNotReadyAddresses: true,
+				Ports:                    peerPorts,
+				PublishNotReadyAddresses: publishNotReadyAddresses,
 			},
 		}
 		r.AddResource(&headless)
 		ctxLog.Debug("created headless service manifest for stateful application", "application", application.Name)
+
+		if network != nil && network.PerPodServices {
+			replicas, err := skiperatorv1alpha1.GetStaticReplicas(application.Spec.Replicas)
+			if err != nil {
+				return &reconciliation.SubResourceError{Message: "Failed to get replicas for per-pod services", WrapErr: err, Reason: reconciliation.InternalError}
+			}
+			for ordinal := int32(0); ordinal < int32(replicas); ordinal++ {
+				podName := statefulset.PodName(application.Name, ordinal)
+				selector := util.GetPodAppSelector(application.Name)
+				selector[appsv1.StatefulSetPodNameLabel] = podName
+				r.AddResource(&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: application.Namespace,
+						Name:      podName,
+						Labels:    util.GetPodAppSelector(application.Name),
+					},
+					Spec: corev1.ServiceSpec{
+						Type:                     corev1.ServiceTypeClusterIP,
+						Selector:                 selector,
+						Ports:                    peerPorts,
+						PublishNotReadyAddresses: publishNotReadyAddresses,
+					},
+				})
+			}
+			ctxLog.Debug("created per-pod service manifests for stateful application", "application", application.Name, "replicas", replicas)
+		}
 	}
 
 	return nil
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestGeneratePerPodServices(t *testing.T) {
+	r := testutil.GetTestMinimalAppReconciliation()
+	application := r.GetSKIPObject().(*skiperatorv1alpha1.Application)
+	application.Spec.Replicas = &apiextensionsv1.JSON{Raw: []byte("2")}
+	application.Spec.Stateful = &skiperatorv1alpha1.StatefulSpec{
+		Enabled: true,
+		Network: &skiperatorv1alpha1.StatefulNetwork{
+			PeerPorts:      []skiperatorv1alpha1.InternalPort{{Name: "gossip", Port: 7946, Protocol: corev1.ProtocolTCP}},
+			PerPodServices: true,
+		},
+	}
+
+	require.NoError(t, Generate(r))
+
+	services := map[string]*corev1.Service{}
+	for _, resource := range r.GetResources() {
+		service := resource.(*corev1.Service)
+		services[service.Name] = service
+	}
+	require.Len(t, services, 4)
+	assert.Contains(t, services, "minimal")
+	assert.Contains(t, services, "minimal-headless")
+
+	for _, name := range []string{"minimal-0", "minimal-1"} {
+		service := services[name]
+		require.NotNil(t, service, name)
+		assert.Equal(t, map[string]string{"app": "minimal", appsv1.StatefulSetPodNameLabel: name}, service.Spec.Selector)
+		assert.Equal(t, corev1.ServiceTypeClusterIP, service.Spec.Type)
+		assert.True(t, service.Spec.PublishNotReadyAddresses)
+		var ports []string
+		for _, port := range service.Spec.Ports {
+			ports = append(ports, port.Name)
+		}
+		assert.ElementsMatch(t, []string{"http", "gossip"}, ports)
+	}
+	assert.NotContains(t, services, "minimal-2")
+}
+
+func TestGenerateNoPerPodServicesByDefault(t *testing.T) {
+	r := testutil.GetTestMinimalAppReconciliation()
+	application := r.GetSKIPObject().(*skiperatorv1alpha1.Application)
+	application.Spec.Stateful = &skiperatorv1alpha1.StatefulSpec{Enabled: true}
+
+	require.NoError(t, Generate(r))
+	assert.Len(t, r.GetResources(), 2)
+}
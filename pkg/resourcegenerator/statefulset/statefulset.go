@@ -25,6 +25,18 @@ func HeadlessServiceName(appName string) string {
 	return appName + headlessServiceSuffix
 }
 
+// PodName returns the name of the pod of the StatefulSet with the given ordinal, which is also the name of its
+// per-pod Service
+func PodName(appName string, ordinal int32) string {
+	return fmt.Sprintf("%s-%d", appName, ordinal)
+}
+
+// ClaimName returns the name of the PVC the StatefulSet controller provisions from the volume claim
+// template for the replica with the given ordinal
+func ClaimName(templateName string, appName string, ordinal int32) string {
+	return fmt.Sprintf("%s-%s-%d", templateName, appName, ordinal)
+}
+
 func Generate(r reconciliation.Reconciliation) error {
 	ctxLog := r.GetLogger()
 	if r.GetType() != reconciliation.ApplicationType {
@@ -47,12 +59,24 @@ func Generate(r reconciliation.Reconciliation) error {
 	podOpts := pod.PodOpts{
 		IstioEnabled:     r.IsSidecarEnabled(),
 		LocalBuiltImages: r.GetSkiperatorConfig().EnableLocallyBuiltImages,
+		SecurityContext:  pod.PodSecurityContext(application.Spec.PodSettings),
 	}
 
-	skiperatorContainer := pod.CreateApplicationContainer(application, podOpts)
+	skiperatorContainer, err := pod.CreateApplicationContainer(application, podOpts)
+	if err != nil {
+		return &reconciliation.SubResourceError{Message: "Failed to generate application container", WrapErr: err, Reason: reconciliation.InternalError}
+	}
 
-	podVolumes := volume.GetPodVolumes(application.Spec.FilesFrom)
+	podVolumes, err := volume.GetPodVolumes(application.Name, application.Spec.FilesFrom)
+	if err != nil {
+		return &reconciliation.SubResourceError{Message: "Failed to generate pod volumes", WrapErr: err, Reason: reconciliation.InternalError}
+	}
 	containerVolumeMounts := volume.GetContainerVolumeMounts(application.Spec.FilesFrom)
+	if !application.HasTmpVolume() {
+		podVolumes, containerVolumeMounts = volume.RemoveTmpVolume(podVolumes, containerVolumeMounts)
+	}
+	podVolumes, containerVolumeMounts = volume.AppendScratchVolumes(podVolumes, containerVolumeMounts, application.Spec.ScratchVolumes)
+	volume.AddScratchStorageRequest(&skiperatorContainer, application.Spec.ScratchVolumes)
 
 	if util.IsGCPAuthEnabled(application.Spec.GCP) {
 		gcpPodVolume := gcp.GetGCPContainerVolume(r.GetSkiperatorConfig().GCPWorkloadIdentityPool, application.Name)
@@ -131,11 +155,17 @@ func Generate(r reconciliation.Reconciliation) error {
 		} else {
 			generatedSpecAnnotations["prometheus.istio.io/merge-metrics"] = "false"
 		}
+		if pod.HasOneshotContainers(application.Spec.ExtraContainers) {
+			generatedSpecAnnotations[resourceutils.AnnotationKeyIstioNativeSidecar] = "true"
+		}
 	}
 
 	if application.Spec.PodSettings != nil && len(application.Spec.PodSettings.Annotations) > 0 {
 		maps.Copy(generatedSpecAnnotations, application.Spec.PodSettings.Annotations)
 	}
+	if envFromKeysHash := r.EnvFromKeysHash(); envFromKeysHash != "" {
+		generatedSpecAnnotations[resourceutils.AnnotationKeyEnvFromKeysHash] = envFromKeysHash
+	}
 
 	containers := []corev1.Container{skiperatorContainer}
 
@@ -143,7 +173,10 @@ func Generate(r reconciliation.Reconciliation) error {
 		containers = append(containers, pod.CreateCloudSqlProxyContainer(application.Spec.GCP.CloudSQLProxy))
 	}
 
-	extraSidecars, extraInitContainers, extraVolumes := pod.CreateExtraContainers(application.Spec.ExtraContainers, podOpts)
+	extraSidecars, extraInitContainers, extraVolumes, err := pod.CreateExtraContainers(application.Name, application.Spec.ExtraContainers, podOpts)
+	if err != nil {
+		return &reconciliation.SubResourceError{Message: "Failed to generate extra containers", WrapErr: err, Reason: reconciliation.InternalError}
+	}
 	containers = append(containers, extraSidecars...)
 	podVolumes = pod.AppendUniqueVolumes(podVolumes, extraVolumes...)
 
@@ -168,10 +201,16 @@ func Generate(r reconciliation.Reconciliation) error {
 	}
 
 	podTemplate.Spec.InitContainers = extraInitContainers
+	pod.ApplyGracefulShutdown(&podTemplate.Spec, application.Spec.GracefulShutdown)
 
 	resourceutils.SetApplicationLabels(&podTemplate, application)
 	resourceutils.SetCommonAnnotations(&podTemplate)
 
+	partition := application.Spec.Stateful.Partition
+	if r.StatefulPartition() != nil {
+		partition = r.StatefulPartition()
+	}
+
 	sts.Spec = appsv1.StatefulSetSpec{
 		Selector:    &metav1.LabelSelector{MatchLabels: util.GetPodAppSelector(application.Name)},
 		ServiceName: HeadlessServiceName(application.Name),
@@ -181,7 +220,7 @@ func Generate(r reconciliation.Reconciliation) error {
 		},
 		VolumeClaimTemplates:                 vctTemplates,
 		PodManagementPolicy:                  appsv1.PodManagementPolicyType(application.Spec.Stateful.PodManagementPolicy),
-		UpdateStrategy:                       buildUpdateStrategy(application.Spec.Stateful.Partition),
+		UpdateStrategy:                       buildUpdateStrategy(partition),
 		PersistentVolumeClaimRetentionPolicy: buildPVCRetentionPolicy(application.Spec.Stateful.PVCRetentionWhenDeleted, application.Spec.Stateful.PVCRetentionWhenScaled),
 		RevisionHistoryLimit:                 new(int32(2)),
 	}
@@ -201,7 +240,19 @@ func Generate(r reconciliation.Reconciliation) error {
 		sts.Annotations[resourceutils.AnnotationKeyLinkPrefix] = fmt.Sprintf("https://%s", ingresses[0])
 	}
 
-	if !podOpts.LocalBuiltImages {
+	// Keep the pods of the previous image running until the pre-deploy Job for the new image has succeeded.
+	// The held template has already been resolved.
+	if r.RolloutHeld() {
+		if r.HeldPodTemplate() == nil {
+			ctxLog.Debug("waiting for pre-deploy job before creating statefulset")
+			return nil
+		}
+		sts.Spec.Template = *r.HeldPodTemplate()
+	}
+
+	if !podOpts.LocalBuiltImages && !r.RolloutHeld() {
+		// Run the digests that were verified, instead of resolving the tags again
+		util.PinImages(&sts.Spec.Template.Spec, r.PinnedImages())
 		err := util.ResolveImageTags(r.GetCtx(), ctxLog.GetLogger(), r.GetRestConfig(), &sts)
 		if err != nil {
 			if !strings.Contains(err.Error(), "https://index.docker.io/v2/library/image/manifests/latest") {
@@ -2,11 +2,14 @@ package volume
 
 import (
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/kartverket/skiperator/api/common/podtypes"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/externalsecret"
 	"github.com/nais/liberator/pkg/namegen"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
@@ -17,12 +20,17 @@ const (
 	DefaultDigdiratorIDportenMountPath     = "/var/run/secrets/skip/idporten"
 
 	tmpVolumeName = "tmp"
+	tmpMountPath  = "/tmp"
+
+	scratchMediumMemory = "Memory"
 
 	// For linking volumes and volume mounts in pods and allowing different volume types to have the same name.
 	configMapPrefix             = "cm-"
 	secretPrefix                = "sec-"
 	emptyDirPrefix              = "ed-"
 	persistentVolumeClaimPrefix = "pvc-"
+	externalSecretPrefix        = "es-"
+	scratchPrefix               = "scratch-"
 )
 
 // AppendDigdiratorSecret wires a digdirator-issued secret into the container via envFrom
@@ -55,7 +63,7 @@ func GetContainerVolumeMounts(filesFrom []podtypes.FilesFrom) []corev1.VolumeMou
 	containerVolumeMounts := []corev1.VolumeMount{
 		{
 			Name:      tmpVolumeName,
-			MountPath: "/tmp",
+			MountPath: tmpMountPath,
 		},
 	}
 
@@ -82,7 +90,9 @@ func GetContainerVolumeMounts(filesFrom []podtypes.FilesFrom) []corev1.VolumeMou
 	return containerVolumeMounts
 }
 
-func GetPodVolumes(filesFrom []podtypes.FilesFrom) []corev1.Volume {
+// GetPodVolumes returns the volumes for filesFrom. ownerName is the name of the workload, which
+// the Secrets synced from secret stores are named after.
+func GetPodVolumes(ownerName string, filesFrom []podtypes.FilesFrom) ([]corev1.Volume, error) {
 
 	// Use a map to avoid duplicates
 	podVolumesMap := map[string]corev1.Volume{
@@ -126,6 +136,20 @@ func GetPodVolumes(filesFrom []podtypes.FilesFrom) []corev1.Volume {
 					},
 				},
 			}
+		} else if file.SecretStore != nil {
+			secretName, err := externalsecret.SecretName(ownerName, file.SecretStore)
+			if err != nil {
+				return nil, err
+			}
+			volume = corev1.Volume{
+				Name: sourceVolumeName(file),
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName:  secretName,
+						DefaultMode: new(defaultMode),
+					},
+				},
+			}
 		} else if len(file.EmptyDir) > 0 {
 			if file.EmptyDir == tmpVolumeName {
 				continue
@@ -158,7 +182,79 @@ func GetPodVolumes(filesFrom []podtypes.FilesFrom) []corev1.Volume {
 		podVolumes = append(podVolumes, podVolumesMap[key])
 	}
 
-	return podVolumes
+	return podVolumes, nil
+}
+
+// RemoveTmpVolume removes the built-in tmp volume and its mount, for workloads that have it disabled.
+// Extra containers mount their own /tmp, which adds the volume back.
+func RemoveTmpVolume(volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) ([]corev1.Volume, []corev1.VolumeMount) {
+	volumes = slices.DeleteFunc(volumes, func(v corev1.Volume) bool { return v.Name == tmpVolumeName })
+	volumeMounts = slices.DeleteFunc(volumeMounts, func(m corev1.VolumeMount) bool { return m.Name == tmpVolumeName })
+	return volumes, volumeMounts
+}
+
+// AppendScratchVolumes adds a size-limited emptyDir volume and mount for each scratch volume.
+// A scratch volume at /tmp resizes the built-in tmp volume instead of adding a new one, and
+// adds it if it was removed.
+func AppendScratchVolumes(volumes []corev1.Volume, volumeMounts []corev1.VolumeMount, scratchVolumes []podtypes.ScratchVolume) ([]corev1.Volume, []corev1.VolumeMount) {
+	for _, scratch := range scratchVolumes {
+		emptyDir := &corev1.EmptyDirVolumeSource{SizeLimit: new(scratch.SizeLimit)}
+		if scratch.Medium == scratchMediumMemory {
+			emptyDir.Medium = corev1.StorageMediumMemory
+		}
+
+		name := volumeName(scratchPrefix, strings.TrimPrefix(scratch.Path, "/"))
+		if scratch.Path == tmpMountPath {
+			i := slices.IndexFunc(volumes, func(v corev1.Volume) bool { return v.Name == tmpVolumeName })
+			if i >= 0 {
+				volumes[i].VolumeSource = corev1.VolumeSource{EmptyDir: emptyDir}
+				continue
+			}
+			name = tmpVolumeName
+		}
+
+		volumes = append(volumes, corev1.Volume{
+			Name:         name,
+			VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: scratch.Path,
+		})
+	}
+	return volumes, volumeMounts
+}
+
+// AddScratchStorageRequest adds the size of disk-backed scratch volumes to the container's
+// ephemeral-storage request, and to its limit when one is set, so the scheduler accounts for
+// them. Memory-backed volumes are left out as they are charged to the container's memory.
+func AddScratchStorageRequest(container *corev1.Container, scratchVolumes []podtypes.ScratchVolume) {
+	total := resource.Quantity{}
+	for _, scratch := range scratchVolumes {
+		if scratch.Medium != scratchMediumMemory {
+			total.Add(scratch.SizeLimit)
+		}
+	}
+	if total.IsZero() {
+		return
+	}
+
+	// The resource lists may be shared with the Application spec, so never modify them in place
+	container.Resources.Requests = container.Resources.Requests.DeepCopy()
+	if container.Resources.Requests == nil {
+		container.Resources.Requests = corev1.ResourceList{}
+	}
+	container.Resources.Limits = container.Resources.Limits.DeepCopy()
+	addQuantity(container.Resources.Requests, corev1.ResourceEphemeralStorage, total)
+	if _, ok := container.Resources.Limits[corev1.ResourceEphemeralStorage]; ok {
+		addQuantity(container.Resources.Limits, corev1.ResourceEphemeralStorage, total)
+	}
+}
+
+func addQuantity(resources corev1.ResourceList, name corev1.ResourceName, quantity resource.Quantity) {
+	sum := resources[name].DeepCopy()
+	sum.Add(quantity)
+	resources[name] = sum
 }
 
 // sourceVolumeName returns the Kubernetes volume name for a FilesFrom source.
@@ -170,6 +266,8 @@ func sourceVolumeName(file podtypes.FilesFrom) string {
 		return volumeName(configMapPrefix, file.ConfigMap)
 	case len(file.Secret) > 0:
 		return volumeName(secretPrefix, file.Secret)
+	case file.SecretStore != nil:
+		return volumeName(externalSecretPrefix, file.SecretStore.Name+"-"+file.SecretStore.RemoteKey)
 	case len(file.EmptyDir) > 0:
 		if file.EmptyDir == tmpVolumeName {
 			return tmpVolumeName
@@ -5,8 +5,11 @@ import (
 	"testing"
 
 	"github.com/kartverket/skiperator/api/common/podtypes"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/externalsecret"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
@@ -80,7 +83,8 @@ func TestVolumeNamesMatchVolumeMountNames(t *testing.T) {
 		{MountPath: "/pvc", PersistentVolumeClaim: "shared-name"},
 	}
 
-	volumes := GetPodVolumes(filesFrom)
+	volumes, err := GetPodVolumes("app", filesFrom)
+	require.NoError(t, err)
 	mounts := GetContainerVolumeMounts(filesFrom)
 
 	volumeNames := map[string]struct{}{}
@@ -98,11 +102,12 @@ func TestGetPodVolumesDefaultMode(t *testing.T) {
 	zero := 0
 	mode0600 := 384
 
-	volumes := GetPodVolumes([]podtypes.FilesFrom{
+	volumes, err := GetPodVolumes("app", []podtypes.FilesFrom{
 		{MountPath: "/config-default", ConfigMap: "config-default"},
 		{MountPath: "/config-zero", ConfigMap: "config-zero", DefaultMode: &zero},
 		{MountPath: "/secret-mode", Secret: "secret-mode", DefaultMode: &mode0600},
 	})
+	require.NoError(t, err)
 
 	assert.Equal(t, int32(420), *findVolume(t, volumes, "cm-config-default").ConfigMap.DefaultMode)
 	assert.Equal(t, int32(0), *findVolume(t, volumes, "cm-config-zero").ConfigMap.DefaultMode)
@@ -121,3 +126,80 @@ func findVolume(t *testing.T, volumes []corev1.Volume, name string) corev1.Volum
 	t.Fatalf("volume %q not found", name)
 	return corev1.VolumeSource{}
 }
+
+func TestAppendScratchVolumes(t *testing.T) {
+	volumes, err := GetPodVolumes("app", nil)
+	require.NoError(t, err)
+	mounts := GetContainerVolumeMounts(nil)
+
+	volumes, mounts = AppendScratchVolumes(volumes, mounts, []podtypes.ScratchVolume{
+		{Path: "/tmp", SizeLimit: resource.MustParse("1Gi"), Medium: "Memory"},
+		{Path: "/var/cache/nginx", SizeLimit: resource.MustParse("500Mi"), Medium: "Disk"},
+	})
+
+	assert.Len(t, volumes, 2)
+	assert.Equal(t, tmpVolumeName, volumes[0].Name)
+	assert.Equal(t, corev1.StorageMediumMemory, volumes[0].EmptyDir.Medium)
+	assert.Equal(t, resource.MustParse("1Gi"), *volumes[0].EmptyDir.SizeLimit)
+	assert.Equal(t, corev1.StorageMediumDefault, volumes[1].EmptyDir.Medium)
+	assert.Equal(t, resource.MustParse("500Mi"), *volumes[1].EmptyDir.SizeLimit)
+
+	assert.Len(t, mounts, 2)
+	assert.Equal(t, volumes[1].Name, mounts[1].Name)
+	assert.Equal(t, "/var/cache/nginx", mounts[1].MountPath)
+	assert.Empty(t, validation.IsDNS1123Label(mounts[1].Name))
+}
+
+func TestRemoveTmpVolume(t *testing.T) {
+	volumes, err := GetPodVolumes("app", nil)
+	require.NoError(t, err)
+	volumes, mounts := RemoveTmpVolume(volumes, GetContainerVolumeMounts(nil))
+	assert.Empty(t, volumes)
+	assert.Empty(t, mounts)
+
+	// A scratch volume at /tmp mounts it again, with its size limit
+	volumes, mounts = AppendScratchVolumes(volumes, mounts, []podtypes.ScratchVolume{
+		{Path: "/tmp", SizeLimit: resource.MustParse("1Gi")},
+	})
+	assert.Len(t, volumes, 1)
+	assert.Equal(t, tmpVolumeName, volumes[0].Name)
+	assert.Equal(t, resource.MustParse("1Gi"), *volumes[0].EmptyDir.SizeLimit)
+	assert.Equal(t, []corev1.VolumeMount{{Name: tmpVolumeName, MountPath: tmpMountPath}}, mounts)
+}
+
+func TestAddScratchStorageRequest(t *testing.T) {
+	requests := corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("100Mi")}
+	limits := corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("1Gi")}
+	container := corev1.Container{Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits}}
+
+	AddScratchStorageRequest(&container, []podtypes.ScratchVolume{
+		{Path: "/tmp", SizeLimit: resource.MustParse("1Gi"), Medium: "Memory"},
+		{Path: "/cache", SizeLimit: resource.MustParse("200Mi")},
+	})
+
+	assert.True(t, resource.MustParse("300Mi").Equal(container.Resources.Requests[corev1.ResourceEphemeralStorage]))
+	assert.True(t, resource.MustParse("1224Mi").Equal(container.Resources.Limits[corev1.ResourceEphemeralStorage]))
+	// The lists from the Application spec are left untouched
+	assert.True(t, resource.MustParse("100Mi").Equal(requests[corev1.ResourceEphemeralStorage]))
+
+	noLimits := corev1.Container{}
+	AddScratchStorageRequest(&noLimits, []podtypes.ScratchVolume{{Path: "/cache", SizeLimit: resource.MustParse("200Mi")}})
+	assert.True(t, resource.MustParse("200Mi").Equal(noLimits.Resources.Requests[corev1.ResourceEphemeralStorage]))
+	assert.Nil(t, noLimits.Resources.Limits)
+}
+
+func TestGetPodVolumesSecretStore(t *testing.T) {
+	source := &podtypes.SecretStoreSource{Name: "gsm", RemoteKey: "database"}
+	filesFrom := []podtypes.FilesFrom{{MountPath: "/var/run/secrets/database", SecretStore: source}}
+
+	volumes, err := GetPodVolumes("app", filesFrom)
+	require.NoError(t, err)
+	mounts := GetContainerVolumeMounts(filesFrom)
+
+	assert.Len(t, volumes, 2)
+	assert.Equal(t, "es-gsm-database", volumes[1].Name)
+	secretName, err := externalsecret.SecretName("app", source)
+	require.NoError(t, err)
+	assert.Equal(t, secretName, volumes[1].Secret.SecretName)
+	assert.Equal(t, volumes[1].Name, mounts[1].Name)
+}
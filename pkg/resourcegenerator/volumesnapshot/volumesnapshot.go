@@ -0,0 +1,88 @@
+package volumesnapshot
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
+	"github.com/kartverket/skiperator/pkg/resourcegenerator/resourceutils"
+	"github.com/nais/liberator/pkg/namegen"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// GroupVersionKind of the CSI VolumeSnapshot. The Go types are not imported to avoid depending on the
+// external snapshotter, so VolumeSnapshots are handled as unstructured objects.
+var GroupVersionKind = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+// AnnotationKeyOrdinal is the ordinal of the replica whose PVC a VolumeSnapshot was taken of
+const AnnotationKeyOrdinal = "skiperator.kartverket.no/ordinal"
+
+// New returns a VolumeSnapshot of the PVC claimName of the replica with the given ordinal, named
+// after the PVC and the time it is taken. The snapshot is not owned by the Application, so it
+// outlives it.
+func New(application *skiperatorv1alpha1.Application, claimName string, ordinal int32, now time.Time) *unstructured.Unstructured {
+	name := fmt.Sprintf("%s-%s", claimName, now.UTC().Format("20060102150405"))
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		shortName, err := namegen.ShortName(name, validation.DNS1123SubdomainMaxLength)
+		if err != nil {
+			panic(err)
+		}
+		name = shortName
+	}
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(GroupVersionKind)
+	snapshot.SetNamespace(application.Namespace)
+	snapshot.SetName(name)
+	snapshot.SetAnnotations(map[string]string{AnnotationKeyOrdinal: strconv.Itoa(int(ordinal))})
+	resourceutils.SetApplicationLabels(snapshot, application)
+	snapshot.Object["spec"] = map[string]any{
+		"volumeSnapshotClassName": application.Spec.Stateful.Backup.VolumeSnapshotClassName,
+		"source": map[string]any{
+			"persistentVolumeClaimName": claimName,
+		},
+	}
+	return snapshot
+}
+
+// DataSource references the VolumeSnapshot name as the data source of a PVC
+func DataSource(name string) *corev1.TypedLocalObjectReference {
+	return &corev1.TypedLocalObjectReference{
+		APIGroup: &GroupVersionKind.Group,
+		Kind:     GroupVersionKind.Kind,
+		Name:     name,
+	}
+}
+
+// ClaimName returns the name of the PVC the snapshot was taken of
+func ClaimName(snapshot *unstructured.Unstructured) string {
+	name, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+	return name
+}
+
+// Ordinal returns the ordinal of the replica whose PVC the snapshot was taken of
+func Ordinal(snapshot *unstructured.Unstructured) int32 {
+	ordinal, _ := strconv.ParseInt(snapshot.GetAnnotations()[AnnotationKeyOrdinal], 10, 32)
+	return int32(ordinal)
+}
+
+// IsReady reports whether the snapshot has been taken and can be restored from
+func IsReady(snapshot *unstructured.Unstructured) bool {
+	ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	return ready
+}
+
+// CreationTime returns the time the snapshot was taken, or the time the object was created if the
+// snapshot has not been taken yet
+func CreationTime(snapshot *unstructured.Unstructured) metav1.Time {
+	creationTime, _, _ := unstructured.NestedString(snapshot.Object, "status", "creationTime")
+	if t, err := time.Parse(time.RFC3339, creationTime); err == nil {
+		return metav1.NewTime(t)
+	}
+	return snapshot.GetCreationTimestamp()
+}
@@ -4,15 +4,22 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
 	"reflect"
 
 	"github.com/kartverket/skiperator/pkg/mesh"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// errRecreatePending is returned by patch when the existing object has been deleted to recreate it. The new object
+// is created on a later reconcile, once the deletion has finished.
+var errRecreatePending = goerrors.New("object is being deleted to recreate it")
+
 func (r *ResourceProcessor) create(ctx context.Context, obj client.Object) error {
 	createObj := obj.DeepCopyObject().(client.Object) //copy so we keep gvk
 	err := r.client.Create(ctx, createObj)
@@ -58,6 +65,11 @@ func (r *ResourceProcessor) patch(ctx context.Context, newObj client.Object) err
 		r.log.Error(err, "Failed to get object, for unknown reason")
 	}
 
+	if requireRecreate(newObj, existing) {
+		r.log.Info("Immutable fields changed, recreating object", "kind", newObj.GetObjectKind().GroupVersionKind().Kind, "name", newObj.GetName())
+		return r.recreate(ctx, existing)
+	}
+
 	preparePatch(newObj, existing)
 
 	identical := isObjectIdentical(newObj, existing)
@@ -73,8 +85,21 @@ func (r *ResourceProcessor) patch(ctx context.Context, newObj client.Object) err
 	return nil
 }
 
+// recreate deletes the existing object, so the new one can be created on a later reconcile once it is gone.
+// Foreground propagation removes the Pods of a Job before the Job itself, so the Pods of the old and the new Job
+// never run at the same time.
+func (r *ResourceProcessor) recreate(ctx context.Context, existing client.Object) error {
+	if existing.GetDeletionTimestamp() == nil {
+		if err := r.client.Delete(ctx, existing, client.PropagationPolicy(metav1.DeletePropagationForeground)); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete object for recreation: %w", err)
+		}
+	}
+	return errRecreatePending
+}
+
 func (r *ResourceProcessor) delete(ctx context.Context, resource client.Object) error {
-	err := r.client.Delete(ctx, resource)
+	// Jobs orphan their pods by default
+	err := r.client.Delete(ctx, resource, client.PropagationPolicy(metav1.DeletePropagationBackground))
 	if err != nil && errors.IsNotFound(err) {
 		return nil
 	}
@@ -95,6 +120,10 @@ func (r *ResourceProcessor) listResourcesByLabels(ctx context.Context, namespace
 
 	for _, schema := range r.schemas {
 		if err := r.client.List(ctx, &schema, listOpts); err != nil {
+			// Optional CRDs, like ExternalSecret, may not be installed. Nothing can have been generated for them then.
+			if meta.IsNoMatchError(err) {
+				continue
+			}
 			return fmt.Errorf("failed to list resources: %w", err)
 		}
 		for _, resource := range schema.Items {
@@ -130,6 +159,9 @@ func isObjectIdentical(resource, existing client.Object) bool {
 }
 
 func getSpecHash(obj client.Object) (string, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return getUnstructuredSpecHash(u)
+	}
 	val := reflect.ValueOf(obj).Elem().FieldByName("Spec")
 	if !val.IsValid() {
 		return "", nil
@@ -141,3 +173,16 @@ func getSpecHash(obj client.Object) (string, error) {
 	hash := sha256.Sum256(specBytes)
 	return fmt.Sprintf("%x", hash), nil
 }
+
+func getUnstructuredSpecHash(obj *unstructured.Unstructured) (string, error) {
+	spec, ok := obj.Object["spec"]
+	if !ok {
+		return "", nil
+	}
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(specBytes)
+	return fmt.Sprintf("%x", hash), nil
+}
@@ -0,0 +1,112 @@
+package resourceprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kartverket/skiperator/pkg/resourceschemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestPatchRecreatesJobAfterForegroundDeletion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	existing := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "test"},
+		Spec:       batchv1.JobSpec{Completions: new(int32(1))},
+	}
+	var propagation *metav1.DeletionPropagation
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).WithInterceptorFuncs(interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deleteOptions := &client.DeleteOptions{}
+			deleteOptions.ApplyOptions(opts)
+			propagation = deleteOptions.PropagationPolicy
+			return c.Delete(ctx, obj, opts...)
+		},
+	}).Build()
+	processor := NewResourceProcessor(c, nil, scheme)
+
+	changed := existing.DeepCopy()
+	changed.ResourceVersion = ""
+	changed.Spec.Completions = new(int32(4))
+	assert.ErrorIs(t, processor.patch(context.Background(), changed), errRecreatePending)
+
+	require.NotNil(t, propagation)
+	assert.Equal(t, metav1.DeletePropagationForeground, *propagation)
+	assert.True(t, errors.IsNotFound(c.Get(context.Background(), client.ObjectKeyFromObject(existing), &batchv1.Job{})))
+
+	// The Job is created on the next reconcile, once the old one is gone
+	require.NoError(t, processor.patch(context.Background(), changed))
+	recreated := &batchv1.Job{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(existing), recreated))
+	assert.Equal(t, int32(4), *recreated.Spec.Completions)
+}
+
+func TestPatchWaitsForJobBeingDeleted(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	existing := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "test", Finalizers: []string{metav1.FinalizerDeleteDependents}},
+		Spec:       batchv1.JobSpec{Completions: new(int32(1))},
+	}
+	deletes := 0
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).WithInterceptorFuncs(interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deletes++
+			return c.Delete(ctx, obj, opts...)
+		},
+	}).Build()
+	processor := NewResourceProcessor(c, nil, scheme)
+
+	changed := existing.DeepCopy()
+	changed.ResourceVersion = ""
+	changed.Finalizers = nil
+	changed.Spec.Completions = new(int32(4))
+	assert.ErrorIs(t, processor.patch(context.Background(), changed), errRecreatePending)
+
+	// The Pods of the old Job are still being removed, so it is neither deleted again nor replaced yet
+	assert.ErrorIs(t, processor.patch(context.Background(), changed), errRecreatePending)
+	assert.Equal(t, 1, deletes)
+	pending := &batchv1.Job{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(existing), pending))
+	assert.Equal(t, int32(1), *pending.Spec.Completions)
+}
+
+func TestPatchRecreatesJobWhenPodFailurePolicyChanges(t *testing.T) {
+	scheme := runtime.NewScheme()
+	resourceschemas.AddSchemas(scheme)
+	existing := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "test"},
+		Spec:       batchv1.JobSpec{Completions: new(int32(1))},
+	}
+	deleted := false
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).WithInterceptorFuncs(interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deleted = true
+			return c.Delete(ctx, obj, opts...)
+		},
+	}).Build()
+	processor := NewResourceProcessor(c, nil, scheme)
+
+	changed := existing.DeepCopy()
+	changed.ResourceVersion = ""
+	changed.Spec.PodFailurePolicy = &batchv1.PodFailurePolicy{Rules: []batchv1.PodFailurePolicyRule{{
+		Action:      batchv1.PodFailurePolicyActionFailJob,
+		OnExitCodes: &batchv1.PodFailurePolicyOnExitCodesRequirement{Operator: batchv1.PodFailurePolicyOnExitCodesOpIn, Values: []int32{42}},
+	}}}
+	assert.ErrorIs(t, processor.patch(context.Background(), changed), errRecreatePending)
+	require.NoError(t, processor.patch(context.Background(), changed))
+
+	assert.True(t, deleted)
+	recreated := &batchv1.Job{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(existing), recreated))
+	assert.Equal(t, changed.Spec.PodFailurePolicy, recreated.Spec.PodFailurePolicy)
+}
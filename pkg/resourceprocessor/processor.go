@@ -1,6 +1,8 @@
 package resourceprocessor
 
 import (
+	"errors"
+
 	"github.com/kartverket/skiperator/api/v1alpha1"
 	"github.com/kartverket/skiperator/pkg/log"
 	"github.com/kartverket/skiperator/pkg/reconciliation"
@@ -55,14 +57,18 @@ func (r *ResourceProcessor) Process(task reconciliation.Reconciliation) []error
 		results[obj] = err
 	}
 
-	var errors []error
+	var errs []error
 	for obj, err := range results {
-		if err != nil {
+		switch {
+		case errors.Is(err, errRecreatePending):
+			task.GetSKIPObject().GetStatus().AddSubResourceStatus(obj, "is being recreated", v1alpha1.PROGRESSING)
+			task.SetRecreatePending(true)
+		case err != nil:
 			task.GetSKIPObject().GetStatus().AddSubResourceStatus(obj, err.Error(), v1alpha1.ERROR)
-			errors = append(errors, err)
-		} else {
+			errs = append(errs, err)
+		default:
 			task.GetSKIPObject().GetStatus().AddSubResourceStatus(obj, "has finished synchronizing", v1alpha1.SYNCED)
 		}
 	}
-	return errors
+	return errs
 }
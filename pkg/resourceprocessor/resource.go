@@ -6,6 +6,7 @@ import (
 	"github.com/kartverket/skiperator/pkg/util"
 	v1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -69,10 +70,29 @@ func preparePatch(new client.Object, old client.Object) {
 		maps.Copy(definition.Spec.Template.Labels, job.Spec.Template.Labels) // kubernetes adds labels on creation
 		definition.Spec.Selector = job.Spec.Selector                         // immutable
 		definition.Spec.Template = job.Spec.Template                         // immutable
-		definition.Spec.Completions = job.Spec.Completions                   // immutable
 	}
 }
 
+// requireRecreate reports whether the object has changed in fields that cannot be patched, so the existing
+// object must be deleted and created again
+func requireRecreate(new client.Object, existing client.Object) bool {
+	switch new.(type) {
+	case *batchv1.Job:
+		job := existing.(*batchv1.Job)
+		definition := new.(*batchv1.Job)
+
+		// Completions is immutable for NonIndexed jobs, and completionMode, podFailurePolicy, backoffLimitPerIndex
+		// and successPolicy are always immutable. The pod failure policy is compared with the server defaults, so
+		// a policy that omits them does not recreate the Job on every reconcile.
+		return !equality.Semantic.DeepEqual(definition.Spec.Completions, job.Spec.Completions) ||
+			!equality.Semantic.DeepEqual(definition.Spec.CompletionMode, job.Spec.CompletionMode) ||
+			!equality.Semantic.DeepEqual(util.WithPodFailurePolicyDefaults(definition.Spec.PodFailurePolicy), util.WithPodFailurePolicyDefaults(job.Spec.PodFailurePolicy)) ||
+			!equality.Semantic.DeepEqual(definition.Spec.BackoffLimitPerIndex, job.Spec.BackoffLimitPerIndex) ||
+			!equality.Semantic.DeepEqual(definition.Spec.SuccessPolicy, job.Spec.SuccessPolicy)
+	}
+	return false
+}
+
 func hasGVK(resources []client.Object) bool {
 	for _, obj := range resources {
 		gvk := (obj).GetObjectKind().GroupVersionKind().Kind
@@ -1,8 +1,10 @@
 package resourceprocessor
 
 import (
+	"github.com/kartverket/skiperator/pkg/util"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"testing"
 )
@@ -17,3 +19,51 @@ func TestRequirePatch(t *testing.T) {
 	assert.True(t, deplShouldPatch)
 	assert.False(t, saShouldPatch)
 }
+
+func TestRequireRecreate_Job(t *testing.T) {
+	existing := &batchv1.Job{Spec: batchv1.JobSpec{
+		Completions:    util.PointTo(int32(1)),
+		CompletionMode: util.PointTo(batchv1.NonIndexedCompletion),
+	}}
+
+	unchanged := existing.DeepCopy()
+	unchanged.Spec.Parallelism = util.PointTo(int32(2))
+	assert.False(t, requireRecreate(unchanged, existing))
+
+	moreCompletions := existing.DeepCopy()
+	moreCompletions.Spec.Completions = util.PointTo(int32(4))
+	assert.True(t, requireRecreate(moreCompletions, existing))
+
+	indexed := existing.DeepCopy()
+	indexed.Spec.CompletionMode = util.PointTo(batchv1.IndexedCompletion)
+	assert.True(t, requireRecreate(indexed, existing))
+
+	podFailurePolicy := existing.DeepCopy()
+	podFailurePolicy.Spec.PodFailurePolicy = &batchv1.PodFailurePolicy{Rules: []batchv1.PodFailurePolicyRule{{
+		Action:      batchv1.PodFailurePolicyActionFailJob,
+		OnExitCodes: &batchv1.PodFailurePolicyOnExitCodesRequirement{Operator: batchv1.PodFailurePolicyOnExitCodesOpIn, Values: []int32{42}},
+	}}}
+	assert.True(t, requireRecreate(podFailurePolicy, existing))
+
+	// The API server defaults the status of onPodConditions patterns to True
+	withDefaults := existing.DeepCopy()
+	withDefaults.Spec.PodFailurePolicy = &batchv1.PodFailurePolicy{Rules: []batchv1.PodFailurePolicyRule{{
+		Action:          batchv1.PodFailurePolicyActionIgnore,
+		OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue}},
+	}}}
+	omitsStatus := withDefaults.DeepCopy()
+	omitsStatus.Spec.PodFailurePolicy.Rules[0].OnPodConditions[0].Status = ""
+	assert.False(t, requireRecreate(omitsStatus, withDefaults))
+	omitsStatus.Spec.PodFailurePolicy.Rules[0].OnPodConditions[0].Status = corev1.ConditionFalse
+	assert.True(t, requireRecreate(omitsStatus, withDefaults))
+
+	backoffLimitPerIndex := existing.DeepCopy()
+	backoffLimitPerIndex.Spec.BackoffLimitPerIndex = util.PointTo(int32(2))
+	assert.True(t, requireRecreate(backoffLimitPerIndex, existing))
+
+	successPolicy := existing.DeepCopy()
+	successPolicy.Spec.SuccessPolicy = &batchv1.SuccessPolicy{Rules: []batchv1.SuccessPolicyRule{{SucceededCount: util.PointTo(int32(1))}}}
+	assert.True(t, requireRecreate(successPolicy, existing))
+
+	assert.False(t, requireRecreate(&corev1.ServiceAccount{}, &corev1.ServiceAccount{}))
+}
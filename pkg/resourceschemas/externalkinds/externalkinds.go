@@ -0,0 +1,8 @@
+// Package externalkinds holds the GroupVersionKinds of resources owned by other operators whose Go types are
+// not imported, to avoid depending on the operators. Such resources are handled as unstructured objects.
+package externalkinds
+
+import "k8s.io/apimachinery/pkg/runtime/schema"
+
+// ExternalSecret is the External Secrets Operator ExternalSecret
+var ExternalSecret = schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1", Kind: "ExternalSecret"}
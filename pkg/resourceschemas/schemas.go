@@ -10,6 +10,7 @@ import (
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	skiperatorv1alpha1 "github.com/kartverket/skiperator/api/v1alpha1"
 	skiperatorv1beta1 "github.com/kartverket/skiperator/api/v1beta1"
+	"github.com/kartverket/skiperator/pkg/resourceschemas/externalkinds"
 	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
 	pov1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	istionetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
@@ -61,10 +62,19 @@ func addGVKToList(lists []client.ObjectList, scheme *runtime.Scheme) []unstructu
 	return listsWithGVKs
 }
 
+// externalSecretSchema lists ExternalSecrets, which are not in the scheme as the External Secrets
+// Operator types are handled as unstructured objects.
+func externalSecretSchema() unstructured.UnstructuredList {
+	list := unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(externalkinds.ExternalSecret.GroupVersion().WithKind(externalkinds.ExternalSecret.Kind + "List"))
+	return list
+}
+
 func GetApplicationSchemas(scheme *runtime.Scheme) []unstructured.UnstructuredList {
-	return addGVKToList([]client.ObjectList{
+	return append(addGVKToList([]client.ObjectList{
 		&appsv1.DeploymentList{},
 		&appsv1.StatefulSetList{},
+		&batchv1.JobList{},
 		&corev1.ServiceList{},
 		&corev1.ConfigMapList{},
 		&istionetworkingv1.ServiceEntryList{},
@@ -85,11 +95,13 @@ func GetApplicationSchemas(scheme *runtime.Scheme) []unstructured.UnstructuredLi
 		&certmanagerv1.CertificateList{},
 		&gatewayapiv1.ListenerSetList{},
 		&gatewayapiv1.HTTPRouteList{},
-	}, scheme)
+		&gatewayapiv1.GRPCRouteList{},
+		&gatewayapiv1.TLSRouteList{},
+	}, scheme), externalSecretSchema())
 }
 
 func GetJobSchemas(scheme *runtime.Scheme) []unstructured.UnstructuredList {
-	return addGVKToList([]client.ObjectList{
+	return append(addGVKToList([]client.ObjectList{
 		&batchv1.CronJobList{},
 		&batchv1.JobList{},
 		&networkingv1.NetworkPolicyList{},
@@ -98,7 +110,7 @@ func GetJobSchemas(scheme *runtime.Scheme) []unstructured.UnstructuredList {
 		&telemetryv1.TelemetryList{},
 		&corev1.ConfigMapList{},
 		&pov1.PodMonitorList{},
-	}, scheme)
+	}, scheme), externalSecretSchema())
 }
 
 func GetRoutingSchemas(scheme *runtime.Scheme) []unstructured.UnstructuredList {
@@ -5,7 +5,12 @@ import (
 	"encoding/json"
 
 	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/k8s-digester/pkg/keychain"
 	"github.com/google/k8s-digester/pkg/resolve"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
@@ -29,6 +34,50 @@ func ResolveImageTags(ctx context.Context, log logr.Logger, config *rest.Config,
 	return nil
 }
 
+// ResolveImageDigest returns the image pinned to the digest its tag points to now, using the registry credentials
+// of obj. Images that are already pinned are returned as they are.
+func ResolveImageDigest(ctx context.Context, log logr.Logger, config *rest.Config, obj client.Object, image string) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := ref.(name.Digest); ok {
+		return image, nil
+	}
+
+	keychain, err := NewKeychain(ctx, log, config, obj)
+	if err != nil {
+		return "", err
+	}
+	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(keychain))
+	if err != nil {
+		return "", err
+	}
+	return image + "@" + desc.Digest.String(), nil
+}
+
+// PinImages replaces the images of the containers in spec with the digest-pinned images they map to in pinned.
+// ResolveImageTags leaves images that are already pinned as they are.
+func PinImages(spec *corev1.PodSpec, pinned map[string]string) {
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			if image, ok := pinned[containers[i].Image]; ok {
+				containers[i].Image = image
+			}
+		}
+	}
+}
+
+// NewKeychain returns the registry credentials used when resolving the images of obj,
+// including image pull secrets from the namespace's service account.
+func NewKeychain(ctx context.Context, log logr.Logger, config *rest.Config, obj client.Object) (authn.Keychain, error) {
+	n, err := parseManifest(obj)
+	if err != nil {
+		return nil, err
+	}
+	return keychain.Create(ctx, log, config, n)
+}
+
 func parseManifest(obj client.Object) (*yaml.RNode, error) {
 	m, err := json.Marshal(obj)
 	if err != nil {
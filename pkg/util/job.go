@@ -0,0 +1,24 @@
+package util
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// WithPodFailurePolicyDefaults returns a copy of the pod failure policy with the defaults the API server sets,
+// so a generated policy compares equal to the one on the Job. The status of onPodConditions patterns defaults
+// to True.
+func WithPodFailurePolicyDefaults(policy *batchv1.PodFailurePolicy) *batchv1.PodFailurePolicy {
+	if policy == nil {
+		return nil
+	}
+	defaulted := policy.DeepCopy()
+	for i := range defaulted.Rules {
+		for j := range defaulted.Rules[i].OnPodConditions {
+			if defaulted.Rules[i].OnPodConditions[j].Status == "" {
+				defaulted.Rules[i].OnPodConditions[j].Status = corev1.ConditionTrue
+			}
+		}
+	}
+	return defaulted
+}